@@ -0,0 +1,555 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/auth"
+	"github.com/NickPresta/gowave/wave/backup"
+	"github.com/NickPresta/gowave/wave/importer"
+)
+
+// resources are the command tree of gowave, one resource per service.
+var resources = []*resource{
+	{
+		name:    "accounts",
+		summary: "Accounts in the chart of accounts of a business",
+		commands: []*command{
+			{name: "list", summary: "List the accounts of a business", scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Accounts.ListContext(ctx, inv.business)
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get an account", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Accounts.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create an account", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				account := new(wave.Account)
+				if err := inv.body(account); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Accounts.CreateContext(ctx, inv.business, account)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of an account", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				account := new(wave.Account)
+				if err := inv.body(account); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Accounts.ReplaceContext(ctx, inv.business, inv.id(0), account)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of an account", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				account := new(wave.Account)
+				if err := inv.body(account); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Accounts.UpdateContext(ctx, inv.business, inv.id(0), account)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete an account", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Accounts.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+		},
+	},
+	{
+		name:    "auth",
+		summary: "Logging in to Wave with the current profile",
+		commands: []*command{
+			{name: "login", summary: "Log in to Wave and store the token of the profile", quiet: true, local: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				if current.ClientID == "" || current.ClientSecret == "" {
+					return usagef("profile %q has no client_id and client_secret; add them to %v or give -id and -secret", current.name, current.configPath)
+				}
+				config, err := current.authConfig()
+				if err != nil {
+					return err
+				}
+				if err := login(ctx, config); err != nil {
+					return err
+				}
+				log.Printf("logged in to profile %q", current.name)
+				return nil
+			})},
+			{name: "logout", summary: "Delete the stored token of the profile", quiet: true, local: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				if err := auth.NewFileStore(current.tokenPath()).Delete(); err != nil {
+					return err
+				}
+				log.Printf("logged out of profile %q", current.name)
+				return nil
+			})},
+			{name: "status", summary: "Show the profile and whether it is logged in", local: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				return inv.output(status(ctx))
+			})},
+		},
+	},
+	{
+		name:    "backup",
+		summary: "Archives of everything a business holds",
+		commands: []*command{
+			{name: "create", summary: "Export the business to a zip archive at file", args: []string{"file"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				snapshot, err := backup.Fetch(ctx, inv.client, inv.business)
+				if err != nil {
+					return err
+				}
+				manifest, err := snapshot.WriteFile(inv.args[0])
+				return inv.output(manifest, nil, err)
+			})},
+			{name: "inspect", summary: "Check an archive and show its manifest", args: []string{"file"}, local: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, manifest, err := backup.ReadFile(inv.args[0])
+				return inv.output(manifest, nil, err)
+			})},
+			restoreCommand(),
+		},
+	},
+	{
+		name:    "bills",
+		summary: "Bills owed by a business to its vendors",
+		commands: []*command{
+			{name: "list", summary: "List the bills of a business", scoped: true, paged: true, setup: func(fs *flag.FlagSet) runFunc {
+				vendor := fs.Uint64("vendor", 0, "Only list bills owed to the vendor with this ID")
+				var start, end dateFlag
+				fs.Var(&start, "due-start", "Only list bills due on or after this `date`")
+				fs.Var(&end, "due-end", "Only list bills due on or before this `date`")
+				return func(ctx context.Context, inv *invocation) error {
+					opts := &wave.BillListOptions{VendorID: *vendor, DueDateStart: start.date, DueDateEnd: end.date, PageOptions: inv.page}
+					v, resp, err := inv.client.Bills.ListContext(ctx, inv.business, opts)
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "get", summary: "Get a bill", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Bills.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create a bill", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				bill := new(wave.Bill)
+				if err := inv.body(bill); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Bills.CreateContext(ctx, inv.business, bill)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of a bill", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				bill := new(wave.Bill)
+				if err := inv.body(bill); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Bills.ReplaceContext(ctx, inv.business, inv.id(0), bill)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of a bill", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				bill := new(wave.Bill)
+				if err := inv.body(bill); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Bills.UpdateContext(ctx, inv.business, inv.id(0), bill)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete a bill", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Bills.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+			{name: "payments", summary: "List the payments made against a bill", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Bills.ListPaymentsContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "pay", summary: "Record a payment against a bill", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				payment := new(wave.BillPayment)
+				if err := inv.body(payment); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Bills.RecordPaymentContext(ctx, inv.business, inv.id(0), payment)
+				return inv.output(v, resp, err)
+			})},
+		},
+	},
+	{
+		name:    "businesses",
+		summary: "Businesses owned by the user",
+		commands: []*command{
+			{name: "list", summary: "List your businesses", paged: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Businesses.ListContext(ctx, &wave.BusinessListOptions{PageOptions: inv.page})
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get a business", args: []string{"business-id"}, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Businesses.GetContext(ctx, inv.args[0])
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create a business", body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				business := new(wave.Business)
+				if err := inv.body(business); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Businesses.CreateContext(ctx, business)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of a business", args: []string{"business-id"}, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				business := new(wave.Business)
+				if err := inv.body(business); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Businesses.ReplaceContext(ctx, inv.args[0], business)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of a business", args: []string{"business-id"}, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				business := new(wave.Business)
+				if err := inv.body(business); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Businesses.UpdateContext(ctx, inv.args[0], business)
+				return inv.output(v, resp, err)
+			})},
+		},
+	},
+	{
+		name:    "countries",
+		summary: "Countries known to Wave",
+		commands: []*command{
+			{name: "list", summary: "List the countries", setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Countries.ListContext(ctx)
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get a country", args: []string{"country-code"}, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Countries.GetContext(ctx, inv.args[0])
+				return inv.output(v, resp, err)
+			})},
+			{name: "provinces", summary: "List the provinces of a country, from the bundled ISO data unless -live", args: []string{"country-code"}, local: true, setup: func(fs *flag.FlagSet) runFunc {
+				live := fs.Bool("live", false, "Ask the API for the provinces Wave knows about instead")
+				return func(ctx context.Context, inv *invocation) error {
+					if !*live {
+						country := wave.BundledReference().Country(inv.args[0])
+						if country == nil {
+							return fmt.Errorf("country %q: %w", inv.args[0], wave.ErrNotFound)
+						}
+						provinces := make([]isoProvince, len(country.Provinces))
+						for i, p := range country.Provinces {
+							provinces[i] = isoProvince{p.Name, p.Slug, p.Code}
+						}
+						return inv.output(provinces, nil, nil)
+					}
+					client, err := newClient(ctx)
+					if err != nil {
+						return fmt.Errorf("could not authenticate: %w", err)
+					}
+					v, resp, err := client.Countries.GetContext(ctx, inv.args[0])
+					if err != nil {
+						return err
+					}
+					return inv.output(v.Provinces, resp, nil)
+				}
+			}},
+		},
+	},
+	{
+		name:    "currencies",
+		summary: "Currencies known to Wave",
+		commands: []*command{
+			{name: "list", summary: "List the currencies", setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Currencies.ListContext(ctx)
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get a currency", args: []string{"code"}, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Currencies.GetContext(ctx, inv.args[0])
+				return inv.output(v, resp, err)
+			})},
+		},
+	},
+	{
+		name:    "customers",
+		summary: "Customers of a business",
+		commands: []*command{
+			{name: "list", summary: "List the customers of a business", scoped: true, paged: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Customers.ListContext(ctx, inv.business, &wave.CustomerListOptions{PageOptions: inv.page})
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get a customer", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Customers.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create a customer", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				customer := new(wave.Customer)
+				if err := inv.body(customer); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Customers.CreateContext(ctx, inv.business, customer)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of a customer", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				customer := new(wave.Customer)
+				if err := inv.body(customer); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Customers.ReplaceContext(ctx, inv.business, inv.id(0), customer)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of a customer", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				customer := new(wave.Customer)
+				if err := inv.body(customer); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Customers.UpdateContext(ctx, inv.business, inv.id(0), customer)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete a customer", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Customers.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+			importCommand("customers", (*importer.Importer).Customers),
+		},
+	},
+	{
+		name:    "diff",
+		summary: "Differences between two businesses",
+		command: diffCommand(),
+	},
+	{
+		name:    "invoices",
+		summary: "Invoices sent by a business to its customers",
+		commands: []*command{
+			{name: "list", summary: "List the invoices of a business", scoped: true, paged: true, setup: func(fs *flag.FlagSet) runFunc {
+				status := fs.String("status", "", "Only list invoices with this status, such as paid")
+				customer := fs.Uint64("customer", 0, "Only list invoices for the customer with this ID")
+				var start, end dateFlag
+				fs.Var(&start, "start", "Only list invoices dated on or after this `date`")
+				fs.Var(&end, "end", "Only list invoices dated on or before this `date`")
+				return func(ctx context.Context, inv *invocation) error {
+					opts := &wave.InvoiceListOptions{Status: *status, CustomerID: *customer, InvoiceDateStart: start.date, InvoiceDateEnd: end.date, PageOptions: inv.page}
+					v, resp, err := inv.client.Invoices.ListContext(ctx, inv.business, opts)
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "get", summary: "Get an invoice", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Invoices.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create an invoice", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				invoice := new(wave.Invoice)
+				if err := inv.body(invoice); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Invoices.CreateContext(ctx, inv.business, invoice)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of an invoice", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				invoice := new(wave.Invoice)
+				if err := inv.body(invoice); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Invoices.ReplaceContext(ctx, inv.business, inv.id(0), invoice)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of an invoice", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				invoice := new(wave.Invoice)
+				if err := inv.body(invoice); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Invoices.UpdateContext(ctx, inv.business, inv.id(0), invoice)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete an invoice", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Invoices.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+			{name: "approve", summary: "Approve a draft invoice", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Invoices.ApproveContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "send", summary: "Email an invoice to the customer", args: []string{"id"}, scoped: true, setup: func(fs *flag.FlagSet) runFunc {
+				var to listFlag
+				fs.Var(&to, "to", "Send to this email `address` rather than the customer's; may be repeated")
+				subject := fs.String("subject", "", "Subject of the email")
+				message := fs.String("message", "", "Message of the email")
+				cc := fs.Bool("cc", false, "Send a copy to yourself")
+				return func(ctx context.Context, inv *invocation) error {
+					opts := &wave.InvoiceSendOptions{}
+					opts.To = to
+					if *subject != "" {
+						opts.Subject = subject
+					}
+					if *message != "" {
+						opts.Message = message
+					}
+					if *cc {
+						opts.CCMyself = cc
+					}
+					v, resp, err := inv.client.Invoices.SendContext(ctx, inv.business, inv.id(0), opts)
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "mark-sent", summary: "Mark an invoice as sent without emailing it", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Invoices.MarkSentContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "pay", summary: "Record a payment against an invoice", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				payment := new(wave.InvoicePayment)
+				if err := inv.body(payment); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Invoices.RecordPaymentContext(ctx, inv.business, inv.id(0), payment)
+				return inv.output(v, resp, err)
+			})},
+		},
+	},
+	{
+		name:    "products",
+		summary: "Products and services bought or sold by a business",
+		commands: []*command{
+			{name: "list", summary: "List the products of a business", scoped: true, paged: true, setup: func(fs *flag.FlagSet) runFunc {
+				embed := fs.Bool("embed-accounts", false, "Include the income and expense accounts in full")
+				return func(ctx context.Context, inv *invocation) error {
+					opts := &wave.ProductListOptions{EmbedAccounts: *embed, PageOptions: inv.page}
+					v, resp, err := inv.client.Products.ListContext(ctx, inv.business, opts)
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "get", summary: "Get a product", args: []string{"id"}, scoped: true, setup: func(fs *flag.FlagSet) runFunc {
+				embed := fs.Bool("embed-accounts", false, "Include the income and expense accounts in full")
+				return func(ctx context.Context, inv *invocation) error {
+					v, resp, err := inv.client.Products.GetContext(ctx, inv.business, inv.id(0), &wave.ProductGetOptions{EmbedAccounts: *embed})
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "create", summary: "Create a product", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				product := new(wave.Product)
+				if err := inv.body(product); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Products.CreateContext(ctx, inv.business, product)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of a product", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				product := new(wave.Product)
+				if err := inv.body(product); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Products.ReplaceContext(ctx, inv.business, inv.id(0), product)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of a product", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				product := new(wave.Product)
+				if err := inv.body(product); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Products.UpdateContext(ctx, inv.business, inv.id(0), product)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete a product", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Products.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+			importCommand("products", (*importer.Importer).Products),
+		},
+	},
+	{
+		name:    "transactions",
+		summary: "Journal entries of a business",
+		commands: []*command{
+			{name: "list", summary: "List the transactions of a business", scoped: true, paged: true, setup: func(fs *flag.FlagSet) runFunc {
+				account := fs.Int("account", 0, "Only list transactions with a line against the account with this ID")
+				var start, end dateFlag
+				fs.Var(&start, "start", "Only list transactions dated on or after this `date`")
+				fs.Var(&end, "end", "Only list transactions dated on or before this `date`")
+				return func(ctx context.Context, inv *invocation) error {
+					opts := &wave.TransactionListOptions{AccountID: *account, DateStart: start.date, DateEnd: end.date, PageOptions: inv.page}
+					v, resp, err := inv.client.Transactions.ListContext(ctx, inv.business, opts)
+					return inv.output(v, resp, err)
+				}
+			}},
+			{name: "get", summary: "Get a transaction", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Transactions.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Post a balanced transaction", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				transaction := new(wave.Transaction)
+				if err := inv.body(transaction); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Transactions.CreateContext(ctx, inv.business, transaction)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete a transaction", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Transactions.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+		},
+	},
+	{
+		name:    "user",
+		summary: "The authenticated user",
+		commands: []*command{
+			{name: "get", summary: "Get the authenticated user", setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Users.GetContext(ctx)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of the authenticated user", body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				user := new(wave.User)
+				if err := inv.body(user); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Users.ReplaceContext(ctx, user)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of the authenticated user", body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				user := new(wave.User)
+				if err := inv.body(user); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Users.UpdateContext(ctx, user)
+				return inv.output(v, resp, err)
+			})},
+		},
+	},
+	{
+		name:    "vendors",
+		summary: "Vendors a business buys from",
+		commands: []*command{
+			{name: "list", summary: "List the vendors of a business", scoped: true, paged: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Vendors.ListContext(ctx, inv.business, &wave.VendorListOptions{PageOptions: inv.page})
+				return inv.output(v, resp, err)
+			})},
+			{name: "get", summary: "Get a vendor", args: []string{"id"}, scoped: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				v, resp, err := inv.client.Vendors.GetContext(ctx, inv.business, inv.id(0))
+				return inv.output(v, resp, err)
+			})},
+			{name: "create", summary: "Create a vendor", scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				vendor := new(wave.Vendor)
+				if err := inv.body(vendor); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Vendors.CreateContext(ctx, inv.business, vendor)
+				return inv.output(v, resp, err)
+			})},
+			{name: "replace", summary: "Replace all the fields of a vendor", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				vendor := new(wave.Vendor)
+				if err := inv.body(vendor); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Vendors.ReplaceContext(ctx, inv.business, inv.id(0), vendor)
+				return inv.output(v, resp, err)
+			})},
+			{name: "update", summary: "Update the given fields of a vendor", args: []string{"id"}, scoped: true, body: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				vendor := new(wave.Vendor)
+				if err := inv.body(vendor); err != nil {
+					return err
+				}
+				v, resp, err := inv.client.Vendors.UpdateContext(ctx, inv.business, inv.id(0), vendor)
+				return inv.output(v, resp, err)
+			})},
+			{name: "delete", summary: "Delete a vendor", args: []string{"id"}, scoped: true, quiet: true, setup: do(func(ctx context.Context, inv *invocation) error {
+				_, err := inv.client.Vendors.DeleteContext(ctx, inv.business, inv.id(0))
+				return err
+			})},
+		},
+	},
+}
+
+// isoProvince is a province of the bundled data as countries provinces
+// prints it, with the ISO 3166-2 code that wave.Province leaves out of JSON.
+type isoProvince struct {
+	Name *string `json:"name"`
+	Slug *string `json:"slug"`
+	Code *string `json:"code,omitempty"`
+}
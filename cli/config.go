@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/auth"
+	"golang.org/x/oauth2"
+)
+
+// defaultProfile is the profile used when none is selected.
+const defaultProfile = "default"
+
+// defaultRedirectURL is where Wave sends the user back to after login, unless
+// the profile gives its own.
+const defaultRedirectURL = "https://wave-portal.ngrok.com/oauth2"
+
+// configFile is the gowave config file, which holds named profiles:
+//
+//	{
+//	  "default_profile": "work",
+//	  "profiles": {
+//	    "work": {
+//	      "client_id": "...",
+//	      "client_secret": "...",
+//	      "business": "..."
+//	    }
+//	  }
+//	}
+type configFile struct {
+	DefaultProfile string              `json:"default_profile,omitempty"`
+	Profiles       map[string]*profile `json:"profiles"`
+}
+
+// profile is the account gowave talks to: the OAuth2 application it logs in
+// with, the business used when -business is not given, and the API it talks
+// to. Tokens are kept per profile, next to the config file.
+type profile struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	RedirectURL  string `json:"redirect_url,omitempty"`
+	Business     string `json:"business,omitempty"`
+	BaseURL      string `json:"base_url,omitempty"`
+
+	// AuthURL and TokenURL are the OAuth2 endpoints of the API. They default
+	// to oauth2/authorize/ and oauth2/token/ under BaseURL, if it is set, and
+	// to those of Wave otherwise.
+	AuthURL  string `json:"auth_url,omitempty"`
+	TokenURL string `json:"token_url,omitempty"`
+
+	name       string
+	configPath string
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// current is the profile selected with -profile or $GOWAVE_PROFILE, with the
+// credential flags applied.
+var current *profile
+
+// configPath returns the path of the config file: -config if given, and
+// gowave/config.json in the user's config directory ($XDG_CONFIG_HOME or
+// ~/.config on Linux) otherwise.
+func configPath() (string, error) {
+	if *configFlag != "" {
+		return *configFlag, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gowave", "config.json"), nil
+}
+
+// loadProfile reads the config file and selects the profile given by
+// -profile, $GOWAVE_PROFILE or the default_profile of the file, in that order.
+// The config file may be missing, and so may the default profile.
+func loadProfile() (*profile, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	config := new(configFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+	}
+
+	name := *profileFlag
+	if name == "" {
+		name = os.Getenv("GOWAVE_PROFILE")
+	}
+	explicit := name != ""
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+	if !profileName.MatchString(name) {
+		return nil, usagef("invalid profile name %q", name)
+	}
+
+	p, ok := config.Profiles[name]
+	if !ok {
+		if explicit && name != defaultProfile {
+			return nil, usagef("no profile %q in %v", name, path)
+		}
+		p = new(profile)
+	}
+	p.name = name
+	p.configPath = path
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "id":
+			p.ClientID = *clientID
+		case "secret":
+			p.ClientSecret = *clientSecret
+		case "access":
+			p.AccessToken = *accessToken
+		case "scope":
+			p.Scope = *scope
+		}
+	})
+	if p.Scope == "" {
+		p.Scope = *scope
+	}
+	if p.RedirectURL == "" {
+		p.RedirectURL = defaultRedirectURL
+	}
+	return p, nil
+}
+
+// tokenPath returns where the token of the profile is kept.
+func (p *profile) tokenPath() string {
+	return filepath.Join(filepath.Dir(p.configPath), "tokens", p.name+".json")
+}
+
+// authConfig returns the OAuth2 config of the profile, which logs in to the
+// API of the profile and keeps its token in the token file of the profile.
+func (p *profile) authConfig() (*auth.Config, error) {
+	endpoint, err := p.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Scopes:       strings.Fields(p.Scope),
+		RedirectURL:  p.RedirectURL,
+		Store:        auth.NewFileStore(p.tokenPath()),
+		Endpoint:     endpoint,
+	}, nil
+}
+
+// endpoint returns the OAuth2 endpoint of the profile.
+func (p *profile) endpoint() (oauth2.Endpoint, error) {
+	endpoint := auth.Endpoint
+	base, err := p.baseURL()
+	if err != nil {
+		return endpoint, err
+	}
+	if base != nil {
+		endpoint.AuthURL = base.ResolveReference(&url.URL{Path: "oauth2/authorize/"}).String()
+		endpoint.TokenURL = base.ResolveReference(&url.URL{Path: "oauth2/token/"}).String()
+	}
+	if p.AuthURL != "" {
+		endpoint.AuthURL = p.AuthURL
+	}
+	if p.TokenURL != "" {
+		endpoint.TokenURL = p.TokenURL
+	}
+	return endpoint, nil
+}
+
+// baseURL returns the API base URL of the profile, or nil for the default.
+func (p *profile) baseURL() (*url.URL, error) {
+	if p.BaseURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(p.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base_url of profile %q: %v", p.name, err)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+// authStatus is printed by "auth status".
+type authStatus struct {
+	Profile    string     `json:"profile"`
+	ConfigFile string     `json:"config_file"`
+	TokenFile  string     `json:"token_file,omitempty"`
+	Business   string     `json:"business,omitempty"`
+	BaseURL    string     `json:"base_url,omitempty"`
+	LoggedIn   bool       `json:"logged_in"`
+	Expiry     *time.Time `json:"expiry,omitempty"`
+	User       *wave.User `json:"user,omitempty"`
+}
+
+// status reports the current profile and, if it has a token, the user it is
+// logged in as.
+func status(ctx context.Context) (*authStatus, *wave.Response, error) {
+	s := &authStatus{
+		Profile:    current.name,
+		ConfigFile: current.configPath,
+		Business:   current.Business,
+		BaseURL:    current.BaseURL,
+	}
+	if current.AccessToken == "" {
+		s.TokenFile = current.tokenPath()
+		token, err := auth.NewFileStore(s.TokenFile).Token()
+		if err == auth.ErrNoToken {
+			return s, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !token.Expiry.IsZero() {
+			s.Expiry = &token.Expiry
+		}
+	}
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, resp, err := client.Users.GetContext(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+	s.LoggedIn = true
+	s.User = user
+	return s, resp, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave/auth"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadProfile(t *testing.T) {
+	Convey("Given a config file with a default profile", t, func() {
+		*configFlag = filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(*configFlag, []byte(`{
+			"default_profile": "work",
+			"profiles": {
+				"default": {"business": "0"},
+				"work": {"business": "1", "scope": "basic user.read"},
+				"sandbox": {"business": "2", "base_url": "http://localhost:8080/api"}
+			}
+		}`), 0600)
+		os.Unsetenv("GOWAVE_PROFILE")
+		defer func() {
+			*configFlag, *profileFlag = "", ""
+			os.Unsetenv("GOWAVE_PROFILE")
+		}()
+
+		Convey("The default profile of the file should be used", func() {
+			p, err := loadProfile()
+			So(err, ShouldBeNil)
+			So(p.name, ShouldEqual, "work")
+			So(p.Business, ShouldEqual, "1")
+			So(p.Scope, ShouldEqual, "basic user.read")
+			So(p.RedirectURL, ShouldEqual, defaultRedirectURL)
+			So(p.tokenPath(), ShouldEqual, filepath.Join(filepath.Dir(*configFlag), "tokens", "work.json"))
+		})
+
+		Convey("$GOWAVE_PROFILE should override the default profile", func() {
+			os.Setenv("GOWAVE_PROFILE", "sandbox")
+			p, err := loadProfile()
+			So(err, ShouldBeNil)
+			So(p.name, ShouldEqual, "sandbox")
+			So(p.Business, ShouldEqual, "2")
+			So(p.Scope, ShouldEqual, "basic")
+		})
+
+		Convey("-profile should override $GOWAVE_PROFILE", func() {
+			os.Setenv("GOWAVE_PROFILE", "sandbox")
+			*profileFlag = "default"
+			p, err := loadProfile()
+			So(err, ShouldBeNil)
+			So(p.name, ShouldEqual, "default")
+			So(p.Business, ShouldEqual, "0")
+		})
+
+		Convey("Unknown and invalid profiles should be usage errors", func() {
+			var usage *usageError
+			for _, name := range []string{"nope", "../work"} {
+				*profileFlag = name
+				_, err := loadProfile()
+				So(errors.As(err, &usage), ShouldBeTrue)
+			}
+			*profileFlag = ""
+			os.Setenv("GOWAVE_PROFILE", "nope")
+			_, err := loadProfile()
+			So(errors.As(err, &usage), ShouldBeTrue)
+		})
+	})
+
+	Convey("Without a config file the default profile should be empty", t, func() {
+		*configFlag = filepath.Join(t.TempDir(), "config.json")
+		defer func() { *configFlag, *profileFlag = "", "" }()
+		os.Unsetenv("GOWAVE_PROFILE")
+
+		p, err := loadProfile()
+		So(err, ShouldBeNil)
+		So(p.name, ShouldEqual, defaultProfile)
+		So(p.Business, ShouldBeBlank)
+
+		*profileFlag = "default"
+		p, err = loadProfile()
+		So(err, ShouldBeNil)
+		So(p.name, ShouldEqual, defaultProfile)
+	})
+}
+
+func TestAuthConfig(t *testing.T) {
+	Convey("The OAuth2 endpoint should be that of Wave by default", t, func() {
+		config, err := (&profile{name: "work"}).authConfig()
+		So(err, ShouldBeNil)
+		So(config.Endpoint, ShouldResemble, auth.Endpoint)
+	})
+
+	Convey("The OAuth2 endpoint should be under the base URL of the profile", t, func() {
+		config, err := (&profile{name: "sandbox", BaseURL: "http://localhost:8080/api"}).authConfig()
+		So(err, ShouldBeNil)
+		So(config.Endpoint.AuthURL, ShouldEqual, "http://localhost:8080/api/oauth2/authorize/")
+		So(config.Endpoint.TokenURL, ShouldEqual, "http://localhost:8080/api/oauth2/token/")
+	})
+
+	Convey("The OAuth2 endpoint should be given by the profile", t, func() {
+		config, err := (&profile{
+			name:     "sandbox",
+			BaseURL:  "http://localhost:8080/",
+			TokenURL: "http://localhost:9090/token",
+		}).authConfig()
+		So(err, ShouldBeNil)
+		So(config.Endpoint.AuthURL, ShouldEqual, "http://localhost:8080/oauth2/authorize/")
+		So(config.Endpoint.TokenURL, ShouldEqual, "http://localhost:9090/token")
+
+		_, err = (&profile{name: "sandbox", BaseURL: "http://[::1"}).authConfig()
+		So(err, ShouldNotBeNil)
+	})
+}
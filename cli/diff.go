@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/NickPresta/gowave/wave/diff"
+)
+
+// diffCommand returns the command of diff, which compares the business with
+// the target business and optionally applies the differences to the target.
+func diffCommand() *command {
+	return &command{
+		summary: "Compare the accounts, customers and products of the business with the target business",
+		args:    []string{"target"},
+		scoped:  true,
+		setup: func(fs *flag.FlagSet) runFunc {
+			apply := fs.Bool("apply", false, "Make the target business match the business")
+			var opts diff.ApplyOptions
+			fs.BoolVar(&opts.Delete, "delete", false, "With -apply, also delete the records which are only in the target business")
+
+			return func(ctx context.Context, inv *invocation) error {
+				if opts.Delete && !*apply {
+					return usagef("-delete is only valid with -apply")
+				}
+				d, err := diff.Compare(ctx, inv.client, inv.business, inv.args[0])
+				if err != nil {
+					return err
+				}
+				if d.Differences == nil {
+					d.Differences = []*diff.Difference{}
+				}
+				if err := inv.output(d.Differences, nil, nil); err != nil {
+					return err
+				}
+				log.Print(d)
+				if !*apply {
+					return nil
+				}
+				err = d.Apply(ctx, inv.client, &opts)
+				for _, difference := range d.Differences {
+					if difference.Err != nil {
+						log.Printf("%v %v: %v", difference.Resource, difference.Key, difference.Err)
+					}
+				}
+				return err
+			}
+		},
+	}
+}
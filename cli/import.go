@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/NickPresta/gowave/wave/importer"
+)
+
+// importCommand returns the "import" verb of customers or products, which
+// imports the file with method, such as (*importer.Importer).Customers.
+func importCommand(noun string, method func(*importer.Importer, context.Context, io.Reader) (*importer.Report, error)) *command {
+	return &command{
+		name:    "import",
+		summary: "Create and update " + noun + " from a CSV file, or - for standard input",
+		args:    []string{"file"},
+		scoped:  true,
+		quiet:   true,
+		setup: func(fs *flag.FlagSet) runFunc {
+			imp := new(importer.Importer)
+			var columns listFlag
+			var report string
+			fs.BoolVar(&imp.DryRun, "dry-run", false, "Only report what would be created and updated")
+			fs.StringVar(&imp.Key, "key", "", "The `field` which matches rows to existing "+noun)
+			fs.IntVar(&imp.Concurrency, "concurrency", importer.DefaultConcurrency, "Number of requests to send at once")
+			fs.Var(&columns, "column", "Map a column to a field, such as E-mail=email; may be repeated")
+			fs.StringVar(&report, "report", "", "Write the report to `file` instead of standard output")
+
+			return func(ctx context.Context, inv *invocation) error {
+				imp.Client = inv.client
+				imp.BusinessID = inv.business
+				imp.Columns = make(map[string]string)
+				for _, c := range columns {
+					i := strings.Index(c, "=")
+					if i <= 0 {
+						return usagef("-column %q is not of the form column=field", c)
+					}
+					imp.Columns[c[:i]] = c[i+1:]
+				}
+
+				in := io.Reader(os.Stdin)
+				if name := inv.args[0]; name != "-" {
+					f, err := os.Open(name)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					in = f
+				}
+				result, err := method(imp, ctx, in)
+				if err != nil {
+					return err
+				}
+
+				out := io.Writer(os.Stdout)
+				if report != "" {
+					f, err := os.Create(report)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					out = f
+				}
+				if err := result.WriteCSV(out); err != nil {
+					return err
+				}
+				log.Print(result)
+				if n := result.Failed(); n > 0 {
+					return fmt.Errorf("%d of %d rows failed", n, len(result.Results))
+				}
+				return nil
+			}
+		},
+	}
+}
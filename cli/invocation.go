@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// resource is a group of commands, such as "customers".
+type resource struct {
+	name     string
+	summary  string
+	commands []*command
+
+	// command, if set, is run without a verb instead, such as "diff".
+	command *command
+}
+
+// runFunc runs a command once its flags have been parsed.
+type runFunc func(ctx context.Context, inv *invocation) error
+
+// command is a verb which can be run on a resource, such as "customers list".
+type command struct {
+	name    string
+	summary string
+	args    []string // the names of the positional arguments; "id" must be a number
+	scoped  bool     // whether the resource belongs to the business given by -business
+	paged   bool     // whether it takes -page and -page-size
+	body    bool     // whether it sends a resource, read from -f and -set
+	quiet   bool     // whether it takes no -output, printing nothing or a report of its own
+	local   bool     // whether it runs without an authenticated client
+
+	// setup defines any flags of its own and returns the func which runs the
+	// command.
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+// do is the setup of a command without flags of its own.
+func do(run runFunc) func(fs *flag.FlagSet) runFunc {
+	return func(fs *flag.FlagSet) runFunc { return run }
+}
+
+// invocation is a command being run, with its flags and arguments parsed.
+type invocation struct {
+	client   *wave.Client
+	cmd      *command
+	fs       *flag.FlagSet
+	args     []string
+	business string
+	page     wave.PageOptions
+	fields   map[string]interface{}
+	printer  printer
+	runner   runFunc
+}
+
+// parse parses the flags and arguments of the command. Any problem is printed
+// along with the usage of the command, and -h returns flag.ErrHelp.
+func (cmd *command) parse(res *resource, args []string) (*invocation, error) {
+	inv := &invocation{cmd: cmd, printer: printer{format: "json"}}
+	name := "gowave " + res.name
+	if cmd.name != "" {
+		name += " " + cmd.name
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	inv.fs = fs
+
+	var file string
+	var sets setFlag
+	if cmd.scoped {
+		fs.StringVar(&inv.business, "business", current.Business, "ID of the business (required unless the profile has one)")
+	}
+	if cmd.paged {
+		fs.IntVar(&inv.page.Page, "page", 0, "Page to fetch")
+		fs.IntVar(&inv.page.PageSize, "page-size", 0, "Number of items per page")
+	}
+	if cmd.body {
+		fs.StringVar(&file, "f", "", "Read the fields from a JSON `file`, or - for standard input")
+		fs.Var(&sets, "set", "Set a field, such as name=Acme or currency.code=USD; the value has the type\nof the field, unless it is a JSON object, array or string, and the flag may\nbe repeated")
+	}
+	if !cmd.quiet {
+		fs.Var(outputFlag{&inv.printer}, "output", "Print the result as `format`: "+strings.Join(formats, ", "))
+		fs.Var(fieldsFlag{&inv.printer}, "fields", "Print only the given comma-separated `fields`, such as id,name,currency.code")
+	}
+	inv.runner = cmd.setup(fs)
+
+	fs.Usage = func() {
+		w := fs.Output()
+		synopsis := ""
+		for _, arg := range cmd.args {
+			synopsis += " <" + arg + ">"
+		}
+		fmt.Fprintf(w, "Usage: %v [flags]%v\n\n%v.\n", name, synopsis, cmd.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(w, "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	inv.args = fs.Args()
+
+	err := inv.check(file, sets)
+	if err != nil {
+		fmt.Fprintf(fs.Output(), "%v\n", err)
+		fs.Usage()
+		return nil, err
+	}
+	return inv, nil
+}
+
+// check validates the arguments and reads the fields of the resource being
+// sent.
+func (inv *invocation) check(file string, sets setFlag) error {
+	cmd := inv.cmd
+	if len(inv.args) != len(cmd.args) {
+		return usagef("%v arguments given, want %v", len(inv.args), len(cmd.args))
+	}
+	for i, arg := range cmd.args {
+		if _, err := strconv.ParseUint(inv.args[i], 10, 64); arg == "id" && err != nil {
+			return usagef("invalid ID %q", inv.args[i])
+		}
+	}
+	if cmd.scoped && inv.business == "" {
+		return usagef("-business is required, since profile %q has no business", current.name)
+	}
+	if !cmd.body {
+		return nil
+	}
+	if file == "" && len(sets) == 0 {
+		return usagef("give the fields with -f or -set")
+	}
+
+	inv.fields = make(map[string]interface{})
+	if file != "" {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return usagef("%v", err)
+		}
+		if err := json.Unmarshal(data, &inv.fields); err != nil {
+			return usagef("%v is not a JSON object: %v", file, err)
+		}
+		if inv.fields == nil {
+			return usagef("%v is not a JSON object: null", file)
+		}
+	}
+	for _, set := range sets {
+		set.apply(inv.fields)
+	}
+	return nil
+}
+
+func (inv *invocation) run(ctx context.Context) error {
+	return inv.runner(ctx, inv)
+}
+
+// id returns the positional argument i as an ID.
+func (inv *invocation) id(i int) uint64 {
+	id, _ := strconv.ParseUint(inv.args[i], 10, 64)
+	return id
+}
+
+// body decodes the fields given with -f and -set into v, which must know
+// about all of them.
+func (inv *invocation) body(v interface{}) error {
+	data, err := json.Marshal(typed(inv.fields, reflect.TypeOf(v)))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return usagef("invalid fields: %v", err)
+	}
+	return nil
+}
+
+// output prints v, the result of the request, unless the request failed.
+func (inv *invocation) output(v interface{}, resp *wave.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if err := inv.printer.print(os.Stdout, v); err != nil {
+		return err
+	}
+	if inv.cmd.paged && resp.NextPage > 0 {
+		log.Printf("%v in total; use -page %v for the next page", resp.TotalCount, resp.NextPage)
+	}
+	return nil
+}
+
+// setFlag collects the fields given with -set.
+type setFlag []field
+
+// field is a name=value pair given with -set.
+type field struct {
+	path  []string
+	value interface{}
+}
+
+// text is a -set value which is not a JSON object, array or string. It takes
+// the type of the field it sets, so that phone_number=5551234567 stays a
+// string while is_sold=true is a bool.
+type text string
+
+func (s *setFlag) String() string {
+	return ""
+}
+
+func (s *setFlag) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i <= 0 {
+		return fmt.Errorf("%q is not of the form name=value", v)
+	}
+	f := field{path: strings.Split(v[:i], "."), value: text(v[i+1:])}
+	if value := v[i+1:]; value != "" && strings.ContainsRune(`{["`, rune(value[0])) {
+		var decoded interface{}
+		if json.Unmarshal([]byte(value), &decoded) == nil {
+			f.value = decoded
+		}
+	}
+	*s = append(*s, f)
+	return nil
+}
+
+// apply sets the field in fields, creating any objects it is nested in.
+func (f field) apply(fields map[string]interface{}) {
+	for _, name := range f.path[:len(f.path)-1] {
+		child, ok := fields[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			fields[name] = child
+		}
+		fields = child
+	}
+	fields[f.path[len(f.path)-1]] = f.value
+}
+
+// typed returns fields with the text values in them given the type of the
+// fields of t that they set: strings for string fields, and JSON otherwise,
+// if they are valid JSON.
+func typed(fields map[string]interface{}, t reflect.Type) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		ft := fieldType(t, name)
+		switch value := value.(type) {
+		case map[string]interface{}:
+			out[name] = typed(value, ft)
+		case text:
+			out[name] = string(value)
+			if ft != nil && ft.Kind() != reflect.String && json.Valid([]byte(value)) {
+				out[name] = json.RawMessage(value)
+			}
+		default:
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// fieldType returns the type of the field of t encoded as name, without
+// pointers, or nil if there is none.
+func fieldType(t reflect.Type, name string) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fname, embedded := jsonName(f)
+		if embedded {
+			if ft := fieldType(f.Type, name); ft != nil {
+				return ft
+			}
+		} else if fname == name {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			return ft
+		}
+	}
+	return nil
+}
+
+// listFlag is a flag which may be repeated to give a list of values.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// dateFlag is a flag holding an optional date, given as 2006-01-02.
+type dateFlag struct {
+	date *wave.Date
+}
+
+func (d *dateFlag) String() string {
+	if d.date == nil {
+		return ""
+	}
+	return time.Time(*d.date).Format("2006-01-02")
+}
+
+func (d *dateFlag) Set(v string) error {
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return fmt.Errorf("%q is not a date of the form 2006-01-02", v)
+	}
+	date := wave.Date(t)
+	d.date = &date
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// parse parses args, a resource, a verb and the rest of a command line, as
+// run does.
+func parse(args ...string) (*invocation, error) {
+	res := findResource(args[0])
+	return res.find(args[1]).parse(res, args[2:])
+}
+
+func TestParse(t *testing.T) {
+	Convey("Given a profile with a business", t, func() {
+		defer silence()()
+		current = &profile{name: "default", Business: "1"}
+		defer func() { current = nil }()
+
+		Convey("Command lines should be checked", func() {
+			for _, test := range []struct {
+				args []string
+				err  string // "" if the command line is valid
+			}{
+				{[]string{"customers", "get", "5"}, ""},
+				{[]string{"customers", "get"}, "0 arguments given, want 1"},
+				{[]string{"customers", "get", "5", "6"}, "2 arguments given, want 1"},
+				{[]string{"customers", "get", "jane"}, `invalid ID "jane"`},
+				{[]string{"customers", "get", "1.5"}, `invalid ID "1.5"`},
+				{[]string{"customers", "list"}, ""},
+				{[]string{"customers", "list", "extra"}, "1 arguments given, want 0"},
+				{[]string{"businesses", "get", "QnVzaW5lc3M6MQ"}, ""},
+				{[]string{"customers", "create"}, "give the fields with -f or -set"},
+				{[]string{"customers", "create", "-set", "name=Jane"}, ""},
+				{[]string{"customers", "create", "-set", "name"}, `invalid value "name" for flag -set: "name" is not of the form name=value`},
+				{[]string{"customers", "create", "-f", "missing.json"}, "open missing.json: no such file or directory"},
+				{[]string{"customers", "delete", "-output", "yaml", "5"}, "flag provided but not defined: -output"},
+				{[]string{"customers", "get", "-output", "xml", "5"}, `invalid value "xml" for flag -output: unknown format "xml", want one of json, jsonl, yaml, table, csv`},
+				{[]string{"user", "get", "-business", "1"}, "flag provided but not defined: -business"},
+			} {
+				_, err := parse(test.args...)
+				if test.err == "" {
+					So(err, ShouldBeNil)
+				} else {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, test.err)
+				}
+			}
+		})
+
+		Convey("Flags should be parsed into the invocation", func() {
+			inv, err := parse("customers", "list", "-business", "2", "-page", "3", "-page-size", "10", "-output", "csv", "-fields", "id,currency.code")
+			So(err, ShouldBeNil)
+			So(inv.business, ShouldEqual, "2")
+			So(inv.page, ShouldResemble, wave.PageOptions{Page: 3, PageSize: 10})
+			So(inv.printer.format, ShouldEqual, "csv")
+			So(inv.printer.fields, ShouldResemble, [][]string{{"id"}, {"currency", "code"}})
+
+			inv, _ = parse("customers", "get", "5")
+			So(inv.business, ShouldEqual, "1")
+			So(inv.id(0), ShouldEqual, 5)
+			So(inv.printer.format, ShouldEqual, "json")
+		})
+
+		Convey("-h should ask for help", func() {
+			_, err := parse("customers", "get", "-h")
+			So(err, ShouldEqual, flag.ErrHelp)
+		})
+
+		Convey("A business should be required without one in the profile", func() {
+			current.Business = ""
+			_, err := parse("customers", "list")
+			var usage *usageError
+			So(errors.As(err, &usage), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, `-business is required, since profile "default" has no business`)
+
+			_, err = parse("businesses", "list")
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestFields(t *testing.T) {
+	Convey("Given a profile with a business", t, func() {
+		defer silence()()
+		current = &profile{name: "default", Business: "1"}
+		defer func() { current = nil }()
+
+		Convey("-set should nest dotted names and read objects, arrays and strings as JSON", func() {
+			inv, err := parse("customers", "create",
+				"-set", "name=Acme",
+				"-set", "currency.code=USD",
+				"-set", "shipping_details.address.city=Ottawa",
+				"-set", "account_number=0042",
+				"-set", `first_name="true"`,
+				"-set", "active=true",
+				"-set", `tags=["a","b"]`,
+				"-set", "website=",
+			)
+			So(err, ShouldBeNil)
+			So(inv.fields, ShouldResemble, map[string]interface{}{
+				"name":             text("Acme"),
+				"currency":         map[string]interface{}{"code": text("USD")},
+				"shipping_details": map[string]interface{}{"address": map[string]interface{}{"city": text("Ottawa")}},
+				"account_number":   text("0042"),
+				"first_name":       "true",
+				"active":           text("true"),
+				"tags":             []interface{}{"a", "b"},
+				"website":          text(""),
+			})
+		})
+
+		Convey("-set values should take the type of the fields they set", func() {
+			inv, err := parse("customers", "update", "-set", "phone_number=5551234567", "-set", "name=true", "-set", "currency.code=123", "5")
+			So(err, ShouldBeNil)
+			var customer wave.Customer
+			So(inv.body(&customer), ShouldBeNil)
+			So(*customer.PhoneNumber, ShouldEqual, "5551234567")
+			So(*customer.Name, ShouldEqual, "true")
+			So(*customer.Currency.Code, ShouldEqual, "123")
+
+			inv, err = parse("products", "update", "-set", "is_sold=true", "-set", "price=12.50", "-set", "name=42", "5")
+			So(err, ShouldBeNil)
+			var product wave.Product
+			So(inv.body(&product), ShouldBeNil)
+			So(*product.IsSold, ShouldBeTrue)
+			So(product.Price.Decimal(), ShouldEqual, "12.50")
+			So(*product.Name, ShouldEqual, "42")
+
+			inv, _ = parse("products", "update", "-set", "is_sold=maybe", "5")
+			So(inv.body(&product).Error(), ShouldStartWith, "invalid fields: ")
+		})
+
+		Convey("A field should replace a value it is nested in", func() {
+			fields := map[string]interface{}{"currency": "USD", "name": "Acme"}
+			field{path: []string{"currency", "code"}, value: "CAD"}.apply(fields)
+			field{path: []string{"name"}, value: nil}.apply(fields)
+			So(fields, ShouldResemble, map[string]interface{}{
+				"currency": map[string]interface{}{"code": "CAD"},
+				"name":     nil,
+			})
+		})
+
+		Convey("-f should read the fields from a file, which -set overrides", func() {
+			file := filepath.Join(t.TempDir(), "customer.json")
+			os.WriteFile(file, []byte(`{"name": "Acme", "currency": {"code": "CAD", "symbol": "$"}}`), 0600)
+			inv, err := parse("customers", "create", "-f", file, "-set", "currency.code=USD")
+			So(err, ShouldBeNil)
+			So(inv.fields, ShouldResemble, map[string]interface{}{
+				"name":     "Acme",
+				"currency": map[string]interface{}{"code": text("USD"), "symbol": "$"},
+			})
+
+			for _, data := range []string{`["Acme"]`, `null`} {
+				os.WriteFile(file, []byte(data), 0600)
+				_, err = parse("customers", "create", "-f", file, "-set", "name=Acme")
+				So(err.Error(), ShouldStartWith, file+" is not a JSON object: ")
+			}
+		})
+
+		Convey("-f - should read the fields from standard input", func() {
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			os.Stdin = r
+			w.WriteString(`{"name": "Acme", "email": "acme@example.com"}`)
+			w.Close()
+
+			inv, err := parse("customers", "update", "-f", "-", "5")
+			So(err, ShouldBeNil)
+			So(inv.fields, ShouldResemble, map[string]interface{}{"name": "Acme", "email": "acme@example.com"})
+			var customer wave.Customer
+			So(inv.body(&customer), ShouldBeNil)
+			So(*customer.Email, ShouldEqual, "acme@example.com")
+
+			inv, _ = parse("customers", "update", "-set", "colour=red", "5")
+			So(inv.body(&customer).Error(), ShouldStartWith, "invalid fields: ")
+		})
+	})
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 
-	"code.google.com/p/goauth2/oauth"
 	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/auth"
+)
+
+// Exit codes.
+const (
+	exitOK       = 0
+	exitError    = 1 // a request or the output failed
+	exitUsage    = 2 // the command line was invalid
+	exitAuth     = 3 // there is no token, or the API rejected it
+	exitNotFound = 4 // the resource does not exist
 )
 
 var (
@@ -18,154 +29,202 @@ var (
 	clientSecret = flag.String("secret", "", "Client secret")
 	accessToken  = flag.String("access", "", "Access token")
 	scope        = flag.String("scope", "basic", "Scope")
-	cachefile    = flag.String("cache", "cache.json", "Token cache file")
-
-	debug = flag.Bool("debug", false, "Sets debug mode")
-
-	page     = flag.Int("page", 1, "Set which page you want to add to the query string")
-	pageSize = flag.Int("pageSize", 1, "How many entires per page")
-
-	businesses = flag.Bool("businesses", false, "LIST your businesses")
-	business   = flag.String("business", "", "GET your business. Takes the ID")
-	currencies = flag.Bool("currencies", false, "LIST the currencies")
-	currency   = flag.String("currency", "", "GET a currency. Takes the code")
-	countries  = flag.Bool("countries", false, "LIST the countries")
-	country    = flag.String("country", "", "GET a country. Takes the code")
-	customers  = flag.String("customers", "", "LIST the customers for a business. Takes the business ID")
-	products   = flag.String("products", "", "LIST the products for a business. Takes the business ID")
-	provinces  = flag.String("provinces", "", "LIST the provinces for a country. Takes the country code")
-	user       = flag.Bool("user", false, "GET the currently authenticated user")
-	accounts   = flag.String("accounts", "", "LIST the accounts for a business. Takes the ID")
+	profileFlag  = flag.String("profile", "", "Profile to use, instead of $GOWAVE_PROFILE or the default profile")
+	configFlag   = flag.String("config", "", "Config `file` (default gowave/config.json in the user config directory)")
+
+	debug = flag.Bool("debug", false, "Log every request with its response, redacting tokens and personal details")
 )
 
-var config *oauth.Config
+// usageError is returned for an invalid command line. The usage of the
+// command is printed along with it.
+type usageError struct {
+	message string
+}
 
-func getToken(t *oauth.Transport) {
-	var c string
-	authURL := config.AuthCodeURL("state")
-	log.Printf("Open in browser: %v\n", authURL)
-	log.Printf("Enter verification code: ")
-	fmt.Scanln(&c)
-	_, err := t.Exchange(c)
-	if err != nil {
-		log.Fatalf("An error occurred exchanging the code: %v\n", err)
-	}
+func (e *usageError) Error() string {
+	return e.message
 }
 
-func main() {
-	flag.Parse()
+func usagef(format string, a ...interface{}) error {
+	return &usageError{fmt.Sprintf(format, a...)}
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: gowave [flags] <resource> <verb> [flags] [arguments]\n\nResources:\n")
+	for _, res := range resources {
+		fmt.Fprintf(w, "  %-14v%v\n", res.name, res.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'gowave <resource>' to list its verbs, and 'gowave <resource> <verb> -h'\nfor help with a verb.\n\nFlags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(w, "\nThe exit status is %d on success, %d if a request fails, %d for invalid usage,\n%d if authentication fails and %d if the resource does not exist.\n",
+		exitOK, exitError, exitUsage, exitAuth, exitNotFound)
+}
 
-	config = &oauth.Config{
-		ClientId:     *clientID,
-		ClientSecret: *clientSecret,
-		Scope:        *scope,
-		AuthURL:      "https://api.waveapps.com/oauth2/authorize/",
-		TokenURL:     "https://api.waveapps.com/oauth2/token/",
-		TokenCache:   oauth.CacheFile(*cachefile),
-		RedirectURL:  "https://wave-portal.ngrok.com/oauth2",
+// login runs the authorization flow on the command line: the user opens the
+// authorization URL and pastes back the URL they were redirected to.
+func login(ctx context.Context, config *auth.Config) error {
+	authURL, state, err := config.AuthCodeURL()
+	if err != nil {
+		return err
+	}
+	log.Printf("Open in browser: %v\n", authURL)
+	log.Printf("Enter the URL you were redirected to: ")
+	var redirect string
+	fmt.Scanln(&redirect)
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return err
 	}
+	_, err = config.HandleCallback(ctx, u.Query(), state)
+	return err
+}
 
-	t := &oauth.Transport{
-		Config:    config,
-		Transport: http.DefaultTransport,
-		Token:     &oauth.Token{AccessToken: *accessToken},
+// newClient returns a Wave client for the current profile, authenticated with
+// its access token or its stored token, logging in first if there is none.
+func newClient(ctx context.Context) (*wave.Client, error) {
+	baseURL, err := current.baseURL()
+	if err != nil {
+		return nil, err
 	}
 
-	if *accessToken == "" {
-		_, err := config.TokenCache.Token()
+	var httpClient *http.Client
+	if current.AccessToken != "" {
+		httpClient = auth.StaticClient(ctx, current.AccessToken)
+	} else {
+		config, err := current.authConfig()
+		if err != nil {
+			return nil, err
+		}
+		httpClient, err = config.Client(ctx)
+		if err == auth.ErrNoToken {
+			if err := login(ctx, config); err != nil {
+				return nil, err
+			}
+			httpClient, err = config.Client(ctx)
+		}
 		if err != nil {
-			getToken(t)
+			return nil, err
 		}
 	}
-
-	client := wave.NewClient(t.Client())
-
-	if *businesses {
-		businesses, resp, err := client.Businesses.List(&wave.BusinessListOptions{PageOptions: wave.PageOptions{Page: *page, PageSize: *pageSize}})
-		p(businesses, resp, err)
+	client := wave.NewClient(httpClient)
+	if baseURL != nil {
+		client.BaseURL = baseURL
 	}
-
-	if *business != "" {
-		business, resp, err := client.Businesses.Get(*business)
-		fatal(resp, err)
-		printResource(business)
+	if *debug {
+		client.Use(wave.Logging(wave.NewLogger(nil), &wave.LogOptions{Bodies: true}))
 	}
+	return client, nil
+}
 
-	if *currencies {
-		currencies, resp, err := client.Currencies.List()
-		fatal(resp, err)
-		printResource(currencies)
-	}
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gowave: ")
+	flag.Usage = usage
+	flag.Parse()
+	os.Exit(run(context.Background(), flag.Args()))
+}
 
-	if *currency != "" {
-		currency, resp, err := client.Currencies.Get(*currency)
-		fatal(resp, err)
-		printResource(currency)
+// run runs the command given by args and returns the exit code.
+func run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	res := findResource(args[0])
+	if res == nil {
+		log.Printf("unknown resource %q", args[0])
+		usage()
+		return exitUsage
+	}
+	cmd, rest := res.command, args[1:]
+	if cmd == nil {
+		if len(args) == 1 || args[1] == "help" {
+			res.usage()
+			if len(args) == 1 {
+				return exitUsage
+			}
+			return exitOK
+		}
+		if cmd = res.find(args[1]); cmd == nil {
+			log.Printf("unknown verb %q for %v", args[1], res.name)
+			res.usage()
+			return exitUsage
+		}
+		rest = args[2:]
 	}
 
-	if *countries {
-		countries, resp, err := client.Countries.List()
-		fatal(resp, err)
-		printResource(countries)
+	var err error
+	current, err = loadProfile()
+	if err != nil {
+		log.Print(err)
+		return exitUsage
 	}
 
-	if *country != "" {
-		country, resp, err := client.Countries.Get(*country)
-		fatal(resp, err)
-		printResource(country)
+	// parse prints any problem with the command line itself.
+	inv, err := cmd.parse(res, rest)
+	if err == flag.ErrHelp {
+		return exitOK
 	}
-
-	if *customers != "" {
-		customers, resp, err := client.Customers.List(*customers, &wave.CustomerListOptions{PageOptions: wave.PageOptions{Page: *page, PageSize: *pageSize}})
-		p(customers, resp, err)
+	if err != nil {
+		return exitUsage
 	}
 
-	if *products != "" {
-		products, resp, err := client.Products.List(*products, &wave.ProductListOptions{PageOptions: wave.PageOptions{Page: *page, PageSize: *pageSize}})
-		p(products, resp, err)
+	if !cmd.local {
+		inv.client, err = newClient(ctx)
+		if err != nil {
+			log.Printf("could not authenticate: %v", err)
+			return exitAuth
+		}
 	}
-
-	if *user {
-		user, resp, err := client.Users.Get()
-		fatal(resp, err)
-		printResource(user)
+	err = inv.run(ctx)
+	if err == nil {
+		return exitOK
 	}
 
-	if *accounts != "" {
-		accounts, resp, err := client.Accounts.List(*accounts)
-		fatal(resp, err)
-		printResource(accounts)
+	log.Print(err)
+	var uerr *usageError
+	switch {
+	case errors.As(err, &uerr):
+		inv.fs.Usage()
+		return exitUsage
+	case errors.Is(err, wave.ErrUnauthorized), errors.Is(err, wave.ErrForbidden):
+		return exitAuth
+	case errors.Is(err, wave.ErrNotFound):
+		return exitNotFound
 	}
+	return exitError
 }
 
-func p(v interface{}, resp *wave.Response, err error) {
-	if *debug {
-		fmt.Printf("Request for \"%v\"\n", resp.Response.Request.URL)
+func findResource(name string) *resource {
+	for _, res := range resources {
+		if res.name == name {
+			return res
+		}
 	}
-	fatal(resp, err)
-	printPaginationInformation(resp)
-	printResource(v)
+	return nil
 }
 
-func fatal(resp *wave.Response, err error) {
-	if err != nil {
-		log.Printf("Couldn't fetch: %+v", err)
-		log.Printf("Raw response from \"%v?access_token=%v\":\n", resp.Response.Request.URL, *accessToken)
-		io.Copy(os.Stderr, resp.Response.Body)
-		log.Println()
-		os.Exit(1)
+// usage prints the verbs of the resource.
+func (res *resource) usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: gowave %v <verb> [flags] [arguments]\n\n%v.\n\nVerbs:\n", res.name, res.summary)
+	cmds := append([]*command(nil), res.commands...)
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].name < cmds[j].name })
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "  %-14v%v\n", cmd.name, cmd.summary)
 	}
 }
 
-func printPaginationInformation(r *wave.Response) {
-	fmt.Printf("Total: %v\n\nNext: %v\nPrevious: %v\nFirst: %v\nLast: %v\n", r.TotalCount, r.NextPage, r.PreviousPage, r.FirstPage, r.LastPage)
-}
-
-func printResource(r interface{}) {
-	b, err := json.MarshalIndent(r, "", "  ")
-	if err != nil {
-		log.Fatalf("Error decoding resource: %+v\n", err)
+func (res *resource) find(name string) *command {
+	for _, cmd := range res.commands {
+		if cmd.name == name {
+			return cmd
+		}
 	}
-	os.Stdout.Write(b)
-	fmt.Println()
+	return nil
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// silence sends what commands print to standard output, standard error and
+// the log to /dev/null, and returns a func which restores them.
+func silence() func() {
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	log.SetOutput(io.Discard)
+	return func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		log.SetOutput(os.Stderr)
+		devNull.Close()
+	}
+}
+
+// writeConfig writes a config file with the given profiles, as JSON objects
+// by name, to a temporary directory and selects it with -config.
+func writeConfig(t *testing.T, profiles map[string]string) {
+	var entries []string
+	for name, p := range profiles {
+		entries = append(entries, fmt.Sprintf("%q: %v", name, p))
+	}
+	*configFlag = filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(*configFlag, []byte(`{"profiles": {`+strings.Join(entries, ",")+`}}`), 0600)
+}
+
+func TestRun(t *testing.T) {
+	Convey("Given a config file for a fake API", t, func() {
+		defer silence()()
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		srv.AccessToken = "token"
+		business := srv.AddBusiness(&wave.Business{CompanyName: wave.String("Acme")})
+		customer := srv.AddCustomer(*business.ID, &wave.Customer{Name: wave.String("Jane")})
+
+		profile := `{"access_token": %q, "base_url": %q, "business": %q}`
+		writeConfig(t, map[string]string{
+			"default": fmt.Sprintf(profile, "token", srv.URL, *business.ID),
+			"revoked": fmt.Sprintf(profile, "revoked", srv.URL, *business.ID),
+		})
+		defer func() { *configFlag, *profileFlag = "", "" }()
+
+		Convey("Commands should exit with the code for how they went", func() {
+			for _, test := range []struct {
+				args []string
+				code int
+			}{
+				{nil, exitUsage},
+				{[]string{"help"}, exitOK},
+				{[]string{"nope"}, exitUsage},
+				{[]string{"customers"}, exitUsage},
+				{[]string{"customers", "help"}, exitOK},
+				{[]string{"customers", "nope"}, exitUsage},
+				{[]string{"customers", "get", "-h"}, exitOK},
+				{[]string{"customers", "get"}, exitUsage},
+				{[]string{"customers", "get", "jane"}, exitUsage},
+				{[]string{"customers", "get", "-output", "xml", fmt.Sprint(customer.ID)}, exitUsage},
+				{[]string{"customers", "get", fmt.Sprint(customer.ID)}, exitOK},
+				{[]string{"customers", "get", "999"}, exitNotFound},
+				{[]string{"customers", "list", "-fields", "name,colour"}, exitUsage},
+				{[]string{"customers", "update", "-set", "colour=red", fmt.Sprint(customer.ID)}, exitUsage},
+				{[]string{"customers", "update", "-set", "city=Ottawa", fmt.Sprint(customer.ID)}, exitOK},
+				{[]string{"countries", "provinces", "CA"}, exitOK},
+				{[]string{"countries", "provinces", "ZZ"}, exitNotFound},
+			} {
+				code := run(context.Background(), test.args)
+				So(fmt.Sprint(test.args, " ", code), ShouldEqual, fmt.Sprint(test.args, " ", test.code))
+			}
+		})
+
+		Convey("A rejected token should exit with the code for authentication", func() {
+			*profileFlag = "revoked"
+			So(run(context.Background(), []string{"customers", "list"}), ShouldEqual, exitAuth)
+		})
+
+		Convey("An unknown profile should exit with the code for usage", func() {
+			*profileFlag = "nope"
+			So(run(context.Background(), []string{"customers", "list"}), ShouldEqual, exitUsage)
+		})
+
+		Convey("A failed request should exit with the code for errors", func() {
+			srv.Fail(wavetest.Failure{Status: http.StatusInternalServerError, Times: -1})
+			So(run(context.Background(), []string{"customers", "list"}), ShouldEqual, exitError)
+		})
+	})
+}
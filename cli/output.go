@@ -0,0 +1,618 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// formats are the values of -output.
+var formats = []string{"json", "jsonl", "yaml", "table", "csv"}
+
+// printer prints the result of a command in the format given by -output,
+// keeping only the fields given by -fields.
+type printer struct {
+	format string
+	fields [][]string // dotted paths, split on the dots
+}
+
+// outputFlag is the value of -output.
+type outputFlag struct {
+	p *printer
+}
+
+func (o outputFlag) String() string {
+	if o.p == nil {
+		return ""
+	}
+	return o.p.format
+}
+
+func (o outputFlag) Set(v string) error {
+	for _, format := range formats {
+		if v == format {
+			o.p.format = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, want one of %v", v, strings.Join(formats, ", "))
+}
+
+// fieldsFlag is the value of -fields.
+type fieldsFlag struct {
+	p *printer
+}
+
+func (f fieldsFlag) String() string {
+	if f.p == nil {
+		return ""
+	}
+	paths := make([]string, len(f.p.fields))
+	for i, path := range f.p.fields {
+		paths[i] = strings.Join(path, ".")
+	}
+	return strings.Join(paths, ",")
+}
+
+func (f fieldsFlag) Set(v string) error {
+	for _, path := range strings.Split(v, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		f.p.fields = append(f.p.fields, strings.Split(path, "."))
+	}
+	return nil
+}
+
+// print writes v, a resource or a slice of them, to w.
+func (p *printer) print(w io.Writer, v interface{}) error {
+	for _, path := range p.fields {
+		if !hasField(reflect.TypeOf(v), path) {
+			return usagef("unknown field %q", strings.Join(path, "."))
+		}
+	}
+	switch p.format {
+	case "table", "csv":
+		return p.printRows(w, v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tree, err := decodeTree(data)
+	if err != nil {
+		return err
+	}
+	if len(p.fields) > 0 {
+		tree = project(tree, p.fields)
+	}
+
+	switch p.format {
+	case "jsonl":
+		items, ok := tree.([]node)
+		if !ok {
+			items = []node{tree}
+		}
+		for _, item := range items {
+			b, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		}
+		return nil
+	case "yaml":
+		var buf bytes.Buffer
+		writeYAML(&buf, tree, 0)
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	b, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// printRows writes v as a table or as CSV, with a row for each element of a
+// slice and a column for each field.
+func (p *printer) printRows(w io.Writer, v interface{}) error {
+	rv := indirect(reflect.ValueOf(v))
+	var rows []reflect.Value
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, rv.Index(i))
+		}
+	} else {
+		rows = []reflect.Value{rv}
+	}
+
+	fields := p.fields
+	if len(fields) == 0 {
+		fields = columns(rows)
+	}
+	header := make([]string, len(fields))
+	for i, path := range fields {
+		header[i] = strings.Join(path, ".")
+	}
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(fields))
+		for j, path := range fields {
+			cells[i][j] = cell(lookup(row, path))
+		}
+	}
+
+	if p.format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(cells)
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range cells {
+		for j, c := range row {
+			row[j] = strings.NewReplacer("\t", " ", "\n", " ").Replace(c)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// project keeps only the fields at paths in n, in the order they were
+// given. The rest of a path is kept in each element of a list along the way.
+func project(n node, paths [][]string) node {
+	switch n := n.(type) {
+	case []node:
+		out := make([]node, len(n))
+		for i, item := range n {
+			out[i] = project(item, paths)
+		}
+		return out
+	case object:
+		out := object{}
+		for i, path := range paths {
+			if seen(paths[:i], path[0]) {
+				continue
+			}
+			child, ok := n.get(path[0])
+			if !ok {
+				continue
+			}
+			var rest [][]string
+			whole := false
+			for _, p := range paths[i:] {
+				if p[0] != path[0] {
+					continue
+				}
+				if len(p) == 1 {
+					whole = true
+				}
+				rest = append(rest, p[1:])
+			}
+			if !whole {
+				child = project(child, rest)
+			}
+			out = append(out, member{path[0], child})
+		}
+		return out
+	}
+	return n
+}
+
+// seen reports whether any of paths starts with key.
+func seen(paths [][]string, key string) bool {
+	for _, path := range paths {
+		if path[0] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// node is a decoded JSON value: an object, a []node, or a scalar held as a
+// json.RawMessage.
+type node interface{}
+
+// member is a key and value of an object.
+type member struct {
+	key   string
+	value node
+}
+
+// object is a JSON object which keeps the order of its keys, so that the
+// fields are printed in the order of the struct they were encoded from.
+type object []member
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o object) get(key string) (node, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+// decodeTree decodes JSON into nodes.
+func decodeTree(data []byte) (node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeNode(dec)
+}
+
+func decodeNode(dec *json.Decoder) (node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, member{key.(string), value})
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		items := []node{}
+		for dec.More() {
+			item, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	b, err := json.Marshal(tok)
+	return json.RawMessage(b), err
+}
+
+// writeYAML writes n as a YAML block indented by indent spaces.
+func writeYAML(buf *bytes.Buffer, n node, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch n := n.(type) {
+	case object:
+		if len(n) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		for _, m := range n {
+			buf.WriteString(pad + yamlString(m.key) + ":")
+			if isBlock(m.value) {
+				buf.WriteString("\n")
+				writeYAML(buf, m.value, indent+2)
+			} else {
+				buf.WriteString(" " + yamlScalar(m.value) + "\n")
+			}
+		}
+	case []node:
+		if len(n) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range n {
+			if !isBlock(item) {
+				buf.WriteString(pad + "- " + yamlScalar(item) + "\n")
+				continue
+			}
+			// The first line of the element goes after the dash.
+			var child bytes.Buffer
+			writeYAML(&child, item, indent+2)
+			buf.WriteString(pad + "- ")
+			buf.Write(child.Bytes()[indent+2:])
+		}
+	default:
+		buf.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+// isBlock reports whether n is a non-empty object or list, which is written
+// on lines of its own.
+func isBlock(n node) bool {
+	switch n := n.(type) {
+	case object:
+		return len(n) > 0
+	case []node:
+		return len(n) > 0
+	}
+	return false
+}
+
+func yamlScalar(n node) string {
+	switch n := n.(type) {
+	case object:
+		return "{}"
+	case []node:
+		return "[]"
+	case json.RawMessage:
+		var s string
+		if json.Unmarshal(n, &s) == nil {
+			return yamlString(s)
+		}
+		return string(n)
+	}
+	return "null"
+}
+
+// yamlImplicit matches the plain scalars which YAML 1.1 or 1.2 reads as
+// something other than a string: numbers in any base, with underscores or in
+// base 60, infinities, NaN and timestamps. Nulls and booleans are matched by
+// yamlString.
+var yamlImplicit = regexp.MustCompile(`^(?:` +
+	`[-+]?(?:0b[01_]+|0o?[0-7_]+|0x[0-9a-fA-F_]+|[0-9][0-9_]*(?::[0-5]?[0-9])+(?:\.[0-9_]*)?)` +
+	`|[-+]?(?:[0-9][0-9_]*(?:\.[0-9_]*)?|\.[0-9][0-9_]*)(?:[eE][-+]?[0-9]+)?` +
+	`|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN)` +
+	`|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(?:[Tt ].*)?` +
+	`)$`)
+
+// yamlString returns s as a plain YAML scalar if it would be read back as the
+// same string, and double-quoted otherwise.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if yamlImplicit.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	for i, r := range s {
+		ok := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("_./+()", r) ||
+			i > 0 && strings.ContainsRune(" -@,'", r) ||
+			r > 0x7f && strconv.IsPrint(r)
+		if !ok {
+			return strconv.Quote(s)
+		}
+	}
+	if strings.HasSuffix(s, " ") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// columns returns the fields with a value in any of rows, in the order of the
+// struct, for a table printed without -fields.
+func columns(rows []reflect.Value) [][]string {
+	if len(rows) == 0 {
+		return nil
+	}
+	t := rows[0].Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return [][]string{{}}
+	}
+	var fields [][]string
+	for _, name := range jsonNames(t) {
+		path := []string{name}
+		for _, row := range rows {
+			if cell(lookup(row, path)) != "" {
+				fields = append(fields, path)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// jsonNames returns the JSON names of the fields of t, including those of any
+// embedded structs, in order.
+func jsonNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, embedded := jsonName(f)
+		switch {
+		case embedded:
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			names = append(names, jsonNames(ft)...)
+		case name != "":
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonName returns the name f is encoded with, or whether its fields are
+// encoded as those of the outer struct. The name is empty if f is not
+// encoded.
+func jsonName(f reflect.StructField) (name string, embedded bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name = strings.Split(tag, ",")[0]
+	ft := f.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+		return "", true
+	}
+	if f.PkgPath != "" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+// structField returns the field of the struct v encoded as name.
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fname, embedded := jsonName(t.Field(i))
+		if embedded {
+			inner := indirect(v.Field(i))
+			if !inner.IsValid() {
+				continue
+			}
+			if fv, ok := structField(inner, name); ok {
+				return fv, true
+			}
+		} else if fname == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// hasField reports whether values of type t have the field at path.
+func hasField(t reflect.Type, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return hasField(t.Elem(), path)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, embedded := jsonName(f)
+			if embedded && hasField(f.Type, path) || name == path[0] && hasField(f.Type, path[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup returns the values at path in v. The rest of the path is looked up
+// in each element of a slice along the way.
+func lookup(v reflect.Value, path []string) []reflect.Value {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if len(path) == 0 {
+		return []reflect.Value{v}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var out []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, lookup(v.Index(i), path)...)
+		}
+		return out
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return lookup(v.MapIndex(reflect.ValueOf(path[0]).Convert(v.Type().Key())), path[1:])
+		}
+	case reflect.Struct:
+		if f, ok := structField(v, path[0]); ok {
+			return lookup(f, path[1:])
+		}
+	}
+	return nil
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// cell formats values for a table or CSV cell.
+func cell(values []reflect.Value) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if s := format(v); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// format formats v for a cell: nested resources with their String method,
+// and anything else without one as compact JSON.
+func format(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	switch x := v.Interface().(type) {
+	case wave.Date:
+		return time.Time(x).Format("2006-01-02")
+	case wave.DateTime:
+		return time.Time(x).Format(time.RFC3339)
+	case fmt.Stringer:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, format(v.Index(i)))
+		}
+		return strings.Join(parts, ", ")
+	case reflect.Struct, reflect.Map:
+		return compact(v.Interface())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// customers are printed by the tests, the second one with a name YAML would
+// read as a date and a currency without a name.
+var customers = []wave.Customer{
+	{
+		ID:       1,
+		Name:     wave.String("Jane"),
+		Email:    wave.String("jane@example.com"),
+		Currency: &wave.Currency{Code: wave.String("CAD"), Name: wave.String("Canadian Dollar")},
+		Address: &wave.Address{
+			City:     wave.String("Ottawa"),
+			Province: &wave.Province{Name: wave.String("Ontario"), Slug: wave.String("ontario")},
+		},
+	},
+	{
+		ID:       2,
+		Name:     wave.String("2015-03-09"),
+		Currency: &wave.Currency{Code: wave.String("USD")},
+	},
+}
+
+// printed returns v as printed in format, keeping only fields if given.
+func printed(format, fields string, v interface{}) (string, error) {
+	p := &printer{format: format}
+	if fields != "" {
+		fieldsFlag{p}.Set(fields)
+	}
+	var buf bytes.Buffer
+	err := p.print(&buf, v)
+	return buf.String(), err
+}
+
+func TestPrinter(t *testing.T) {
+	Convey("Resources should be printed as JSON", t, func() {
+		out, err := printed("json", "id,name,currency.code", customers)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `[
+  {
+    "id": 1,
+    "name": "Jane",
+    "currency": {
+      "code": "CAD"
+    }
+  },
+  {
+    "id": 2,
+    "name": "2015-03-09",
+    "currency": {
+      "code": "USD"
+    }
+  }
+]
+`)
+
+		out, _ = printed("json", "", customers[1])
+		So(out, ShouldEqual, `{
+  "id": 2,
+  "name": "2015-03-09",
+  "currency": {
+    "code": "USD"
+  }
+}
+`)
+	})
+
+	Convey("Resources should be printed as JSON lines", t, func() {
+		out, err := printed("jsonl", "name,province.slug", customers)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `{"name":"Jane","province":{"slug":"ontario"}}
+{"name":"2015-03-09"}
+`)
+
+		out, _ = printed("jsonl", "id", customers[0])
+		So(out, ShouldEqual, "{\"id\":1}\n")
+	})
+
+	Convey("Resources should be printed as YAML", t, func() {
+		out, err := printed("yaml", "id,name,currency,province.name", customers)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, `- id: 1
+  name: Jane
+  currency:
+    code: CAD
+    name: Canadian Dollar
+  province:
+    name: Ontario
+- id: 2
+  name: "2015-03-09"
+  currency:
+    code: USD
+`)
+
+		out, _ = printed("yaml", "", []wave.Customer{})
+		So(out, ShouldEqual, "[]\n")
+	})
+
+	Convey("Resources should be printed as a table", t, func() {
+		out, err := printed("table", "", customers)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, strings.Join([]string{
+			"ID  NAME        EMAIL             CURRENCY               CITY    PROVINCE",
+			"1   Jane        jane@example.com  CAD (Canadian Dollar)  Ottawa  Ontario",
+			"2   2015-03-09                    USD ()                         ",
+			"",
+		}, "\n"))
+
+		out, _ = printed("table", "name,province.slug,currency.code", customers)
+		So(out, ShouldEqual, strings.Join([]string{
+			"NAME        PROVINCE.SLUG  CURRENCY.CODE",
+			"Jane        ontario        CAD",
+			"2015-03-09                 USD",
+			"",
+		}, "\n"))
+	})
+
+	Convey("Resources should be printed as CSV", t, func() {
+		out, err := printed("csv", "id,name,city,currency.code", customers)
+		So(err, ShouldBeNil)
+		So(out, ShouldEqual, "id,name,city,currency.code\n1,Jane,Ottawa,CAD\n2,2015-03-09,,USD\n")
+
+		out, _ = printed("csv", "id,email", customers[0])
+		So(out, ShouldEqual, "id,email\n1,jane@example.com\n")
+	})
+
+	Convey("Unknown fields should be a usage error in every format", t, func() {
+		for _, format := range formats {
+			_, err := printed(format, "name,currency.rate", customers)
+			var usage *usageError
+			So(errors.As(err, &usage), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, `unknown field "currency.rate"`)
+		}
+	})
+}
+
+func TestYAMLString(t *testing.T) {
+	Convey("Strings YAML would read as another type should be quoted", t, func() {
+		for _, s := range []string{
+			"", "~", "null", "True", "no", "Off", "y",
+			"12", "-3", "+1.5", "1.", ".5", "1e3", "1_000", "0x1F", "0o17", "0b101", "017", "1:20", ".inf", "-.Inf", ".NaN",
+			"2015-03-09", "2015-3-9", "2015-03-09 10:00", "2015-03-09T10:00:00Z",
+			"- item", "key: value", "#comment", "trailing ",
+		} {
+			So(yamlString(s), ShouldEqual, `"`+strings.ReplaceAll(s, `"`, `\"`)+`"`)
+		}
+	})
+
+	Convey("Other strings should be written plainly", t, func() {
+		for _, s := range []string{"Ottawa", "123 Main St", "v1.2.3", "jane@example.com", "Côte-d'Ivoire", "1.2.3", "infinity"} {
+			So(yamlString(s), ShouldEqual, s)
+		}
+	})
+}
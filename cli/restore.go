@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/NickPresta/gowave/wave/backup"
+)
+
+// restoreCommand returns the "restore" verb of backup, which recreates the
+// accounts, customers and products of an archive in the business.
+func restoreCommand() *command {
+	return &command{
+		name:    "restore",
+		summary: "Recreate the accounts, customers and products of an archive in the business",
+		args:    []string{"file"},
+		scoped:  true,
+		quiet:   true,
+		setup: func(fs *flag.FlagSet) runFunc {
+			var journal, report string
+			fs.StringVar(&journal, "journal", "", "Keep the IDs of restored resources in `file`, so that a failed restore can be run again (default file.business.journal)")
+			fs.StringVar(&report, "report", "", "Write the report to `file` instead of standard output")
+
+			return func(ctx context.Context, inv *invocation) error {
+				snapshot, _, err := backup.ReadFile(inv.args[0])
+				if err != nil {
+					return err
+				}
+				if journal == "" {
+					journal = fmt.Sprintf("%v.%v.journal", inv.args[0], inv.business)
+				}
+				r := &backup.Restorer{Client: inv.client, BusinessID: inv.business, JournalPath: journal}
+				result, err := r.Restore(ctx, snapshot)
+				if result == nil {
+					return err
+				}
+
+				out := io.Writer(os.Stdout)
+				if report != "" {
+					f, err := os.Create(report)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					out = f
+				}
+				if err := result.WriteCSV(out); err != nil {
+					return err
+				}
+				log.Print(result)
+				if err != nil {
+					return err
+				}
+				if n := result.Count("", backup.Failed); n > 0 {
+					return fmt.Errorf("%d of %d resources failed; run the restore again to retry them", n, len(result.Items))
+				}
+				return nil
+			}
+		},
+	}
+}
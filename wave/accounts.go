@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // AccountsService handles communication with the acccounts related methods of the Wave API.
 //
@@ -37,15 +40,21 @@ type Account struct {
 }
 
 func (a Account) String() string {
-	return fmt.Sprintf("%v (type=%v, payment=%v)", *a.Name, *a.AccountType, *a.IsPayment)
+	return fmt.Sprintf("%v (type=%v, payment=%v)", deref(a.Name), deref(a.AccountType), deref(a.IsPayment))
 }
 
 // List all accounts for a given business.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#get--businesses-{business_id}-accounts-
 func (service *AccountsService) List(businessID string) ([]Account, *Response, error) {
+	return service.ListContext(context.Background(), businessID)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *AccountsService) ListContext(ctx context.Context, businessID string) ([]Account, *Response, error) {
+	ctx = withOperation(ctx, "Accounts.List")
 	url := fmt.Sprintf("businesses/%v/accounts/", businessID)
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -61,8 +70,14 @@ func (service *AccountsService) List(businessID string) ([]Account, *Response, e
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#get--businesses-{business_id}-accounts-{account_id}-
 func (service *AccountsService) Get(businessID string, accountID uint64) (*Account, *Response, error) {
+	return service.GetContext(context.Background(), businessID, accountID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *AccountsService) GetContext(ctx context.Context, businessID string, accountID uint64) (*Account, *Response, error) {
+	ctx = withOperation(ctx, "Accounts.Get")
 	url := fmt.Sprintf("businesses/%v/accounts/%v/", businessID, accountID)
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -78,8 +93,14 @@ func (service *AccountsService) Get(businessID string, accountID uint64) (*Accou
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#post--businesses-
 func (service *AccountsService) Create(businessID string, account *Account) (*Account, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, account)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *AccountsService) CreateContext(ctx context.Context, businessID string, account *Account) (*Account, *Response, error) {
+	ctx = withOperation(ctx, "Accounts.Create")
 	url := fmt.Sprintf("businesses/%v/accounts/", businessID)
-	req, err := service.client.NewRequest("POST", url, account)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, account)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -95,8 +116,14 @@ func (service *AccountsService) Create(businessID string, account *Account) (*Ac
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#put--businesses-{business_id}-accounts-{account_id}-
 func (service *AccountsService) Replace(businessID string, accountID uint64, account *Account) (*Account, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, accountID, account)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *AccountsService) ReplaceContext(ctx context.Context, businessID string, accountID uint64, account *Account) (*Account, *Response, error) {
+	ctx = withOperation(ctx, "Accounts.Replace")
 	url := fmt.Sprintf("businesses/%v/accounts/%v/", businessID, accountID)
-	req, err := service.client.NewRequest("PUT", url, account)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, account)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -112,8 +139,14 @@ func (service *AccountsService) Replace(businessID string, accountID uint64, acc
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#patch--businesses-{business_id}-accounts-{account_id}-
 func (service *AccountsService) Update(businessID string, accountID uint64, account *Account) (*Account, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, accountID, account)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *AccountsService) UpdateContext(ctx context.Context, businessID string, accountID uint64, account *Account) (*Account, *Response, error) {
+	ctx = withOperation(ctx, "Accounts.Update")
 	url := fmt.Sprintf("businesses/%v/accounts/%v/", businessID, accountID)
-	req, err := service.client.NewRequest("PATCH", url, account)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, account)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -129,8 +162,14 @@ func (service *AccountsService) Update(businessID string, accountID uint64, acco
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#delete--businesses-{business_id}-accounts-{account_id}-
 func (service *AccountsService) Delete(businessID string, accountID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, accountID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *AccountsService) DeleteContext(ctx context.Context, businessID string, accountID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Accounts.Delete")
 	url := fmt.Sprintf("businesses/%v/accounts/%v/", businessID, accountID)
-	req, err := service.client.NewRequest("DELETE", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return nil, err
 	}
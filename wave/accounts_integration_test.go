@@ -4,8 +4,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build integration
-
 package wave
 
 import (
@@ -15,27 +13,27 @@ import (
 )
 
 func TestAccountsServiceIntegration(t *testing.T) {
-	setUpIntegrations()
+	defer setUpIntegrations(t)()
 
-	// Skip until the API drops trailing slashes
-	SkipConvey("Full end-to-end Account integration", t, func() {
-		businesses, _, err := integrationClient.Businesses.List()
+	Convey("Full end-to-end Account integration", t, func() {
+		businesses, _, err := integrationClient.Businesses.List(nil)
 		So(err, ShouldBeNil)
-		bID := businesses[0].ID
+		bID := *businesses[0].ID
 
 		accounts, _, err := integrationClient.Accounts.List(bID)
 		So(err, ShouldBeNil)
 		So(accounts, ShouldNotBeNil)
 
-		aID := accounts[0].ID
-		account, _, err := integrationClient.Accounts.Get(bId, aID)
+		aID := uint64(*accounts[0].ID)
+		account, _, err := integrationClient.Accounts.Get(bID, aID)
 		So(err, ShouldBeNil)
 		So(account, ShouldNotBeNil)
 
 		a := &Account{
 			Name:                  String("Checking CREATE TEST Account"),
+			AccountType:           String("asset"),
 			IsPayment:             Bool(true),
-			Currency:              &Currency{Code: "JPY"},
+			Currency:              &Currency{Code: String("JPY")},
 			StandardAccountNumber: Int(1006),
 		}
 		account, _, err = integrationClient.Accounts.Create(bID, a)
@@ -43,10 +41,10 @@ func TestAccountsServiceIntegration(t *testing.T) {
 		So(account, ShouldNotBeNil)
 		So(*account.Name, ShouldEqual, *a.Name)
 		So(*account.IsPayment, ShouldEqual, *a.IsPayment)
-		So(account.Currency.Code, ShouldEqual, a.Currency.Code)
+		So(*account.Currency.Code, ShouldEqual, *a.Currency.Code)
 		So(*account.StandardAccountNumber, ShouldEqual, *a.StandardAccountNumber)
 
-		resp, err := integrationClient.Accounts.Delete(bID, account.ID)
+		resp, err := integrationClient.Accounts.Delete(bID, uint64(*account.ID))
 		So(err, ShouldBeNil)
 		So(resp.StatusCode, ShouldEqual, http.StatusNoContent)
 	})
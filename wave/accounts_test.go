@@ -193,6 +193,11 @@ func TestAccountsService(t *testing.T) {
 		checkInvalidURLError(nil, resp, err)
 	})
 
+	Convey("LIST all Accounts with a cancelled context", t, func() {
+		accounts, resp, err := client.Accounts.ListContext(canceledContext(), "1")
+		checkCanceledError(accounts, resp, err)
+	})
+
 	Convey("String method on Account", t, func() {
 		a := new(Account)
 		a.Name = String("Account Test")
@@ -0,0 +1,250 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package auth implements the Wave OAuth2 authorization-code flow and returns
+http.Clients which authenticate requests for wave.NewClient.
+
+A Config sends the user to Wave to grant access, checks the state on the
+redirect back and exchanges the authorization code for a token, which is saved
+in a TokenStore:
+
+	config := &auth.Config{
+		ClientID:     "... your client ID ...",
+		ClientSecret: "... your client secret ...",
+		RedirectURL:  "https://example.com/oauth2",
+		Scopes:       []string{"basic"},
+		Store:        auth.NewFileStore("token.json"),
+	}
+
+	authURL, state, err := config.AuthCodeURL()
+	// Remember state, and send the user to authURL. When Wave redirects back:
+	token, err := config.HandleCallback(ctx, r.URL.Query(), state)
+
+Once a token has been stored, Client returns an http.Client which refreshes
+the token when it expires and saves the refreshed token back to the store:
+
+	httpClient, err := config.Client(ctx)
+	if err == auth.ErrNoToken {
+		// Run the authorization flow first
+	}
+	client := wave.NewClient(httpClient)
+*/
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// Endpoint is the Wave OAuth2 endpoint.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://api.waveapps.com/oauth2/authorize/",
+	TokenURL: "https://api.waveapps.com/oauth2/token/",
+}
+
+var (
+	// ErrNoToken is returned by a TokenStore which holds no token, and by
+	// Config.Client when the authorization flow has not been run yet.
+	ErrNoToken = errors.New("auth: no token stored")
+
+	// ErrStateMismatch is returned when the state sent back by Wave does not
+	// match the state generated for the authorization request.
+	ErrStateMismatch = errors.New("auth: state does not match")
+
+	// ErrNoCode is returned when Wave redirects back without an authorization
+	// code.
+	ErrNoCode = errors.New("auth: no authorization code")
+)
+
+// AuthorizationError is returned by HandleCallback when Wave redirects back
+// with an error, such as when the user denies access.
+type AuthorizationError struct {
+	Code        string
+	Description string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("auth: authorization failed: %v", e.Code)
+	}
+	return fmt.Sprintf("auth: authorization failed: %v: %v", e.Code, e.Description)
+}
+
+// Config describes a Wave OAuth2 application and where its tokens are kept.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// Store holds the token obtained by Exchange, and receives refreshed
+	// tokens. If nil, tokens are kept in memory only.
+	Store TokenStore
+
+	// Endpoint overrides the Wave endpoint, which is mostly useful in tests.
+	Endpoint oauth2.Endpoint
+
+	// HTTPClient, if set, is used to talk to the token endpoint.
+	HTTPClient *http.Client
+
+	once sync.Once
+}
+
+func (c *Config) init() {
+	c.once.Do(func() {
+		if c.Store == nil {
+			c.Store = NewMemoryStore()
+		}
+	})
+}
+
+func (c *Config) oauth2() *oauth2.Config {
+	endpoint := c.Endpoint
+	if endpoint.AuthURL == "" && endpoint.TokenURL == "" {
+		endpoint = Endpoint
+	}
+	return &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		RedirectURL:  c.RedirectURL,
+		Scopes:       c.Scopes,
+		Endpoint:     endpoint,
+	}
+}
+
+func (c *Config) context(ctx context.Context) context.Context {
+	if c.HTTPClient != nil {
+		return context.WithValue(ctx, oauth2.HTTPClient, c.HTTPClient)
+	}
+	return ctx
+}
+
+// AuthCodeURL returns the URL of the Wave page which asks the user to grant
+// access, along with the random state embedded in it. Keep the state, such as
+// in the user's session, and pass it to HandleCallback or ValidateState.
+func (c *Config) AuthCodeURL() (authURL, state string, err error) {
+	state, err = NewState()
+	if err != nil {
+		return "", "", err
+	}
+	return c.oauth2().AuthCodeURL(state), state, nil
+}
+
+// NewState returns a random, URL-safe state for an authorization request.
+func NewState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ValidateState returns ErrStateMismatch unless got equals the expected
+// state. The comparison takes constant time.
+func ValidateState(expected, got string) error {
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(got)) != 1 {
+		return ErrStateMismatch
+	}
+	return nil
+}
+
+// Exchange trades an authorization code for a token and saves it in the
+// store.
+func (c *Config) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	c.init()
+	token, err := c.oauth2().Exchange(c.context(ctx), code)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Store.SaveToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// HandleCallback completes the flow from the query of the request Wave
+// redirects back to. It checks for an error from Wave, validates the state
+// against the one returned by AuthCodeURL, and exchanges the code.
+func (c *Config) HandleCallback(ctx context.Context, query url.Values, state string) (*oauth2.Token, error) {
+	if code := query.Get("error"); code != "" {
+		return nil, &AuthorizationError{Code: code, Description: query.Get("error_description")}
+	}
+	if err := ValidateState(state, query.Get("state")); err != nil {
+		return nil, err
+	}
+	code := query.Get("code")
+	if code == "" {
+		return nil, ErrNoCode
+	}
+	return c.Exchange(ctx, code)
+}
+
+// TokenSource returns a token source which starts from the stored token,
+// refreshes it when it expires and saves each new token to the store. It
+// returns ErrNoToken if the store is empty.
+func (c *Config) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	c.init()
+	token, err := c.Store.Token()
+	if err != nil {
+		return nil, err
+	}
+	ctx = c.context(ctx)
+	src := c.oauth2().TokenSource(ctx, token)
+	return &storingTokenSource{src: src, store: c.Store, last: token.AccessToken}, nil
+}
+
+// Client returns an http.Client, ready for wave.NewClient, which authorizes
+// requests with the stored token and refreshes it as needed. It returns
+// ErrNoToken if the authorization flow has not been completed.
+func (c *Config) Client(ctx context.Context) (*http.Client, error) {
+	src, err := c.TokenSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(c.context(ctx), src), nil
+}
+
+// StaticClient returns an http.Client which authorizes requests with a fixed
+// access token, such as one generated from the Wave developer portal. The
+// token is never refreshed.
+func StaticClient(ctx context.Context, accessToken string) *http.Client {
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}))
+}
+
+// storingTokenSource saves tokens to a store whenever src returns a new one.
+type storingTokenSource struct {
+	mu    sync.Mutex
+	src   oauth2.TokenSource
+	store TokenStore
+	last  string
+}
+
+func (s *storingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken != s.last {
+		if err := s.store.SaveToken(token); err != nil {
+			return nil, err
+		}
+		s.last = token.AccessToken
+	}
+	return token, nil
+}
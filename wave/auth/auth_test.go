@@ -0,0 +1,226 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// mux is the HTTP request multiplexer used with the test server.
+	mux *http.ServeMux
+
+	// server is a test HTTP server standing in for Wave's OAuth2 endpoints.
+	server *httptest.Server
+
+	// config is the Config being tested, pointed at the test server.
+	config *Config
+)
+
+func setUp() {
+	mux = http.NewServeMux()
+	server = httptest.NewServer(mux)
+	config = &Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		RedirectURL:  "https://example.com/oauth2",
+		Scopes:       []string{"basic", "user.read"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  server.URL + "/oauth2/authorize/",
+			TokenURL: server.URL + "/oauth2/token/",
+		},
+	}
+}
+
+func tearDown() {
+	server.Close()
+}
+
+// serveToken answers token requests with successive access tokens, calling
+// check with each request.
+func serveToken(check func(r *http.Request)) *int {
+	calls := new(int)
+	mux.HandleFunc("/oauth2/token/", func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		r.ParseForm()
+		check(r)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access-%d", "refresh_token": "refresh-%d", "token_type": "Bearer", "expires_in": 3600}`, *calls, *calls)
+	})
+	return calls
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	Convey("AuthCodeURL should point at Wave with a fresh state", t, func() {
+		c := &Config{ClientID: "id", RedirectURL: "https://example.com/oauth2", Scopes: []string{"basic"}}
+
+		authURL, state, err := c.AuthCodeURL()
+		So(err, ShouldBeNil)
+		So(len(state), ShouldBeGreaterThanOrEqualTo, 32)
+
+		u, _ := url.Parse(authURL)
+		So(u.Host, ShouldEqual, "api.waveapps.com")
+		So(u.Path, ShouldEqual, "/oauth2/authorize/")
+		q := u.Query()
+		So(q.Get("client_id"), ShouldEqual, "id")
+		So(q.Get("response_type"), ShouldEqual, "code")
+		So(q.Get("redirect_uri"), ShouldEqual, "https://example.com/oauth2")
+		So(q.Get("scope"), ShouldEqual, "basic")
+		So(q.Get("state"), ShouldEqual, state)
+
+		_, other, _ := c.AuthCodeURL()
+		So(other, ShouldNotEqual, state)
+	})
+}
+
+func TestValidateState(t *testing.T) {
+	Convey("ValidateState should only accept the expected state", t, func() {
+		So(ValidateState("abc", "abc"), ShouldBeNil)
+		So(ValidateState("abc", "abd"), ShouldEqual, ErrStateMismatch)
+		So(ValidateState("abc", ""), ShouldEqual, ErrStateMismatch)
+		So(ValidateState("", ""), ShouldEqual, ErrStateMismatch)
+	})
+}
+
+func TestExchange(t *testing.T) {
+	Convey("Exchange should trade the code for a token and store it", t, func() {
+		setUp()
+		defer tearDown()
+
+		serveToken(func(r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			So(r.Form.Get("grant_type"), ShouldEqual, "authorization_code")
+			So(r.Form.Get("code"), ShouldEqual, "the-code")
+		})
+
+		token, err := config.Exchange(context.Background(), "the-code")
+		So(err, ShouldBeNil)
+		So(token.AccessToken, ShouldEqual, "access-1")
+
+		stored, err := config.Store.Token()
+		So(err, ShouldBeNil)
+		So(stored.AccessToken, ShouldEqual, "access-1")
+		So(stored.RefreshToken, ShouldEqual, "refresh-1")
+	})
+}
+
+func TestHandleCallback(t *testing.T) {
+	Convey("HandleCallback should complete the flow from the redirect", t, func() {
+		setUp()
+		defer tearDown()
+		calls := serveToken(func(r *http.Request) {})
+
+		Convey("When the state matches", func() {
+			q := url.Values{"code": {"the-code"}, "state": {"s1"}}
+			token, err := config.HandleCallback(context.Background(), q, "s1")
+			So(err, ShouldBeNil)
+			So(token.AccessToken, ShouldEqual, "access-1")
+		})
+
+		Convey("When the state does not match", func() {
+			q := url.Values{"code": {"the-code"}, "state": {"forged"}}
+			_, err := config.HandleCallback(context.Background(), q, "s1")
+			So(err, ShouldEqual, ErrStateMismatch)
+			So(*calls, ShouldEqual, 0)
+		})
+
+		Convey("When there is no code", func() {
+			q := url.Values{"state": {"s1"}}
+			_, err := config.HandleCallback(context.Background(), q, "s1")
+			So(err, ShouldEqual, ErrNoCode)
+		})
+
+		Convey("When Wave reports an error", func() {
+			q := url.Values{"error": {"access_denied"}, "error_description": {"The user denied access"}, "state": {"s1"}}
+			_, err := config.HandleCallback(context.Background(), q, "s1")
+			e, ok := err.(*AuthorizationError)
+			So(ok, ShouldBeTrue)
+			So(e.Code, ShouldEqual, "access_denied")
+			So(err.Error(), ShouldEqual, "auth: authorization failed: access_denied: The user denied access")
+			So(*calls, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestClient(t *testing.T) {
+	Convey("Client should need a stored token", t, func() {
+		setUp()
+		defer tearDown()
+
+		_, err := config.Client(context.Background())
+		So(err, ShouldEqual, ErrNoToken)
+	})
+
+	Convey("Client should authorize requests with the stored token", t, func() {
+		setUp()
+		defer tearDown()
+
+		config.Store = NewMemoryStore()
+		config.Store.SaveToken(&oauth2.Token{AccessToken: "valid", Expiry: time.Now().Add(time.Hour)})
+		mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Header.Get("Authorization"), ShouldEqual, "Bearer valid")
+		})
+
+		c, err := config.Client(context.Background())
+		So(err, ShouldBeNil)
+		_, err = c.Get(server.URL + "/api")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Client should refresh an expired token and store the new one", t, func() {
+		setUp()
+		defer tearDown()
+
+		calls := serveToken(func(r *http.Request) {
+			So(r.Form.Get("grant_type"), ShouldEqual, "refresh_token")
+			So(r.Form.Get("refresh_token"), ShouldEqual, "old-refresh")
+		})
+		mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Header.Get("Authorization"), ShouldEqual, "Bearer access-1")
+		})
+
+		config.Store = NewMemoryStore()
+		config.Store.SaveToken(&oauth2.Token{
+			AccessToken:  "expired",
+			RefreshToken: "old-refresh",
+			Expiry:       time.Now().Add(-time.Hour),
+		})
+
+		c, err := config.Client(context.Background())
+		So(err, ShouldBeNil)
+		_, err = c.Get(server.URL + "/api")
+		So(err, ShouldBeNil)
+		_, err = c.Get(server.URL + "/api")
+		So(err, ShouldBeNil)
+		So(*calls, ShouldEqual, 1)
+
+		stored, _ := config.Store.Token()
+		So(stored.AccessToken, ShouldEqual, "access-1")
+		So(stored.RefreshToken, ShouldEqual, "refresh-1")
+	})
+
+	Convey("StaticClient should authorize requests with a fixed token", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Header.Get("Authorization"), ShouldEqual, "Bearer personal")
+		})
+
+		_, err := StaticClient(context.Background(), "personal").Get(server.URL + "/api")
+		So(err, ShouldBeNil)
+	})
+}
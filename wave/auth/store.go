@@ -0,0 +1,130 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenStore persists the token for a Config. Implementations must be safe for
+// concurrent use.
+type TokenStore interface {
+	// Token returns the stored token, or ErrNoToken if there is none.
+	Token() (*oauth2.Token, error)
+
+	// SaveToken replaces the stored token.
+	SaveToken(token *oauth2.Token) error
+}
+
+// MemoryStore is a TokenStore which keeps the token in memory.
+type MemoryStore struct {
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return new(MemoryStore)
+}
+
+// Token implements the TokenStore interface.
+func (s *MemoryStore) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, ErrNoToken
+	}
+	t := *s.token
+	return &t, nil
+}
+
+// SaveToken implements the TokenStore interface.
+func (s *MemoryStore) SaveToken(token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *token
+	s.token = &t
+	return nil
+}
+
+// FileStore is a TokenStore which keeps the token as JSON in a file. The file
+// is only readable by its owner, since the token grants access to the
+// user's Wave businesses.
+type FileStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a FileStore which keeps the token at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Token implements the TokenStore interface.
+func (s *FileStore) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// SaveToken implements the TokenStore interface. The file, and any missing
+// parent directories, are created as needed, and the token is written to a
+// temporary file first so that a crash never leaves a partial token behind.
+func (s *FileStore) SaveToken(token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+// Delete removes the stored token. Deleting a missing token is not an error.
+func (s *FileStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/oauth2"
+)
+
+func TestMemoryStore(t *testing.T) {
+	Convey("A MemoryStore should hold a copy of the saved token", t, func() {
+		s := NewMemoryStore()
+		_, err := s.Token()
+		So(err, ShouldEqual, ErrNoToken)
+
+		token := &oauth2.Token{AccessToken: "a", RefreshToken: "r"}
+		So(s.SaveToken(token), ShouldBeNil)
+		token.AccessToken = "changed"
+
+		stored, err := s.Token()
+		So(err, ShouldBeNil)
+		So(stored.AccessToken, ShouldEqual, "a")
+		So(stored.RefreshToken, ShouldEqual, "r")
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	Convey("A FileStore should keep the token in a private file", t, func() {
+		dir := t.TempDir()
+		s := NewFileStore(filepath.Join(dir, "gowave", "token.json"))
+
+		_, err := s.Token()
+		So(err, ShouldEqual, ErrNoToken)
+
+		expiry := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+		So(s.SaveToken(&oauth2.Token{AccessToken: "a", RefreshToken: "r", Expiry: expiry}), ShouldBeNil)
+
+		info, err := os.Stat(s.Path)
+		So(err, ShouldBeNil)
+		So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+
+		stored, err := NewFileStore(s.Path).Token()
+		So(err, ShouldBeNil)
+		So(stored.AccessToken, ShouldEqual, "a")
+		So(stored.RefreshToken, ShouldEqual, "r")
+		So(stored.Expiry.Equal(expiry), ShouldBeTrue)
+
+		Convey("Saving again should replace the token", func() {
+			So(s.SaveToken(&oauth2.Token{AccessToken: "b"}), ShouldBeNil)
+			stored, _ := s.Token()
+			So(stored.AccessToken, ShouldEqual, "b")
+
+			entries, _ := os.ReadDir(filepath.Dir(s.Path))
+			So(len(entries), ShouldEqual, 1)
+		})
+
+		Convey("Deleting should forget the token", func() {
+			So(s.Delete(), ShouldBeNil)
+			_, err := s.Token()
+			So(err, ShouldEqual, ErrNoToken)
+			So(s.Delete(), ShouldBeNil)
+		})
+	})
+
+	Convey("A FileStore should report a corrupt file", t, func() {
+		path := filepath.Join(t.TempDir(), "token.json")
+		os.WriteFile(path, []byte("not json"), 0600)
+		_, err := NewFileStore(path).Token()
+		So(err, ShouldNotBeNil)
+		So(err, ShouldNotEqual, ErrNoToken)
+	})
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package backup exports everything a Wave business holds to a portable archive,
+and reads such archives back.
+
+An archive is a zip file with a JSON file per type of resource (business.json,
+accounts.json, customers.json and products.json) and a manifest.json listing
+each file with the number of resources in it and its SHA-256 checksum:
+
+	snapshot, err := backup.Fetch(ctx, client, bID)
+	if err != nil {
+		// A request failed
+	}
+	manifest, err := snapshot.WriteFile("acme.zip")
+
+Reading an archive checks its version and the checksum and count of every
+file:
+
+	snapshot, manifest, err := backup.ReadFile("acme.zip")
+	if errors.Is(err, backup.ErrCorrupt) {
+		// The archive was modified or truncated
+	}
+
+A Restorer recreates the accounts, customers and products of a snapshot in
+another business, remapping the IDs which refer to them.
+*/
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// Format identifies gowave archives in their manifest.
+const Format = "gowave-backup"
+
+// Version is the version of the archive format written by this package.
+// Archives of a later version cannot be read.
+const Version = 1
+
+// ManifestName is the name of the manifest in an archive.
+const ManifestName = "manifest.json"
+
+// ErrCorrupt is wrapped by the errors returned for an archive whose files do
+// not match its manifest.
+var ErrCorrupt = errors.New("backup: archive is corrupt")
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	BusinessID string    `json:"business_id"`
+	Files      []File    `json:"files"`
+}
+
+// File is a file in an archive, holding the resources of one type.
+type File struct {
+	Name     string `json:"name"`
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// File returns the entry of the manifest for the resource, or nil if the
+// archive has none.
+func (m *Manifest) File(resource string) *File {
+	for i := range m.Files {
+		if m.Files[i].Resource == resource {
+			return &m.Files[i]
+		}
+	}
+	return nil
+}
+
+// Snapshot is everything a business holds, as it was when it was fetched.
+type Snapshot struct {
+	Business  *wave.Business
+	Accounts  []wave.Account
+	Customers []wave.Customer
+
+	// Products embed their income and expense accounts.
+	Products []wave.Product
+}
+
+// entry is a type of resource kept in an archive.
+type entry struct {
+	resource string
+	name     string
+	value    func(s *Snapshot) interface{} // a pointer to the resources
+	count    func(s *Snapshot) int
+}
+
+// entries are the files of an archive, in the order they are written.
+var entries = []entry{
+	{"business", "business.json", func(s *Snapshot) interface{} { return &s.Business }, func(s *Snapshot) int {
+		if s.Business == nil {
+			return 0
+		}
+		return 1
+	}},
+	{"accounts", "accounts.json", func(s *Snapshot) interface{} { return &s.Accounts }, func(s *Snapshot) int { return len(s.Accounts) }},
+	{"customers", "customers.json", func(s *Snapshot) interface{} { return &s.Customers }, func(s *Snapshot) int { return len(s.Customers) }},
+	{"products", "products.json", func(s *Snapshot) interface{} { return &s.Products }, func(s *Snapshot) int { return len(s.Products) }},
+}
+
+// Fetch fetches the business with the given ID along with all its accounts,
+// customers and products.
+func Fetch(ctx context.Context, client *wave.Client, businessID string) (*Snapshot, error) {
+	s := new(Snapshot)
+	var err error
+	if s.Business, _, err = client.Businesses.GetContext(ctx, businessID); err != nil {
+		return nil, err
+	}
+	if s.Accounts, _, err = client.Accounts.ListContext(ctx, businessID); err != nil {
+		return nil, err
+	}
+	if s.Customers, err = wave.ListAll(ctx, client.Customers.ListIter(businessID, nil), 0); err != nil {
+		return nil, err
+	}
+	opts := &wave.ProductListOptions{EmbedAccounts: true}
+	if s.Products, err = wave.ListAll(ctx, client.Products.ListIter(businessID, opts), 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes the snapshot to w as an archive, and returns its manifest.
+func (s *Snapshot) Write(w io.Writer) (*Manifest, error) {
+	m := &Manifest{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if s.Business != nil && s.Business.ID != nil {
+		m.BusinessID = *s.Business.ID
+	}
+
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		v := reflect.ValueOf(e.value(s)).Elem()
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			// Write an empty list rather than null.
+			v = reflect.MakeSlice(v.Type(), 0, 0)
+		}
+		data, err := json.MarshalIndent(v.Interface(), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		m.Files = append(m.Files, File{
+			Name:     e.name,
+			Resource: e.resource,
+			Count:    e.count(s),
+			Size:     int64(len(data)),
+			SHA256:   hex.EncodeToString(sum[:]),
+		})
+		if err := writeEntry(zw, e.name, m.CreatedAt, data); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(zw, ManifestName, m.CreatedAt, data); err != nil {
+		return nil, err
+	}
+	return m, zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, modified time.Time, data []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// WriteFile writes the snapshot as an archive at path. The archive is written
+// to a temporary file first, so that a failure never leaves a partial archive
+// behind.
+func (s *Snapshot) WriteFile(path string) (*Manifest, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	m, err := s.Write(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return m, os.Rename(f.Name(), path)
+}
+
+// Read reads an archive of the given size, checking that its files match its
+// manifest.
+func Read(r io.ReaderAt, size int64) (*Snapshot, *Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	m := new(Manifest)
+	if err := readEntry(zr, ManifestName, m, nil); err != nil {
+		return nil, nil, err
+	}
+	if m.Format != Format {
+		return nil, nil, fmt.Errorf("backup: not a %v archive", Format)
+	}
+	if m.Version > Version {
+		return nil, nil, fmt.Errorf("backup: archive version %d is newer than %d", m.Version, Version)
+	}
+
+	s := new(Snapshot)
+	for _, e := range entries {
+		f := m.File(e.resource)
+		if f == nil {
+			return nil, nil, fmt.Errorf("%w: the manifest has no %v", ErrCorrupt, e.resource)
+		}
+		if err := readEntry(zr, f.Name, e.value(s), f); err != nil {
+			return nil, nil, err
+		}
+		if n := e.count(s); n != f.Count {
+			return nil, nil, fmt.Errorf("%w: %v holds %d %v, the manifest says %d", ErrCorrupt, f.Name, n, e.resource, f.Count)
+		}
+	}
+	return s, m, nil
+}
+
+// readEntry decodes the file with the given name into v, checking its
+// checksum against the manifest entry f if it is not nil.
+func readEntry(zr *zip.Reader, name string, v interface{}, f *File) error {
+	rc, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("%w: %v: %v", ErrCorrupt, name, err)
+	}
+	if f != nil {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return fmt.Errorf("%w: the checksum of %v does not match the manifest", ErrCorrupt, name)
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v: %v", ErrCorrupt, name, err)
+	}
+	return nil
+}
+
+// ReadFile reads the archive at path.
+func ReadFile(path string) (*Snapshot, *Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	return Read(f, info.Size())
+}
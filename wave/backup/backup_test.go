@@ -0,0 +1,148 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// seed adds a business to srv with more customers than fit on a page.
+func seed(srv *wavetest.Server) string {
+	business := srv.AddBusiness(&wave.Business{
+		CompanyName:         wave.String("Acme"),
+		PrimaryCurrencyCode: wave.String("USD"),
+	})
+	bID := *business.ID
+	sales := srv.AddAccount(bID, &wave.Account{Name: wave.String("Sales"), AccountType: wave.String("income")})
+	srv.AddAccount(bID, &wave.Account{Name: wave.String("Rent"), AccountType: wave.String("expense")})
+	for i := 0; i < wavetest.DefaultPageSize+5; i++ {
+		srv.AddCustomer(bID, &wave.Customer{Name: wave.String(fmt.Sprintf("Customer %d", i))})
+	}
+	srv.AddProduct(bID, &wave.Product{
+		Name:          wave.String("Widget"),
+		Price:         wave.Amount("13.370"),
+		IncomeAccount: &wave.Account{ID: sales.ID},
+	})
+	return bID
+}
+
+// rewrite copies the archive in data, replacing the contents of the files in
+// replace.
+func rewrite(data []byte, replace map[string]string) []byte {
+	zr, _ := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		w, _ := zw.Create(f.Name)
+		if contents, ok := replace[f.Name]; ok {
+			io.WriteString(w, contents)
+			continue
+		}
+		rc, _ := f.Open()
+		io.Copy(w, rc)
+		rc.Close()
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestBackup(t *testing.T) {
+	Convey("A business should be exported and read back", t, func() {
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		bID := seed(srv)
+		ctx := context.Background()
+
+		snapshot, err := Fetch(ctx, srv.Client(), bID)
+		So(err, ShouldBeNil)
+		So(*snapshot.Business.CompanyName, ShouldEqual, "Acme")
+		So(snapshot.Accounts, ShouldHaveLength, 2)
+		So(snapshot.Customers, ShouldHaveLength, wavetest.DefaultPageSize+5)
+		So(snapshot.Products, ShouldHaveLength, 1)
+		So(*snapshot.Products[0].IncomeAccount.Name, ShouldEqual, "Sales")
+
+		var buf bytes.Buffer
+		manifest, err := snapshot.Write(&buf)
+		So(err, ShouldBeNil)
+		So(manifest.Format, ShouldEqual, Format)
+		So(manifest.Version, ShouldEqual, Version)
+		So(manifest.BusinessID, ShouldEqual, bID)
+		So(manifest.CreatedAt.IsZero(), ShouldBeFalse)
+		So(manifest.Files, ShouldHaveLength, 4)
+		So(manifest.File("business").Count, ShouldEqual, 1)
+		So(manifest.File("customers").Name, ShouldEqual, "customers.json")
+		So(manifest.File("customers").Count, ShouldEqual, wavetest.DefaultPageSize+5)
+		So(manifest.File("customers").SHA256, ShouldHaveLength, 64)
+		So(manifest.File("invoices"), ShouldBeNil)
+
+		data := buf.Bytes()
+		read, readManifest, err := Read(bytes.NewReader(data), int64(len(data)))
+		So(err, ShouldBeNil)
+		So(readManifest, ShouldResemble, manifest)
+		So(*read.Business.ID, ShouldEqual, bID)
+		So(read.Accounts, ShouldHaveLength, 2)
+		So(*read.Customers[14].Name, ShouldEqual, "Customer 14")
+		So(read.Products[0].Price.Decimal(), ShouldEqual, "13.370")
+		So(*read.Products[0].IncomeAccount.Name, ShouldEqual, "Sales")
+
+		Convey("Empty lists should be written as such", func() {
+			var buf bytes.Buffer
+			manifest, err := (&Snapshot{Business: snapshot.Business}).Write(&buf)
+			So(err, ShouldBeNil)
+			So(manifest.File("products").Size, ShouldEqual, 2)
+		})
+
+		Convey("Archives should be written to and read from files", func() {
+			path := filepath.Join(t.TempDir(), "acme.zip")
+			_, err := snapshot.WriteFile(path)
+			So(err, ShouldBeNil)
+			read, _, err := ReadFile(path)
+			So(err, ShouldBeNil)
+			So(read.Customers, ShouldHaveLength, wavetest.DefaultPageSize+5)
+		})
+
+		Convey("Modified files should be detected", func() {
+			data := rewrite(data, map[string]string{"customers.json": `[]`})
+			_, _, err := Read(bytes.NewReader(data), int64(len(data)))
+			So(errors.Is(err, ErrCorrupt), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "checksum of customers.json")
+		})
+
+		Convey("Missing files should be detected", func() {
+			_, _, err := Read(bytes.NewReader(data[:len(data)/2]), int64(len(data)/2))
+			So(errors.Is(err, ErrCorrupt), ShouldBeTrue)
+		})
+
+		Convey("Archives of a later version should not be read", func() {
+			data := rewrite(data, map[string]string{ManifestName: `{"format": "gowave-backup", "version": 2}`})
+			_, _, err := Read(bytes.NewReader(data), int64(len(data)))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "version 2")
+		})
+	})
+
+	Convey("Export should fail if a request fails", t, func() {
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		bID := seed(srv)
+		srv.Fail(wavetest.Failure{Path: "/businesses/*/customers/", Status: 500})
+
+		_, err := Fetch(context.Background(), srv.Client(), bID)
+		So(errors.Is(err, wave.ErrServer), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backup
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// Outcome is what a restore did with a resource.
+type Outcome string
+
+const (
+	// Created is a resource which was created in the target business.
+	Created Outcome = "created"
+
+	// Resumed is a resource which the journal shows was created by an
+	// earlier run of the restore.
+	Resumed Outcome = "resumed"
+
+	// Matched is a system account, which cannot be created, matched to the
+	// account of the same name and type in the target business.
+	Matched Outcome = "matched"
+
+	// Skipped is a system account with no match in the target business.
+	// Products which use it are restored without it.
+	Skipped Outcome = "skipped"
+
+	// Failed is a resource which could not be created.
+	Failed Outcome = "failed"
+)
+
+// Item is what happened to a resource of the archive.
+type Item struct {
+	Resource string // "accounts", "customers" or "products"
+	OldID    string
+	NewID    string
+	Name     string
+	Outcome  Outcome
+
+	// Notes are anything the restore changed, such as an account left out of
+	// a product.
+	Notes []string
+
+	// Err is why the resource Failed.
+	Err error
+}
+
+// RestoreReport lists what happened to every account, customer and product
+// of the archive, in the order they were restored.
+type RestoreReport struct {
+	Items []*Item
+}
+
+// Count returns the number of resources of the given type with the given
+// outcome. An empty resource counts all of them.
+func (r *RestoreReport) Count(resource string, outcome Outcome) int {
+	n := 0
+	for _, item := range r.Items {
+		if (resource == "" || item.Resource == resource) && item.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *RestoreReport) String() string {
+	return fmt.Sprintf("%d created, %d resumed, %d matched, %d skipped, %d failed",
+		r.Count("", Created), r.Count("", Resumed), r.Count("", Matched), r.Count("", Skipped), r.Count("", Failed))
+}
+
+// WriteCSV writes the report as CSV, with a row for every resource.
+func (r *RestoreReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"resource", "old_id", "new_id", "name", "outcome", "notes", "error"})
+	for _, item := range r.Items {
+		message := ""
+		if item.Err != nil {
+			message = item.Err.Error()
+		}
+		cw.Write([]string{item.Resource, item.OldID, item.NewID, item.Name, string(item.Outcome), strings.Join(item.Notes, "; "), message})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Journal records the IDs given to the resources created by a restore, so
+// that a restore which failed part of the way can carry on where it stopped.
+type Journal struct {
+	SourceBusinessID string `json:"source_business_id"`
+	TargetBusinessID string `json:"target_business_id"`
+
+	// IDs maps the resource type and ID in the archive, such as
+	// "accounts/12", to the ID of the resource created from it.
+	IDs map[string]string `json:"ids"`
+}
+
+// Restorer recreates the accounts, customers and products of an archive in
+// another business, such as to clone a template business. IDs are remapped,
+// so that the accounts of a product are the accounts created from the ones
+// in the archive.
+type Restorer struct {
+	Client *wave.Client
+
+	// BusinessID is the ID of the business to restore into.
+	BusinessID string
+
+	// JournalPath, if set, is the file in which the IDs of the created
+	// resources are kept. Running the restore again with the same journal
+	// only creates the resources which are not in it yet.
+	JournalPath string
+}
+
+// Restore restores the snapshot, carrying on past resources which fail.
+// The error is only set if the restore could not run, or stopped early
+// because ctx is done; the report shows any resources which failed.
+func (r *Restorer) Restore(ctx context.Context, s *Snapshot) (*RestoreReport, error) {
+	sourceID := ""
+	if s.Business != nil && s.Business.ID != nil {
+		sourceID = *s.Business.ID
+	}
+	journal, err := r.loadJournal(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	existing, _, err := r.Client.Accounts.ListContext(ctx, r.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := new(RestoreReport)
+	// create records the outcome of creating the resource with the given
+	// old ID, unless the journal shows it was created already.
+	create := func(resource, oldID, name string, do func() (string, error)) (*Item, error) {
+		item := &Item{Resource: resource, OldID: oldID, Name: name}
+		report.Items = append(report.Items, item)
+		key := resource + "/" + oldID
+		if id, ok := journal.IDs[key]; ok {
+			item.NewID, item.Outcome = id, Resumed
+			return item, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id, err := do()
+		if err != nil {
+			item.Outcome, item.Err = Failed, err
+			return item, ctx.Err()
+		}
+		item.NewID, item.Outcome = id, Created
+		journal.IDs[key] = id
+		return item, r.saveJournal(journal)
+	}
+
+	accounts := make(map[string]string) // old ID to new ID
+	for _, a := range s.Accounts {
+		oldID := intID(a.ID)
+		if a.CanDelete != nil && !*a.CanDelete {
+			item := &Item{Resource: "accounts", OldID: oldID, Name: deref(a.Name), Outcome: Skipped}
+			if match := matchAccount(existing, a); match != nil {
+				item.NewID, item.Outcome = intID(match.ID), Matched
+				accounts[oldID] = item.NewID
+			} else {
+				item.Notes = append(item.Notes, "no account of the same name and type in the target business")
+			}
+			report.Items = append(report.Items, item)
+			continue
+		}
+		account := a
+		account.ID, account.URL, account.DateCreated, account.DateModified = nil, nil, nil, nil
+		account.CanDelete, account.IsCurrencyEditable, account.IsNameEditable, account.IsPaymentEditable = nil, nil, nil, nil
+		item, err := create("accounts", oldID, deref(a.Name), func() (string, error) {
+			created, _, err := r.Client.Accounts.CreateContext(ctx, r.BusinessID, &account)
+			if err != nil {
+				return "", err
+			}
+			return intID(created.ID), nil
+		})
+		if err != nil {
+			return report, err
+		}
+		if item.NewID != "" {
+			accounts[oldID] = item.NewID
+		}
+	}
+
+	for _, c := range s.Customers {
+		customer := c
+		customer.ID, customer.URL, customer.DateCreated, customer.DateModified = 0, nil, nil, nil
+		_, err := create("customers", strconv.FormatUint(c.ID, 10), c.String(), func() (string, error) {
+			created, _, err := r.Client.Customers.CreateContext(ctx, r.BusinessID, &customer)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatUint(created.ID, 10), nil
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	for _, p := range s.Products {
+		product := p
+		product.ID, product.URL, product.DateCreated, product.DateModified = nil, nil, nil, nil
+		var notes []string
+		remap := func(name string, account *wave.Account) *wave.Account {
+			if account == nil {
+				return nil
+			}
+			oldID := intID(account.ID)
+			newID, err := strconv.Atoi(accounts[oldID])
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("%v account %v was not restored", name, oldID))
+				return nil
+			}
+			return &wave.Account{ID: &newID}
+		}
+		product.IncomeAccount = remap("income", p.IncomeAccount)
+		product.ExpenseAccount = remap("expense", p.ExpenseAccount)
+		item, err := create("products", intID(p.ID), deref(p.Name), func() (string, error) {
+			created, _, err := r.Client.Products.CreateContext(ctx, r.BusinessID, &product)
+			if err != nil {
+				return "", err
+			}
+			return intID(created.ID), nil
+		})
+		if item != nil && item.Outcome == Created {
+			item.Notes = notes
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// matchAccount returns the account of existing with the name and type of a,
+// or nil if there is none.
+func matchAccount(existing []wave.Account, a wave.Account) *wave.Account {
+	for i, e := range existing {
+		if deref(e.Name) == deref(a.Name) && deref(e.AccountType) == deref(a.AccountType) {
+			return &existing[i]
+		}
+	}
+	return nil
+}
+
+func (r *Restorer) loadJournal(sourceID string) (*Journal, error) {
+	journal := &Journal{SourceBusinessID: sourceID, TargetBusinessID: r.BusinessID, IDs: make(map[string]string)}
+	if r.JournalPath == "" {
+		return journal, nil
+	}
+	data, err := os.ReadFile(r.JournalPath)
+	if os.IsNotExist(err) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := new(Journal)
+	if err := json.Unmarshal(data, saved); err != nil {
+		return nil, fmt.Errorf("backup: journal %v: %v", r.JournalPath, err)
+	}
+	if saved.SourceBusinessID != sourceID || saved.TargetBusinessID != r.BusinessID {
+		return nil, fmt.Errorf("backup: journal %v is for restoring business %v into %v, not %v into %v",
+			r.JournalPath, saved.SourceBusinessID, saved.TargetBusinessID, sourceID, r.BusinessID)
+	}
+	if saved.IDs == nil {
+		saved.IDs = make(map[string]string)
+	}
+	return saved, nil
+}
+
+// saveJournal writes the journal to a temporary file first, so that a crash
+// never leaves a partial journal behind.
+func (r *Restorer) saveJournal(journal *Journal) error {
+	if r.JournalPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(r.JournalPath), filepath.Base(r.JournalPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), r.JournalPath)
+}
+
+func intID[T int | uint64](id *T) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprint(*id)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestore(t *testing.T) {
+	Convey("Restoring an archive into another business", t, func() {
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		ctx := context.Background()
+		client := srv.Client()
+
+		bID := seed(srv)
+		srv.AddAccount(bID, &wave.Account{Name: wave.String("Accounts Receivable"), AccountType: wave.String("asset"), CanDelete: wave.Bool(false)})
+		srv.AddAccount(bID, &wave.Account{Name: wave.String("Owner's Equity"), AccountType: wave.String("equity"), CanDelete: wave.Bool(false)})
+		snapshot, err := Fetch(ctx, client, bID)
+		So(err, ShouldBeNil)
+		equity := snapshot.Accounts[3]
+		snapshot.Products = append(snapshot.Products, wave.Product{
+			Name:           wave.String("Gadget"),
+			Price:          wave.Amount("2"),
+			ID:             wave.Uint64(999),
+			IncomeAccount:  &snapshot.Accounts[0],
+			ExpenseAccount: &equity,
+		})
+
+		target := srv.AddBusiness(&wave.Business{
+			CompanyName:         wave.String("Franchise"),
+			PrimaryCurrencyCode: wave.String("USD"),
+		})
+		tID := *target.ID
+		receivable := srv.AddAccount(tID, &wave.Account{Name: wave.String("Accounts Receivable"), AccountType: wave.String("asset"), CanDelete: wave.Bool(false)})
+		restorer := &Restorer{Client: client, BusinessID: tID, JournalPath: filepath.Join(t.TempDir(), "journal.json")}
+
+		Convey("should recreate its resources with new IDs", func() {
+			report, err := restorer.Restore(ctx, snapshot)
+			So(err, ShouldBeNil)
+			So(report.String(), ShouldEqual, "19 created, 0 resumed, 1 matched, 1 skipped, 0 failed")
+
+			accounts, _, err := client.Accounts.List(tID)
+			So(err, ShouldBeNil)
+			So(accounts, ShouldHaveLength, 3)
+			So(*accounts[1].Name, ShouldEqual, "Sales")
+			So(*accounts[1].CanDelete, ShouldBeTrue)
+
+			receivableItem := report.Items[2]
+			So(receivableItem.Outcome, ShouldEqual, Matched)
+			So(receivableItem.NewID, ShouldEqual, fmt.Sprint(*receivable.ID))
+			So(report.Items[3].Outcome, ShouldEqual, Skipped)
+
+			customers, err := wave.ListAll(ctx, client.Customers.ListIter(tID, nil), 0)
+			So(err, ShouldBeNil)
+			So(customers, ShouldHaveLength, wavetest.DefaultPageSize+5)
+			So(*customers[0].Name, ShouldEqual, "Customer 0")
+
+			products, _, err := client.Products.List(tID, nil)
+			So(err, ShouldBeNil)
+			So(products, ShouldHaveLength, 2)
+			So(*products[0].IncomeAccount.ID, ShouldEqual, *accounts[1].ID)
+			So(*products[0].IncomeAccount.ID, ShouldNotEqual, *snapshot.Accounts[0].ID)
+			So(products[1].ExpenseAccount, ShouldBeNil)
+			So(report.Items[len(report.Items)-1].Notes, ShouldResemble, []string{fmt.Sprintf("expense account %d was not restored", *equity.ID)})
+
+			Convey("and running it again should create nothing", func() {
+				report, err := restorer.Restore(ctx, snapshot)
+				So(err, ShouldBeNil)
+				So(report.Count("", Created), ShouldEqual, 0)
+				So(report.Count("", Resumed), ShouldEqual, 19)
+				products, _, _ := client.Products.List(tID, nil)
+				So(products, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("should carry on after failures and resume them when run again", func() {
+			srv.Fail(wavetest.Failure{Method: "POST", Path: "/businesses/*/accounts/", Status: http.StatusBadRequest})
+			report, err := restorer.Restore(ctx, snapshot)
+			So(err, ShouldBeNil)
+			So(report.Items[0].Outcome, ShouldEqual, Failed)
+			So(errors.Is(report.Items[0].Err, wave.ErrValidation), ShouldBeTrue)
+			So(report.Count("", Failed), ShouldEqual, 1)
+			So(report.Count("products", Created), ShouldEqual, 2)
+			So(report.Items[len(report.Items)-2].Notes, ShouldResemble, []string{fmt.Sprintf("income account %d was not restored", *snapshot.Accounts[0].ID)})
+
+			report, err = restorer.Restore(ctx, snapshot)
+			So(err, ShouldBeNil)
+			So(report.Count("", Created), ShouldEqual, 1)
+			So(report.Count("", Failed), ShouldEqual, 0)
+			accounts, _, _ := client.Accounts.List(tID)
+			So(accounts, ShouldHaveLength, 3)
+		})
+
+		Convey("should stop when the context is done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err := restorer.Restore(ctx, snapshot)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+
+		Convey("should refuse a journal of another restore", func() {
+			_, err := restorer.Restore(ctx, snapshot)
+			So(err, ShouldBeNil)
+			restorer.BusinessID = bID
+			_, err = restorer.Restore(ctx, snapshot)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "is for restoring business")
+		})
+
+		Convey("should not need a journal", func() {
+			restorer.JournalPath = ""
+			report, err := restorer.Restore(ctx, snapshot)
+			So(err, ShouldBeNil)
+			So(report.Count("", Created), ShouldEqual, 19)
+			_, err = os.Stat(restorer.JournalPath)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRestoreReport(t *testing.T) {
+	Convey("A restore report should be written as CSV", t, func() {
+		report := &RestoreReport{Items: []*Item{
+			{Resource: "accounts", OldID: "1", NewID: "7", Name: "Sales", Outcome: Created},
+			{Resource: "products", OldID: "2", Name: "Widget", Outcome: Failed, Notes: []string{"a", "b"}, Err: errors.New("boom")},
+		}}
+		var buf bytes.Buffer
+		So(report.WriteCSV(&buf), ShouldBeNil)
+		records, err := csv.NewReader(&buf).ReadAll()
+		So(err, ShouldBeNil)
+		So(records, ShouldResemble, [][]string{
+			{"resource", "old_id", "new_id", "name", "outcome", "notes", "error"},
+			{"accounts", "1", "7", "Sales", "created", "", ""},
+			{"products", "2", "", "Widget", "failed", "a; b", "boom"},
+		})
+	})
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// BillsService handles communication with the bill related methods of the Wave API.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html
+type BillsService struct {
+	client *Client
+}
+
+// Bill statuses. A bill is unpaid until payments are recorded against it,
+// and becomes overdue once its due date passes without being paid in full.
+const (
+	BillStatusUnpaid  = "unpaid"
+	BillStatusPartial = "partial"
+	BillStatusPaid    = "paid"
+	BillStatusOverdue = "overdue"
+)
+
+// BillItem represents a line item on a Bill. Each item is expensed to an
+// Account, either directly or through the expense account of its Product.
+type BillItem struct {
+	ID          *uint64  `json:"id,omitempty"`
+	Product     *Product `json:"product,omitempty"`
+	Account     *Account `json:"account,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	Price       *Money   `json:"price,omitempty"`
+	Amount      *Money   `json:"amount,omitempty"`
+	Taxes       []Tax    `json:"taxes,omitempty"`
+}
+
+// ExpenseAccount returns the account a bill item is expensed to: the item's
+// own Account if it has one, otherwise the ExpenseAccount of its Product.
+// It returns nil if neither is set.
+func (i BillItem) ExpenseAccount() *Account {
+	if i.Account != nil {
+		return i.Account
+	}
+	if i.Product != nil {
+		return i.Product.ExpenseAccount
+	}
+	return nil
+}
+
+// Bill represents a payable owed to a Vendor.
+type Bill struct {
+	ID           uint64     `json:"id,omitempty"`
+	URL          *string    `json:"url,omitempty"`
+	BillNumber   *string    `json:"bill_number,omitempty"`
+	PONumber     *string    `json:"po_number,omitempty"`
+	Status       *string    `json:"status,omitempty"`
+	Vendor       *Vendor    `json:"vendor,omitempty"`
+	Currency     *Currency  `json:"currency,omitempty"`
+	BillDate     *Date      `json:"bill_date,omitempty"`
+	DueDate      *Date      `json:"due_date,omitempty"`
+	Items        []BillItem `json:"items,omitempty"`
+	Subtotal     *Money     `json:"subtotal,omitempty"`
+	TaxTotal     *Money     `json:"tax_total,omitempty"`
+	Total        *Money     `json:"total,omitempty"`
+	AmountPaid   *Money     `json:"amount_paid,omitempty"`
+	AmountDue    *Money     `json:"amount_due,omitempty"`
+	Memo         *string    `json:"memo,omitempty"`
+	DateCreated  *DateTime  `json:"date_created,omitempty"`
+	DateModified *DateTime  `json:"date_modified,omitempty"`
+}
+
+func (b Bill) String() string {
+	return fmt.Sprintf("Bill %v (status=%v)", deref(b.BillNumber), deref(b.Status))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The amounts of the bill and its items take the bill's currency.
+func (b *Bill) UnmarshalJSON(data []byte) error {
+	type bill Bill
+	if err := json.Unmarshal(data, (*bill)(b)); err != nil {
+		return err
+	}
+	withCurrency(b.Currency, b.Subtotal, b.TaxTotal, b.Total, b.AmountPaid, b.AmountDue)
+	for i := range b.Items {
+		withCurrency(b.Currency, b.Items[i].Price, b.Items[i].Amount)
+	}
+	return nil
+}
+
+// BillPayment represents a payment made against a Bill.
+type BillPayment struct {
+	ID            *uint64   `json:"id,omitempty"`
+	URL           *string   `json:"url,omitempty"`
+	Amount        *Money    `json:"amount,omitempty"`
+	PaymentDate   *Date     `json:"payment_date,omitempty"`
+	PaymentMethod *string   `json:"payment_method,omitempty"`
+	Account       *Account  `json:"payment_account,omitempty"`
+	Memo          *string   `json:"memo,omitempty"`
+	DateCreated   *DateTime `json:"date_created,omitempty"`
+	DateModified  *DateTime `json:"date_modified,omitempty"`
+}
+
+// BillListOptions specifies the optional parameters to LIST endpoint.
+type BillListOptions struct {
+	// VendorID only lists bills owed to the given vendor.
+	VendorID uint64 `url:"vendor_id,omitempty"`
+	// DueDateStart and DueDateEnd only list bills due within the range,
+	// inclusive.
+	DueDateStart *Date `url:"due_date_start,omitempty"`
+	DueDateEnd   *Date `url:"due_date_end,omitempty"`
+
+	PageOptions
+}
+
+// List all bills for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#get--businesses-{business_id}-bills-
+func (service *BillsService) List(businessID string, opts *BillListOptions) ([]Bill, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *BillsService) ListContext(ctx context.Context, businessID string, opts *BillListOptions) ([]Bill, *Response, error) {
+	ctx = withOperation(ctx, "Bills.List")
+	url := fmt.Sprintf("businesses/%v/bills/", businessID)
+	url, err := addOptions(url, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	bills := new([]Bill)
+	resp, err := service.client.Do(req, bills)
+	if err != nil {
+		return nil, resp, err
+	}
+	return *bills, resp, nil
+}
+
+// ListIter returns an Iterator over every bill for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *BillsService) ListIter(businessID string, opts *BillListOptions) *Iterator[Bill] {
+	o := new(BillListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Bill, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
+// Get an existing bill for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#get--businesses-{business_id}-bills-{bill_id}-
+func (service *BillsService) Get(businessID string, billID uint64) (*Bill, *Response, error) {
+	return service.GetContext(context.Background(), businessID, billID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *BillsService) GetContext(ctx context.Context, businessID string, billID uint64) (*Bill, *Response, error) {
+	ctx = withOperation(ctx, "Bills.Get")
+	url := fmt.Sprintf("businesses/%v/bills/%v/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	bill := new(Bill)
+	resp, err := service.client.Do(req, bill)
+	if err != nil {
+		return nil, resp, err
+	}
+	return bill, resp, nil
+}
+
+// Create a new bill for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#post--businesses-{business_id}-bills-
+func (service *BillsService) Create(businessID string, bill *Bill) (*Bill, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, bill)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *BillsService) CreateContext(ctx context.Context, businessID string, bill *Bill) (*Bill, *Response, error) {
+	ctx = withOperation(ctx, "Bills.Create")
+	url := fmt.Sprintf("businesses/%v/bills/", businessID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, bill)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := new(Bill)
+	resp, err := service.client.Do(req, b)
+	if err != nil {
+		return nil, resp, err
+	}
+	return b, resp, nil
+}
+
+// Replace an existing bill. You cannot create a bill using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#put--businesses-{business_id}-bills-{bill_id}-
+func (service *BillsService) Replace(businessID string, billID uint64, bill *Bill) (*Bill, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, billID, bill)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *BillsService) ReplaceContext(ctx context.Context, businessID string, billID uint64, bill *Bill) (*Bill, *Response, error) {
+	ctx = withOperation(ctx, "Bills.Replace")
+	url := fmt.Sprintf("businesses/%v/bills/%v/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, bill)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := new(Bill)
+	resp, err := service.client.Do(req, b)
+	if err != nil {
+		return nil, resp, err
+	}
+	return b, resp, nil
+}
+
+// Update an existing bill. You cannot create a bill using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#patch--businesses-{business_id}-bills-{bill_id}-
+func (service *BillsService) Update(businessID string, billID uint64, bill *Bill) (*Bill, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, billID, bill)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *BillsService) UpdateContext(ctx context.Context, businessID string, billID uint64, bill *Bill) (*Bill, *Response, error) {
+	ctx = withOperation(ctx, "Bills.Update")
+	url := fmt.Sprintf("businesses/%v/bills/%v/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, bill)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := new(Bill)
+	resp, err := service.client.Do(req, b)
+	if err != nil {
+		return nil, resp, err
+	}
+	return b, resp, nil
+}
+
+// Delete an existing bill.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#delete--businesses-{business_id}-bills-{bill_id}-
+func (service *BillsService) Delete(businessID string, billID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, billID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *BillsService) DeleteContext(ctx context.Context, businessID string, billID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Bills.Delete")
+	url := fmt.Sprintf("businesses/%v/bills/%v/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return service.client.Do(req, nil)
+}
+
+// ListPayments lists the payments recorded against a bill.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#get--businesses-{business_id}-bills-{bill_id}-payments-
+func (service *BillsService) ListPayments(businessID string, billID uint64) ([]BillPayment, *Response, error) {
+	return service.ListPaymentsContext(context.Background(), businessID, billID)
+}
+
+// ListPaymentsContext is like ListPayments but carries ctx through to the HTTP request.
+func (service *BillsService) ListPaymentsContext(ctx context.Context, businessID string, billID uint64) ([]BillPayment, *Response, error) {
+	ctx = withOperation(ctx, "Bills.ListPayments")
+	url := fmt.Sprintf("businesses/%v/bills/%v/payments/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	payments := new([]BillPayment)
+	resp, err := service.client.Do(req, payments)
+	if err != nil {
+		return nil, resp, err
+	}
+	return *payments, resp, nil
+}
+
+// RecordPayment records a payment against a bill.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/bills.html#post--businesses-{business_id}-bills-{bill_id}-payments-
+func (service *BillsService) RecordPayment(businessID string, billID uint64, payment *BillPayment) (*BillPayment, *Response, error) {
+	return service.RecordPaymentContext(context.Background(), businessID, billID, payment)
+}
+
+// RecordPaymentContext is like RecordPayment but carries ctx through to the HTTP request.
+func (service *BillsService) RecordPaymentContext(ctx context.Context, businessID string, billID uint64, payment *BillPayment) (*BillPayment, *Response, error) {
+	ctx = withOperation(ctx, "Bills.RecordPayment")
+	url := fmt.Sprintf("businesses/%v/bills/%v/payments/", businessID, billID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := new(BillPayment)
+	resp, err := service.client.Do(req, p)
+	if err != nil {
+		return nil, resp, err
+	}
+	return p, resp, nil
+}
@@ -0,0 +1,340 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	expectedBillJSON = `{
+		"id": 1,
+		"url": "url",
+		"bill_number": "B-42",
+		"po_number": "PO-1",
+		"status": "unpaid",
+		"vendor": {
+			"id": 1,
+			"name": "Los Pollos Hermanos"
+		},
+		"currency": {
+			"url": "url",
+			"code": "USD",
+			"symbol": "$",
+			"name": "U.S. dollar"
+		},
+		"bill_date": "2013-12-05",
+		"due_date": "2014-01-04",
+		"items": [
+			{
+				"id": 1,
+				"product": {
+					"id": 3,
+					"name": "Fryer Oil",
+					"expense_account": {
+						"id": 7,
+						"name": "Supplies"
+					}
+				},
+				"description": "Fryer Oil",
+				"quantity": 2,
+				"price": 13.37,
+				"amount": 26.74,
+				"taxes": [
+					{
+						"id": 1,
+						"name": "Harmonized Sales Tax",
+						"abbreviation": "HST",
+						"rate": 13,
+						"amount": 3.48
+					}
+				]
+			},
+			{
+				"id": 2,
+				"account": {
+					"id": 8,
+					"name": "Repairs"
+				},
+				"description": "Fryer repair",
+				"quantity": 1,
+				"price": 100,
+				"amount": 100
+			}
+		],
+		"subtotal": 126.74,
+		"tax_total": 3.48,
+		"total": 130.22,
+		"amount_paid": 0,
+		"amount_due": 130.22,
+		"memo": "Memo",
+		"date_created": "2013-12-05T10:31:01+00:00",
+		"date_modified": "2013-12-05T13:37:59+00:00"
+	}`
+	expectedBillsJSON = "[" + expectedBillJSON + "]"
+
+	expectedBillPaymentJSON = `{
+		"id": 7,
+		"url": "url",
+		"amount": 130.22,
+		"payment_date": "2013-12-20",
+		"payment_method": "cheque",
+		"payment_account": {
+			"id": 1,
+			"name": "Chequing"
+		},
+		"memo": "Paid in full",
+		"date_created": "2013-12-20T10:31:01+00:00",
+		"date_modified": "2013-12-20T10:31:01+00:00"
+	}`
+	expectedBillPaymentsJSON = "[" + expectedBillPaymentJSON + "]"
+)
+
+func TestBillsService(t *testing.T) {
+	expectedBillStruct := new(Bill)
+	json.Unmarshal([]byte(expectedBillJSON), expectedBillStruct)
+
+	expectedBillPaymentStruct := new(BillPayment)
+	json.Unmarshal([]byte(expectedBillPaymentJSON), expectedBillPaymentStruct)
+
+	Convey("Testing JSON unmarshalling of a Bill", t, func() {
+		b := expectedBillStruct
+		So(*b.Status, ShouldEqual, BillStatusUnpaid)
+		So(*b.Vendor.Name, ShouldEqual, "Los Pollos Hermanos")
+		So(time.Time(*b.DueDate).Format("2006-01-02"), ShouldEqual, "2014-01-04")
+		So(time.Time(*b.DateCreated).Format(time.RFC3339), ShouldEqual, "2013-12-05T10:31:01Z")
+		So(len(b.Items), ShouldEqual, 2)
+		So(*b.Items[0].Taxes[0].Abbreviation, ShouldEqual, "HST")
+		So(b.Total.Decimal(), ShouldEqual, "130.22")
+	})
+
+	Convey("The expense account of a BillItem", t, func() {
+		items := expectedBillStruct.Items
+
+		Convey("Should come from its Product when it has no Account", func() {
+			So(*items[0].ExpenseAccount().Name, ShouldEqual, "Supplies")
+		})
+
+		Convey("Should be its own Account when one is set", func() {
+			So(*items[1].ExpenseAccount().Name, ShouldEqual, "Repairs")
+
+			i := BillItem{
+				Product: &Product{ExpenseAccount: &Account{ID: Int(7)}},
+				Account: &Account{ID: Int(8)},
+			}
+			So(*i.ExpenseAccount().ID, ShouldEqual, 8)
+		})
+
+		Convey("Should be nil when neither is set", func() {
+			So(BillItem{}.ExpenseAccount(), ShouldBeNil)
+			So(BillItem{Product: &Product{}}.ExpenseAccount(), ShouldBeNil)
+		})
+	})
+
+	Convey("LIST all Bills for a business", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedBillsJSON)
+		})
+
+		bills, _, err := client.Bills.List("1", nil)
+		b := []Bill{*expectedBillStruct}
+		So(err, ShouldEqual, nil)
+		So(bills, ShouldResemble, b)
+	})
+
+	Convey("LIST all Bills for a business with filters", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.URL.RawQuery, ShouldEqual, "due_date_end=2014-01-31&due_date_start=2014-01-01&page=2&vendor_id=5")
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedBillsJSON)
+		})
+
+		start := Date(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC))
+		end := Date(time.Date(2014, time.January, 31, 0, 0, 0, 0, time.UTC))
+		opts := &BillListOptions{
+			VendorID:     5,
+			DueDateStart: &start,
+			DueDateEnd:   &end,
+			PageOptions:  PageOptions{Page: 2},
+		}
+		_, _, err := client.Bills.List("1", opts)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("LIST all Bills for a business invalid ID", t, func() {
+		_, resp, err := client.Bills.List("%", nil)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("GET a specific Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedBillJSON)
+		})
+
+		bill, _, err := client.Bills.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(bill, ShouldResemble, expectedBillStruct)
+	})
+
+	Convey("GET a specific Bill with an invalid ID", t, func() {
+		bill, resp, err := client.Bills.Get("%", 1)
+		checkInvalidURLError(bill, resp, err)
+	})
+
+	Convey("CREATE a Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"vendor":{"id":1},"due_date":"2014-01-04","items":[{"product":{"id":3},"quantity":2},{"account":{"id":8},"price":100}]}`+"\n")
+			fmt.Fprint(w, expectedBillJSON)
+		})
+
+		due := Date(time.Date(2014, time.January, 4, 0, 0, 0, 0, time.UTC))
+		b := &Bill{
+			Vendor:  &Vendor{ID: 1},
+			DueDate: &due,
+			Items: []BillItem{
+				{Product: &Product{ID: Uint64(3)}, Quantity: Float64(2)},
+				{Account: &Account{ID: Int(8)}, Price: Amount("100")},
+			},
+		}
+		bill, _, err := client.Bills.Create("1", b)
+		So(err, ShouldBeNil)
+		So(bill, ShouldResemble, expectedBillStruct)
+	})
+
+	Convey("CREATE a Bill with an invalid ID", t, func() {
+		bill, resp, err := client.Bills.Create("%", &Bill{})
+		checkInvalidURLError(bill, resp, err)
+	})
+
+	Convey("REPLACE a Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PUT")
+			fmt.Fprint(w, expectedBillJSON)
+		})
+
+		bill, _, err := client.Bills.Replace("1", 1, &Bill{})
+		So(err, ShouldBeNil)
+		So(bill, ShouldResemble, expectedBillStruct)
+	})
+
+	Convey("REPLACE a Bill with an invalid ID", t, func() {
+		bill, resp, err := client.Bills.Replace("%", 1, &Bill{})
+		checkInvalidURLError(bill, resp, err)
+	})
+
+	Convey("UPDATE a Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PATCH")
+			fmt.Fprint(w, expectedBillJSON)
+		})
+
+		bill, _, err := client.Bills.Update("1", 1, &Bill{})
+		So(err, ShouldEqual, nil)
+		So(bill, ShouldResemble, expectedBillStruct)
+	})
+
+	Convey("UPDATE a Bill with an invalid ID", t, func() {
+		bill, resp, err := client.Bills.Update("%", 1, &Bill{})
+		checkInvalidURLError(bill, resp, err)
+	})
+
+	Convey("DELETE a specific Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "DELETE")
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		_, err := client.Bills.Delete("1", 1)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("DELETE a specific Bill with an invalid ID", t, func() {
+		resp, err := client.Bills.Delete("%", 1)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("LIST the payments on a Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/payments/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedBillPaymentsJSON)
+		})
+
+		payments, _, err := client.Bills.ListPayments("1", 1)
+		So(err, ShouldBeNil)
+		So(payments, ShouldResemble, []BillPayment{*expectedBillPaymentStruct})
+	})
+
+	Convey("LIST the payments on a Bill with an invalid ID", t, func() {
+		_, resp, err := client.Bills.ListPayments("%", 1)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("RECORD a payment on a Bill", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/bills/1/payments/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"amount":130.22,"payment_date":"2013-12-20","payment_account":{"id":1}}`+"\n")
+			fmt.Fprint(w, expectedBillPaymentJSON)
+		})
+
+		date := Date(time.Date(2013, time.December, 20, 0, 0, 0, 0, time.UTC))
+		p := &BillPayment{Amount: Amount("130.22"), PaymentDate: &date, Account: &Account{ID: Int(1)}}
+		payment, _, err := client.Bills.RecordPayment("1", 1, p)
+		So(err, ShouldBeNil)
+		So(payment, ShouldResemble, expectedBillPaymentStruct)
+	})
+
+	Convey("RECORD a payment on a Bill with an invalid ID", t, func() {
+		payment, resp, err := client.Bills.RecordPayment("%", 1, &BillPayment{})
+		checkInvalidURLError(payment, resp, err)
+	})
+
+	Convey("String method on Bill", t, func() {
+		b := new(Bill)
+		b.BillNumber = String("B-42")
+		b.Status = String(BillStatusPaid)
+		So(b.String(), ShouldEqual, "Bill B-42 (status=paid)")
+	})
+}
@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // BusinessesService handles communication with the business related methods of the Wave API.
 //
@@ -44,7 +47,7 @@ type Business struct {
 }
 
 func (b Business) String() string {
-	return fmt.Sprintf("%v (id=%v)", *b.CompanyName, *b.ID)
+	return fmt.Sprintf("%v (id=%v)", deref(b.CompanyName), deref(b.ID))
 }
 
 // BusinessListOptions specifies the optional parameters to the LIST endpoint
@@ -56,11 +59,17 @@ type BusinessListOptions struct {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#get--businesses-
 func (service *BusinessesService) List(opts *BusinessListOptions) ([]Business, *Response, error) {
+	return service.ListContext(context.Background(), opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *BusinessesService) ListContext(ctx context.Context, opts *BusinessListOptions) ([]Business, *Response, error) {
+	ctx = withOperation(ctx, "Businesses.List")
 	url, err := addOptions("businesses/", opts)
 	if err != nil {
 		return nil, nil, err
 	}
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -72,12 +81,31 @@ func (service *BusinessesService) List(opts *BusinessListOptions) ([]Business, *
 	return *businesses, resp, nil
 }
 
+// ListIter returns an Iterator over every business owned by the authenticated user.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *BusinessesService) ListIter(opts *BusinessListOptions) *Iterator[Business] {
+	o := new(BusinessListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Business, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, o)
+	})
+}
+
 // Get an existing business.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#get--businesses-(identity_business_id)-
 func (service *BusinessesService) Get(id string) (*Business, *Response, error) {
+	return service.GetContext(context.Background(), id)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *BusinessesService) GetContext(ctx context.Context, id string) (*Business, *Response, error) {
+	ctx = withOperation(ctx, "Businesses.Get")
 	u := fmt.Sprintf("businesses/%v/", id)
-	req, err := service.client.NewRequest("GET", u, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -93,7 +121,13 @@ func (service *BusinessesService) Get(id string) (*Business, *Response, error) {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#post--businesses-
 func (service *BusinessesService) Create(business *Business) (*Business, *Response, error) {
-	req, err := service.client.NewRequest("POST", "businesses/", business)
+	return service.CreateContext(context.Background(), business)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *BusinessesService) CreateContext(ctx context.Context, business *Business) (*Business, *Response, error) {
+	ctx = withOperation(ctx, "Businesses.Create")
+	req, err := service.client.NewRequestContext(ctx, "POST", "businesses/", business)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -109,8 +143,14 @@ func (service *BusinessesService) Create(business *Business) (*Business, *Respon
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#put--businesses-(identity_business_id)-
 func (service *BusinessesService) Replace(id string, business *Business) (*Business, *Response, error) {
+	return service.ReplaceContext(context.Background(), id, business)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *BusinessesService) ReplaceContext(ctx context.Context, id string, business *Business) (*Business, *Response, error) {
+	ctx = withOperation(ctx, "Businesses.Replace")
 	url := fmt.Sprintf("businesses/%v/", id)
-	req, err := service.client.NewRequest("PUT", url, business)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, business)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -126,8 +166,14 @@ func (service *BusinessesService) Replace(id string, business *Business) (*Busin
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/businesses.html#patch--businesses-(identity_business_id)-
 func (service *BusinessesService) Update(id string, business *Business) (*Business, *Response, error) {
+	return service.UpdateContext(context.Background(), id, business)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *BusinessesService) UpdateContext(ctx context.Context, id string, business *Business) (*Business, *Response, error) {
+	ctx = withOperation(ctx, "Businesses.Update")
 	url := fmt.Sprintf("businesses/%v/", id)
-	req, err := service.client.NewRequest("PATCH", url, business)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, business)
 	if err != nil {
 		return nil, nil, err
 	}
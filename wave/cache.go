@@ -0,0 +1,480 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderFromCache is set on responses served by a Cache, whether without
+// asking the API or after the API answered 304 Not Modified.
+const HeaderFromCache = "X-From-Cache"
+
+// CacheEntry is a response kept by a Cache.
+type CacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+
+	// Expires is when the entry stops being fresh. Until then it is served
+	// without asking the API; afterwards it is revalidated with its ETag or
+	// Last-Modified header.
+	Expires time.Time `json:"expires"`
+}
+
+// CacheStore keeps the entries of a Cache, keyed by the URL of the request.
+// It must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry with the given key, or nil if there is none.
+	Get(key string) (*CacheEntry, error)
+	Set(key string, entry *CacheEntry) error
+	Delete(key string) error
+	Keys() ([]string, error)
+}
+
+// Cache caches the responses of GET requests, so that they can be served
+// without asking the API while they are fresh, and revalidated with
+// If-None-Match and If-Modified-Since headers once they are not. A 304 Not
+// Modified response is answered with the cached body.
+//
+// Requests which change a resource, such as a POST or a PUT, invalidate the
+// cached responses of its top-level collection, such as the invoices of a
+// business, and of everything under it, such as each invoice.
+//
+// The key of a response is its URL, so a store must not be shared by clients
+// of different users.
+type Cache struct {
+	Store CacheStore
+
+	// TTL is how long the responses of each operation, as returned by
+	// Operation, stay fresh, such as a day for "Currencies.List". Responses
+	// of other operations are revalidated every time, so they are only kept
+	// if they have an ETag or Last-Modified header.
+	TTL map[string]time.Duration
+}
+
+// NewCache returns a Cache which keeps its entries in store.
+func NewCache(store CacheStore) *Cache {
+	return &Cache{Store: store, TTL: make(map[string]time.Duration)}
+}
+
+// uncachedHeaders are the headers of a response which are not kept, since
+// they describe the response rather than the resource.
+var uncachedHeaders = []string{headerRateLimit, headerRateRemaining, headerRateReset, "Date", "Set-Cookie"}
+
+// InvalidationError is returned, along with the response, by a request which
+// changed a resource when the cached responses of the resource could not be
+// invalidated afterwards. The change was made, and the response to it is
+// returned as usual, but the cache may serve stale responses of the resource
+// until they are purged.
+type InvalidationError struct {
+	URL string // of the request which changed the resource
+	Err error  // returned by the CacheStore
+}
+
+func (e *InvalidationError) Error() string {
+	return fmt.Sprintf("wave: invalidating the cache after %v: %v", e.URL, e.Err)
+}
+
+// Unwrap returns the error of the CacheStore.
+func (e *InvalidationError) Unwrap() error { return e.Err }
+
+// send sends req with the client, serving and storing GET requests from the
+// cache and invalidating it after other requests.
+func (cache *Cache) send(c *Client, req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		resp, err := c.send(req)
+		if ierr := cache.invalidate(c, req); ierr != nil && err == nil {
+			err = &InvalidationError{URL: req.URL.String(), Err: ierr}
+		}
+		return resp, err
+	}
+
+	key := req.URL.String()
+	entry, err := cache.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	ttl := cache.TTL[Operation(req)]
+	if entry != nil {
+		if time.Now().Before(entry.Expires) {
+			return entry.response(req), nil
+		}
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		entry.Expires = time.Now().Add(ttl)
+		if err := cache.Store.Set(key, entry); err != nil {
+			return nil, err
+		}
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK || !cacheable(resp.Header, ttl) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	entry = &CacheEntry{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: body, Expires: time.Now().Add(ttl)}
+	for _, h := range uncachedHeaders {
+		entry.Header.Del(h)
+	}
+	return resp, cache.Store.Set(key, entry)
+}
+
+// cacheable reports whether a response with the given headers can be kept,
+// either because it stays fresh for a while or because it can be
+// revalidated.
+func cacheable(h http.Header, ttl time.Duration) bool {
+	if strings.Contains(h.Get("Cache-Control"), "no-store") {
+		return false
+	}
+	return ttl > 0 || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// response returns the cached response to req.
+func (e *CacheEntry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	header.Set(HeaderFromCache, "1")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %v", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// invalidate deletes the entries of the top-level collection of the resource
+// changed by req, and of everything under it. The collections of a business,
+// such as "businesses/1/invoices/", are top-level ones, so that approving an
+// invoice at "businesses/1/invoices/5/approve/" or paying a bill at
+// "businesses/1/bills/5/payments/" invalidates the list of invoices or bills.
+func (cache *Cache) invalidate(c *Client, req *http.Request) error {
+	base := strings.TrimSuffix(c.BaseURL.Path, "/") + "/"
+	if !strings.HasPrefix(req.URL.Path, base) {
+		base = "/"
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, base), "/"), "/")
+	n := 1
+	if segments[0] == "businesses" && len(segments) > 2 {
+		n = 3
+	}
+	collection := *req.URL
+	collection.Path = strings.TrimSuffix(path.Join(base, path.Join(segments[:n]...)), "/") + "/"
+	collection.RawPath, collection.RawQuery, collection.Fragment = "", "", ""
+	return cache.Purge(collection.String())
+}
+
+// Purge deletes the entries whose URL starts with prefix, or all of them if
+// prefix is empty.
+func (cache *Cache) Purge(prefix string) error {
+	keys, err := cache.Store.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			if err := cache.Store.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MemoryStore is a CacheStore which keeps entries in memory, evicting the
+// least recently used entry once it holds its maximum number of them.
+type MemoryStore struct {
+	max int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *memoryEntry, the most recently used first
+}
+
+type memoryEntry struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryStore returns a MemoryStore which holds up to max entries, or any
+// number of them if max is 0.
+func NewMemoryStore(max int) *MemoryStore {
+	return &MemoryStore{max: max, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Get implements the CacheStore interface.
+func (s *MemoryStore) Get(key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	s.lru.MoveToFront(el)
+	entry := *el.Value.(*memoryEntry).entry
+	return &entry, nil
+}
+
+// Set implements the CacheStore interface.
+func (s *MemoryStore) Set(key string, entry *CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*memoryEntry).entry = entry
+		s.lru.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key, entry})
+	if s.max > 0 && s.lru.Len() > s.max {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Delete implements the CacheStore interface.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.lru.Remove(el)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Keys implements the CacheStore interface.
+func (s *MemoryStore) Keys() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DiskStore is a CacheStore which keeps each entry in a JSON file in a
+// directory, so that it outlives the process. The keys of the entries are
+// listed in an index file, so that Keys does not read every entry. A
+// directory must not be used by more than one DiskStore at a time.
+type DiskStore struct {
+	dir string
+
+	mu      sync.Mutex
+	indexed map[string]bool // keys listed in the index
+}
+
+// diskEntry is the contents of the file of an entry.
+type diskEntry struct {
+	Key   string      `json:"key"`
+	Entry *CacheEntry `json:"entry"`
+}
+
+// diskIndex is the name of the index file of a DiskStore, which lists the
+// key of each entry on a line. Keys are added as entries are set, and
+// removed when Keys finds that their entries are gone.
+const diskIndex = "index"
+
+// NewDiskStore returns a DiskStore which keeps its entries in dir, creating
+// it if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &DiskStore{dir: dir, indexed: make(map[string]bool)}
+	keys, _, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		s.indexed[key] = true
+	}
+	return s, nil
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readIndex returns the keys listed in the index, without duplicates, and
+// the number of lines it has.
+func (s *DiskStore) readIndex() ([]string, int, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, diskIndex))
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil || len(data) == 0 {
+		return nil, 0, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	seen := make(map[string]bool, len(lines))
+	keys := make([]string, 0, len(lines))
+	for _, key := range lines {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, len(lines), nil
+}
+
+// writeFile replaces the file at path with data, writing it to a temporary
+// file first so that a crash never leaves a partial file.
+func (s *DiskStore) writeFile(path string, data []byte) error {
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// read returns the contents of the file at path, or nil if there is none.
+func (s *DiskStore) read(path string) (*diskEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	e := new(diskEntry)
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("wave: cache entry %v: %v", path, err)
+	}
+	return e, nil
+}
+
+// Get implements the CacheStore interface.
+func (s *DiskStore) Get(key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := s.read(s.path(key))
+	if e == nil || e.Key != key {
+		return nil, err
+	}
+	return e.Entry, nil
+}
+
+// Set implements the CacheStore interface. The key is added to the index
+// before the entry is written, so that the index lists every entry even if
+// the process crashes in between.
+func (s *DiskStore) Set(key string, entry *CacheEntry) error {
+	if strings.Contains(key, "\n") {
+		return fmt.Errorf("wave: cache key %q has a newline", key)
+	}
+	data, err := json.Marshal(diskEntry{key, entry})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.indexed[key] {
+		f, err := os.OpenFile(filepath.Join(s.dir, diskIndex), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(key + "\n")
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		s.indexed[key] = true
+	}
+	return s.writeFile(s.path(key), data)
+}
+
+// Delete implements the CacheStore interface. The key is left in the index
+// until Keys finds that its entry is gone.
+func (s *DiskStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Keys implements the CacheStore interface. It lists the keys in the index
+// whose entries exist, and rewrites the index without the others once they
+// make up most of it.
+func (s *DiskStore) Keys() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, lines, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	live := keys[:0]
+	for _, key := range keys {
+		_, err := os.Stat(s.path(key))
+		if err == nil {
+			live = append(live, key)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if lines > 2*len(live) {
+		var b strings.Builder
+		s.indexed = make(map[string]bool, len(live))
+		for _, key := range live {
+			b.WriteString(key + "\n")
+			s.indexed[key] = true
+		}
+		if err := s.writeFile(filepath.Join(s.dir, diskIndex), []byte(b.String())); err != nil {
+			return nil, err
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCache(t *testing.T) {
+	Convey("Given a client with a cache", t, func() {
+		setUp()
+		defer tearDown()
+		client.Cache = NewCache(NewMemoryStore(0))
+
+		Convey("Responses with an ETag should be revalidated and served from the cache on 304", func() {
+			var hits int
+			var conditions []string
+			mux.HandleFunc("/businesses/1/", func(w http.ResponseWriter, r *http.Request) {
+				hits++
+				conditions = append(conditions, r.Header.Get("If-None-Match"))
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set(headerRateRemaining, "10")
+				fmt.Fprint(w, `{"id": "1", "company_name": "Acme"}`)
+			})
+
+			business, resp, err := client.Businesses.Get("1")
+			So(err, ShouldBeNil)
+			So(*business.CompanyName, ShouldEqual, "Acme")
+			So(resp.Header.Get(HeaderFromCache), ShouldEqual, "")
+
+			business, resp, err = client.Businesses.Get("1")
+			So(err, ShouldBeNil)
+			So(*business.CompanyName, ShouldEqual, "Acme")
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(resp.Header.Get(HeaderFromCache), ShouldEqual, "1")
+			So(resp.Header.Get(headerRateRemaining), ShouldEqual, "")
+			So(hits, ShouldEqual, 2)
+			So(conditions, ShouldResemble, []string{"", `"v1"`})
+		})
+
+		Convey("Responses with a Last-Modified header should be revalidated with If-Modified-Since", func() {
+			modified := "Mon, 02 Jan 2006 15:04:05 GMT"
+			var since string
+			mux.HandleFunc("/currencies/", func(w http.ResponseWriter, r *http.Request) {
+				since = r.Header.Get("If-Modified-Since")
+				w.Header().Set("Last-Modified", modified)
+				fmt.Fprint(w, `[{"code": "CAD"}]`)
+			})
+
+			client.Currencies.List()
+			currencies, _, err := client.Currencies.List()
+			So(err, ShouldBeNil)
+			So(*currencies[0].Code, ShouldEqual, "CAD")
+			So(since, ShouldEqual, modified)
+		})
+
+		Convey("Fresh responses should be served without asking the API", func() {
+			client.Cache.TTL["Countries.List"] = time.Hour
+			var hits int
+			mux.HandleFunc("/countries/", func(w http.ResponseWriter, r *http.Request) {
+				hits++
+				fmt.Fprint(w, `[{"country_code": "CA"}]`)
+			})
+
+			client.Countries.List()
+			countries, resp, err := client.Countries.List()
+			So(err, ShouldBeNil)
+			So(*countries[0].CountryCode, ShouldEqual, "CA")
+			So(resp.Header.Get(HeaderFromCache), ShouldEqual, "1")
+			So(hits, ShouldEqual, 1)
+		})
+
+		Convey("Responses without validators or a TTL should not be kept", func() {
+			mux.HandleFunc("/countries/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[]`)
+			})
+			mux.HandleFunc("/currencies/", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Cache-Control", "no-store")
+				fmt.Fprint(w, `[]`)
+			})
+
+			client.Countries.List()
+			client.Currencies.List()
+			keys, _ := client.Cache.Store.Keys()
+			So(keys, ShouldBeEmpty)
+		})
+
+		Convey("Error responses should not be kept", func() {
+			client.Cache.TTL["Countries.List"] = time.Hour
+			mux.HandleFunc("/countries/", func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"error": {"message": "Not found"}}`, http.StatusNotFound)
+			})
+
+			_, _, err := client.Countries.List()
+			So(err, ShouldNotBeNil)
+			_, _, err = client.Countries.List()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Changing a resource should invalidate its collection", func() {
+			var hits int
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					hits++
+				}
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, `{}`)
+			}
+			mux.HandleFunc("/businesses/1/customers/", handler)
+			mux.HandleFunc("/businesses/1/customers/5/", handler)
+			mux.HandleFunc("/businesses/1/products/", handler)
+			mux.HandleFunc("/businesses/1/", handler)
+			client.Cache.TTL["Customers.List"] = time.Hour
+			client.Cache.TTL["Customers.Get"] = time.Hour
+			client.Cache.TTL["Products.List"] = time.Hour
+			client.Cache.TTL["Businesses.Get"] = time.Hour
+
+			client.Customers.List("1", &CustomerListOptions{PageOptions{Page: 1}})
+			client.Customers.Get("1", 5)
+			client.Products.List("1", nil)
+			client.Businesses.Get("1")
+			So(hits, ShouldEqual, 4)
+
+			_, err := client.Customers.Delete("1", 5)
+			So(err, ShouldBeNil)
+			client.Customers.List("1", &CustomerListOptions{PageOptions{Page: 1}})
+			client.Customers.Get("1", 5)
+			client.Products.List("1", nil)
+			client.Businesses.Get("1")
+			So(hits, ShouldEqual, 6)
+		})
+
+		Convey("Changing a nested resource should invalidate its top-level collection", func() {
+			var hits int
+			mux.HandleFunc("/businesses/1/invoices/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					hits++
+				}
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, `[]`)
+			})
+			mux.HandleFunc("/businesses/1/invoices/5/approve/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id": 5}`)
+			})
+			client.Cache.TTL["Invoices.List"] = time.Hour
+
+			client.Invoices.List("1", nil)
+			client.Invoices.List("1", nil)
+			So(hits, ShouldEqual, 1)
+
+			_, _, err := client.Invoices.Approve("1", 5)
+			So(err, ShouldBeNil)
+			client.Invoices.List("1", nil)
+			So(hits, ShouldEqual, 2)
+		})
+
+		Convey("A change should return its response when the cache cannot be invalidated", func() {
+			client.Cache.Store = brokenStore{NewMemoryStore(0)}
+			mux.HandleFunc("/businesses/1/customers/5/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id": 5, "name": "Jane"}`)
+			})
+
+			_, resp, err := client.Customers.Update("1", 5, &Customer{Name: String("Jane")})
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			body, _ := io.ReadAll(resp.Body)
+			So(string(body), ShouldEqual, `{"id": 5, "name": "Jane"}`)
+			var invalidation *InvalidationError
+			So(errors.As(err, &invalidation), ShouldBeTrue)
+			So(invalidation.URL, ShouldEndWith, "/businesses/1/customers/5/")
+			So(errors.Is(err, errBrokenStore), ShouldBeTrue)
+		})
+
+		Convey("Purge should delete the entries under a prefix", func() {
+			store := client.Cache.Store
+			store.Set("https://api.waveapps.com/businesses/1/customers/", &CacheEntry{})
+			store.Set("https://api.waveapps.com/businesses/1/products/", &CacheEntry{})
+			store.Set("https://api.waveapps.com/currencies/", &CacheEntry{})
+
+			So(client.Cache.Purge("https://api.waveapps.com/businesses/"), ShouldBeNil)
+			keys, _ := store.Keys()
+			So(keys, ShouldResemble, []string{"https://api.waveapps.com/currencies/"})
+
+			So(client.Cache.Purge(""), ShouldBeNil)
+			keys, _ = store.Keys()
+			So(keys, ShouldBeEmpty)
+		})
+	})
+}
+
+var errBrokenStore = errors.New("broken store")
+
+// brokenStore is a CacheStore which cannot list its keys.
+type brokenStore struct{ *MemoryStore }
+
+func (brokenStore) Keys() ([]string, error) { return nil, errBrokenStore }
+
+func TestMemoryStore(t *testing.T) {
+	Convey("A memory store should evict the least recently used entry", t, func() {
+		store := NewMemoryStore(2)
+		store.Set("a", &CacheEntry{Body: []byte("a")})
+		store.Set("b", &CacheEntry{Body: []byte("b")})
+		store.Get("a")
+		store.Set("c", &CacheEntry{Body: []byte("c")})
+
+		keys, _ := store.Keys()
+		sort.Strings(keys)
+		So(keys, ShouldResemble, []string{"a", "c"})
+		entry, err := store.Get("b")
+		So(entry, ShouldBeNil)
+		So(err, ShouldBeNil)
+		entry, _ = store.Get("a")
+		So(string(entry.Body), ShouldEqual, "a")
+	})
+}
+
+func TestDiskStore(t *testing.T) {
+	Convey("A disk store should keep entries across instances", t, func() {
+		dir, _ := os.MkdirTemp("", "gowave-cache")
+		defer os.RemoveAll(dir)
+
+		store, err := NewDiskStore(dir)
+		So(err, ShouldBeNil)
+		expires := time.Date(2014, 1, 2, 3, 4, 5, 0, time.UTC)
+		err = store.Set("https://api.waveapps.com/currencies/", &CacheEntry{
+			StatusCode: 200,
+			Header:     http.Header{"Etag": {`"v1"`}},
+			Body:       []byte(`[]`),
+			Expires:    expires,
+		})
+		So(err, ShouldBeNil)
+
+		store, _ = NewDiskStore(dir)
+		entry, err := store.Get("https://api.waveapps.com/currencies/")
+		So(err, ShouldBeNil)
+		So(entry.StatusCode, ShouldEqual, 200)
+		So(entry.Header.Get("ETag"), ShouldEqual, `"v1"`)
+		So(string(entry.Body), ShouldEqual, `[]`)
+		So(entry.Expires.Equal(expires), ShouldBeTrue)
+		keys, _ := store.Keys()
+		So(keys, ShouldResemble, []string{"https://api.waveapps.com/currencies/"})
+
+		So(store.Delete("https://api.waveapps.com/currencies/"), ShouldBeNil)
+		So(store.Delete("https://api.waveapps.com/currencies/"), ShouldBeNil)
+		entry, err = store.Get("https://api.waveapps.com/currencies/")
+		So(entry, ShouldBeNil)
+		So(err, ShouldBeNil)
+		keys, _ = store.Keys()
+		So(keys, ShouldBeEmpty)
+	})
+
+	Convey("A disk store should list its keys from its index without reading the entries", t, func() {
+		dir, _ := os.MkdirTemp("", "gowave-cache")
+		defer os.RemoveAll(dir)
+
+		store, _ := NewDiskStore(dir)
+		for _, key := range []string{"a", "b", "c", "a"} {
+			So(store.Set(key, &CacheEntry{}), ShouldBeNil)
+		}
+		So(os.WriteFile(store.path("b"), []byte("not JSON"), 0600), ShouldBeNil)
+		keys, err := store.Keys()
+		So(err, ShouldBeNil)
+		So(keys, ShouldResemble, []string{"a", "b", "c"})
+
+		store.Delete("a")
+		store.Delete("c")
+		keys, _ = store.Keys()
+		So(keys, ShouldResemble, []string{"b"})
+		index, _ := os.ReadFile(filepath.Join(dir, diskIndex))
+		So(string(index), ShouldEqual, "b\n")
+
+		store, _ = NewDiskStore(dir)
+		So(store.Set("a", &CacheEntry{}), ShouldBeNil)
+		keys, _ = store.Keys()
+		So(keys, ShouldResemble, []string{"b", "a"})
+	})
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package cassette provides an http.RoundTripper which records requests to the
+Wave API, and their responses, to a fixture file on disk, and later replays
+them without a network connection or an access token.
+
+Record a cassette once against the real API, then commit it and replay it in
+tests:
+
+	mode := cassette.ModeReplay
+	if token != "" {
+		mode = cassette.ModeRecord
+	}
+	recorder, err := cassette.New("testdata/customers.json", mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Stop()
+	recorder.Transport = auth.StaticClient(ctx, token).Transport
+
+	client := wave.NewClient(recorder.Client())
+
+Requests are matched to recorded interactions on their method, path, query and
+body; the host is ignored, so a cassette recorded against the Wave API can be
+replayed against any base URL. Authorization headers, cookies and access
+tokens are redacted before anything is written to disk.
+*/
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode controls whether a Recorder makes real requests or replays recorded
+// ones.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette and never makes a real
+	// request. The cassette must exist.
+	ModeReplay Mode = iota
+
+	// ModeRecord makes real requests and records them, replacing the
+	// cassette when the Recorder is stopped.
+	ModeRecord
+
+	// ModeAuto replays the cassette if it exists, and records it otherwise.
+	ModeAuto
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModeAuto:
+		return "auto"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// secretHeaders are the headers whose values are redacted.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// secretParams are the query parameters, form fields and JSON keys whose
+// values are redacted.
+var secretParams = []string{"access_token", "refresh_token", "client_secret"}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the contents of a fixture file: the interactions in the order
+// they were recorded.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Load reads the cassette at path. The error satisfies os.IsNotExist if there
+// is no such file.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Cassette)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cassette: %v: %v", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path, creating any missing parent directories.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// NoMatchError is returned when a request being replayed matches no recorded
+// interaction.
+type NoMatchError struct {
+	Method string
+	URL    string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("cassette: no recorded interaction matches %v %v", e.Method, e.URL)
+}
+
+// Recorder is an http.RoundTripper which records or replays a cassette. It is
+// safe for concurrent use.
+type Recorder struct {
+	// Transport makes the real requests when recording. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed map[*Interaction]bool
+}
+
+// New returns a Recorder for the cassette at path. In ModeReplay, or in
+// ModeAuto when the cassette exists, the cassette is loaded, and the error
+// satisfies os.IsNotExist if there is none.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		cassette: new(Cassette),
+		replayed: make(map[*Interaction]bool),
+	}
+	if mode == ModeAuto {
+		_, err := os.Stat(path)
+		switch {
+		case err == nil:
+			r.mode = ModeReplay
+		case os.IsNotExist(err):
+			r.mode = ModeRecord
+		default:
+			return nil, err
+		}
+	}
+	if r.mode == ModeReplay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+	}
+	return r, nil
+}
+
+// Mode returns whether the Recorder is recording or replaying.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an http.Client which sends its requests through the
+// Recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop saves the cassette if the Recorder is recording.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := drain(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Header: redactHeader(req.Header),
+		Body:   redactBody(body, req.Header.Get("Content-Type")),
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = http.NoBody
+	if len(body) > 0 {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := drain(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+			Body:       redactBody(respBody, resp.Header.Get("Content-Type")),
+		},
+	})
+	return resp, nil
+}
+
+// replay answers req with the first matching interaction which has not been
+// replayed yet, so that repeating a request replays the responses in the
+// order they were recorded. Once all of them have been replayed, the last one
+// is replayed again.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var match *Interaction
+	for _, i := range r.cassette.Interactions {
+		if !i.Request.matches(recorded) {
+			continue
+		}
+		match = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if match == nil {
+		return nil, &NoMatchError{Method: req.Method, URL: recorded.URL}
+	}
+	r.replayed[match] = true
+
+	header := match.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %v", match.Response.StatusCode, http.StatusText(match.Response.StatusCode)),
+		StatusCode:    match.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(match.Response.Body)),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matches reports whether the requests have the same method, path, query and
+// body. JSON bodies match if they encode the same value.
+func (req Request) matches(other Request) bool {
+	if req.Method != other.Method {
+		return false
+	}
+	a, errA := url.Parse(req.URL)
+	b, errB := url.Parse(other.URL)
+	if errA != nil || errB != nil || a.Path != b.Path {
+		return false
+	}
+	if qa, qb := a.Query(), b.Query(); (len(qa) > 0 || len(qb) > 0) && !reflect.DeepEqual(qa, qb) {
+		return false
+	}
+	if req.Body == other.Body {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal([]byte(req.Body), &va) != nil || json.Unmarshal([]byte(other.Body), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// drain reads and closes body, which may be nil.
+func drain(body io.ReadCloser) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	h := header.Clone()
+	for _, key := range secretHeaders {
+		if _, ok := h[key]; ok {
+			h[key] = []string{Redacted}
+		}
+	}
+	return h
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	if redactValues(q) {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// redactValues redacts the secret parameters of v, reporting whether there
+// were any.
+func redactValues(v url.Values) bool {
+	redacted := false
+	for _, key := range secretParams {
+		if _, ok := v[key]; ok {
+			v[key] = []string{Redacted}
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// redactBody redacts the secrets in a form or JSON body.
+func redactBody(body []byte, contentType string) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if v, err := url.ParseQuery(string(body)); err == nil && redactValues(v) {
+			return v.Encode()
+		}
+		return string(body)
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if dec.Decode(&v) != nil || !redactJSON(v) {
+		return string(body)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// redactJSON redacts the secret keys of the objects in v, reporting whether
+// there were any.
+func redactJSON(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSecret(key) {
+				v[key] = Redacted
+				redacted = true
+			} else if redactJSON(value) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+func isSecret(key string) bool {
+	for _, secret := range secretParams {
+		if key == secret {
+			return true
+		}
+	}
+	return false
+}
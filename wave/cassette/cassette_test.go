@@ -0,0 +1,203 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	// mux is the HTTP request multiplexer used with the test server.
+	mux *http.ServeMux
+
+	// server is a test HTTP server standing in for the Wave API.
+	server *httptest.Server
+)
+
+func setUp() {
+	mux = http.NewServeMux()
+	server = httptest.NewServer(mux)
+}
+
+func tearDown() {
+	server.Close()
+}
+
+// send makes a request through c and returns the status code and body of the
+// response.
+func send(c *http.Client, method, url, body string) (int, string, error) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	Convey("A recorded cassette should replay without the server", t, func() {
+		setUp()
+		path := filepath.Join(t.TempDir(), "fixtures", "customers.json")
+
+		calls := 0
+		mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			b, _ := io.ReadAll(r.Body)
+			if r.Method == "POST" {
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"id": %d, "request": %s}`, calls, b)
+				return
+			}
+			w.Header().Set("X-Total-Count", "1")
+			fmt.Fprintf(w, `[{"id": %d, "page": "%v"}]`, calls, r.URL.Query().Get("page"))
+		})
+
+		recorder, err := New(path, ModeRecord)
+		So(err, ShouldBeNil)
+		So(recorder.Mode(), ShouldEqual, ModeRecord)
+		c := recorder.Client()
+
+		code, body, err := send(c, "GET", server.URL+"/businesses/1/customers/?page=1&page_size=2", "")
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		So(body, ShouldEqual, `[{"id": 1, "page": "1"}]`)
+		send(c, "GET", server.URL+"/businesses/1/customers/?page=1&page_size=2", "")
+		send(c, "POST", server.URL+"/businesses/1/customers/", `{"name": "Jane"}`)
+		So(recorder.Stop(), ShouldBeNil)
+		tearDown()
+
+		recorder, err = New(path, ModeReplay)
+		So(err, ShouldBeNil)
+		c = recorder.Client()
+
+		Convey("Repeated requests should replay in the order they were recorded", func() {
+			_, body, err := send(c, "GET", "http://elsewhere.example.com/businesses/1/customers/?page_size=2&page=1", "")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, `[{"id": 1, "page": "1"}]`)
+
+			_, body, _ = send(c, "GET", "http://elsewhere.example.com/businesses/1/customers/?page_size=2&page=1", "")
+			So(body, ShouldEqual, `[{"id": 2, "page": "1"}]`)
+
+			_, body, _ = send(c, "GET", "http://elsewhere.example.com/businesses/1/customers/?page_size=2&page=1", "")
+			So(body, ShouldEqual, `[{"id": 2, "page": "1"}]`)
+		})
+
+		Convey("The status code, headers and body should be replayed", func() {
+			req, _ := http.NewRequest("GET", server.URL+"/businesses/1/customers/?page=1&page_size=2", nil)
+			resp, err := c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(resp.Header.Get("X-Total-Count"), ShouldEqual, "1")
+		})
+
+		Convey("JSON bodies should match however they are formatted", func() {
+			code, body, err := send(c, "POST", server.URL+"/businesses/1/customers/", `{"name":"Jane"}`)
+			So(err, ShouldBeNil)
+			So(code, ShouldEqual, http.StatusCreated)
+			So(body, ShouldEqual, `{"id": 3, "request": {"name": "Jane"}}`)
+		})
+
+		Convey("Requests which were not recorded should fail", func() {
+			cases := []struct{ method, url, body string }{
+				{"DELETE", server.URL + "/businesses/1/customers/", ""},
+				{"GET", server.URL + "/businesses/2/customers/?page=1&page_size=2", ""},
+				{"GET", server.URL + "/businesses/1/customers/?page=2&page_size=2", ""},
+				{"POST", server.URL + "/businesses/1/customers/", `{"name": "John"}`},
+			}
+			for _, c := range cases {
+				_, _, err := send(recorder.Client(), c.method, c.url, c.body)
+				var nomatch *NoMatchError
+				So(errors.As(err, &nomatch), ShouldBeTrue)
+				So(nomatch.Method, ShouldEqual, c.method)
+			}
+		})
+	})
+
+	Convey("Replaying a missing cassette should fail", t, func() {
+		_, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+
+	Convey("ModeAuto should record a missing cassette and replay an existing one", t, func() {
+		setUp()
+		defer tearDown()
+		path := filepath.Join(t.TempDir(), "auto.json")
+		mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id": "1"}`)
+		})
+
+		recorder, err := New(path, ModeAuto)
+		So(err, ShouldBeNil)
+		So(recorder.Mode(), ShouldEqual, ModeRecord)
+		send(recorder.Client(), "GET", server.URL+"/user/", "")
+		So(recorder.Stop(), ShouldBeNil)
+
+		recorder, err = New(path, ModeAuto)
+		So(err, ShouldBeNil)
+		So(recorder.Mode(), ShouldEqual, ModeReplay)
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	Convey("Secrets should never be written to the cassette", t, func() {
+		setUp()
+		defer tearDown()
+		path := filepath.Join(t.TempDir(), "token.json")
+
+		mux.HandleFunc("/oauth2/token/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=secret-cookie")
+			fmt.Fprint(w, `{"access_token": "secret-access", "refresh_token": "secret-refresh", "token_type": "Bearer", "expires_in": 3600}`)
+		})
+		mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id": "1", "currency": {"code": "USD"}}`)
+		})
+
+		recorder, _ := New(path, ModeRecord)
+		c := recorder.Client()
+
+		req, _ := http.NewRequest("POST", server.URL+"/oauth2/token/", strings.NewReader("grant_type=refresh_token&refresh_token=secret-old&client_secret=secret-client"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := c.Do(req)
+		So(err, ShouldBeNil)
+		b, _ := io.ReadAll(resp.Body)
+		So(string(b), ShouldContainSubstring, "secret-access")
+
+		_, body, err := send(c, "GET", server.URL+"/user/?access_token=secret-query", "")
+		So(err, ShouldBeNil)
+		So(body, ShouldContainSubstring, "USD")
+		So(recorder.Stop(), ShouldBeNil)
+
+		data, _ := os.ReadFile(path)
+		So(string(data), ShouldNotContainSubstring, "secret-")
+		So(string(data), ShouldContainSubstring, Redacted)
+		So(string(data), ShouldContainSubstring, "refresh_token")
+		So(string(data), ShouldContainSubstring, "USD")
+
+		Convey("Requests with secrets should still match when replayed", func() {
+			recorder, err := New(path, ModeReplay)
+			So(err, ShouldBeNil)
+			_, body, err := send(recorder.Client(), "GET", server.URL+"/user/?access_token=another-token", "")
+			So(err, ShouldBeNil)
+			So(body, ShouldContainSubstring, "USD")
+		})
+	})
+}
@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // CountriesService handles communication with the country related methods of the Wave API.
 //
@@ -19,10 +22,14 @@ type CountriesService struct {
 type Province struct {
 	Name *string `json:"name"`
 	Slug *string `json:"slug"`
+
+	// Code is the ISO 3166-2 code of the province, such as "CA-ON", from the
+	// reference data. The API does not know it, so it is never sent.
+	Code *string `json:"-"`
 }
 
 func (p Province) String() string {
-	return *p.Name
+	return deref(p.Name)
 }
 
 // Country represents a country in ISO 3166-1 alpha-2 format (http://en.wikipedia.org/wiki/ISO_3166-1_alpha-2).
@@ -35,14 +42,26 @@ type Country struct {
 }
 
 func (c Country) String() string {
-	return fmt.Sprintf("%v (%v)", *c.Name, *c.CountryCode)
+	return fmt.Sprintf("%v (%v)", deref(c.Name), deref(c.CountryCode))
+}
+
+// Lookup returns the country with the given ISO 3166-1 alpha-2 code from the
+// client's reference data, without asking the API, or nil if there is none.
+func (service *CountriesService) Lookup(code string) *Country {
+	return service.client.reference().Country(code)
 }
 
 // List all countries available in Wave.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/geography.html#get--countries-
 func (service *CountriesService) List() ([]Country, *Response, error) {
-	req, err := service.client.NewRequest("GET", "countries", nil)
+	return service.ListContext(context.Background())
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *CountriesService) ListContext(ctx context.Context) ([]Country, *Response, error) {
+	ctx = withOperation(ctx, "Countries.List")
+	req, err := service.client.NewRequestContext(ctx, "GET", "countries", nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -58,8 +77,14 @@ func (service *CountriesService) List() ([]Country, *Response, error) {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/geography.html#get--countries-(country_code)-
 func (service *CountriesService) Get(code string) (*Country, *Response, error) {
+	return service.GetContext(context.Background(), code)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *CountriesService) GetContext(ctx context.Context, code string) (*Country, *Response, error) {
+	ctx = withOperation(ctx, "Countries.Get")
 	u := fmt.Sprintf("countries/%v", code)
-	req, err := service.client.NewRequest("GET", u, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // CurrenciesService handles communication with the currency related methods of the Wave API.
 //
@@ -21,17 +24,35 @@ type Currency struct {
 	Code   *string `json:"code,omitempty"`
 	Symbol *string `json:"symbol,omitempty"`
 	Name   *string `json:"name,omitempty"`
+
+	// MinorUnits is the number of digits after the decimal point in amounts
+	// of the currency, from the reference data. It is nil for currencies
+	// without minor units, such as gold. The API does not know it, so it is
+	// never sent.
+	MinorUnits *int `json:"-"`
 }
 
 func (c Currency) String() string {
-	return fmt.Sprintf("%v (%v)", *c.Code, *c.Name)
+	return fmt.Sprintf("%v (%v)", deref(c.Code), deref(c.Name))
+}
+
+// Lookup returns the currency with the given ISO 4217 code from the client's
+// reference data, without asking the API, or nil if there is none.
+func (service *CurrenciesService) Lookup(code string) *Currency {
+	return service.client.reference().Currency(code)
 }
 
 // List all currencies available in Wave.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/currencies.html#get--currencies-
 func (service *CurrenciesService) List() ([]Currency, *Response, error) {
-	req, err := service.client.NewRequest("GET", "currencies", nil)
+	return service.ListContext(context.Background())
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *CurrenciesService) ListContext(ctx context.Context) ([]Currency, *Response, error) {
+	ctx = withOperation(ctx, "Currencies.List")
+	req, err := service.client.NewRequestContext(ctx, "GET", "currencies", nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -47,8 +68,14 @@ func (service *CurrenciesService) List() ([]Currency, *Response, error) {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/currencies.html#get--currencies-(code)-
 func (service *CurrenciesService) Get(code string) (*Currency, *Response, error) {
+	return service.GetContext(context.Background(), code)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *CurrenciesService) GetContext(ctx context.Context, code string) (*Currency, *Response, error) {
+	ctx = withOperation(ctx, "Currencies.Get")
 	u := fmt.Sprintf("currencies/%v", code)
-	req, err := service.client.NewRequest("GET", u, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, nil, err
 	}
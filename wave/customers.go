@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // CustomersService handles communication with the customer related methods of the Wave API.
 //
@@ -15,11 +18,11 @@ type CustomersService struct {
 	client *Client
 }
 
-// ShippingDetails represents details for shipping for a given Customer.
+// ShippingDetails represents details for shipping for a given Customer or Vendor.
 type ShippingDetails struct {
 	ShipToContact        *string  `json:"ship_to_contact,omitempty"`
 	DeliveryInstructions *string  `json:"delivery_instructions,omitempty"`
-	PhoneNumber          *string  `json:"phone_number,omitempty"`
+	PhoneNumber          *string  `json:"phone_number,omitempty" wave:"sensitive"`
 	Address              *Address `json:"address,omitempty"`
 }
 
@@ -31,11 +34,11 @@ type Customer struct {
 	Name            *string          `json:"name,omitempty"`
 	FirstName       *string          `json:"first_name,omitempty"`
 	LastName        *string          `json:"last_name,omitempty"`
-	Email           *string          `json:"email,omitempty"`
-	FaxNumber       *string          `json:"fax_number,omitempty"`
-	MobileNumber    *string          `json:"mobile_number,omitempty"`
-	PhoneNumber     *string          `json:"phone_number,omitempty"`
-	TollFreeNumber  *string          `json:"toll_free_number,omitempty"`
+	Email           *string          `json:"email,omitempty" wave:"sensitive"`
+	FaxNumber       *string          `json:"fax_number,omitempty" wave:"sensitive"`
+	MobileNumber    *string          `json:"mobile_number,omitempty" wave:"sensitive"`
+	PhoneNumber     *string          `json:"phone_number,omitempty" wave:"sensitive"`
+	TollFreeNumber  *string          `json:"toll_free_number,omitempty" wave:"sensitive"`
 	Website         *string          `json:"website,omitempty"`
 	Currency        *Currency        `json:"currency,omitempty"`
 	ShippingDetails *ShippingDetails `json:"shipping_details,omitempty"`
@@ -86,12 +89,18 @@ type CustomerListOptions struct {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/customers.html#get--businesses-{business_id}-customers-
 func (service *CustomersService) List(businessID string, opts *CustomerListOptions) ([]Customer, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *CustomersService) ListContext(ctx context.Context, businessID string, opts *CustomerListOptions) ([]Customer, *Response, error) {
+	ctx = withOperation(ctx, "Customers.List")
 	url := fmt.Sprintf("businesses/%v/customers/", businessID)
 	url, err := addOptions(url, opts)
 	if err != nil {
 		return nil, nil, err
 	}
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -103,12 +112,31 @@ func (service *CustomersService) List(businessID string, opts *CustomerListOptio
 	return *customers, resp, nil
 }
 
+// ListIter returns an Iterator over every customer for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *CustomersService) ListIter(businessID string, opts *CustomerListOptions) *Iterator[Customer] {
+	o := new(CustomerListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Customer, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
 // Get an existing customer for a given business.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/customers.html#get--businesses-{business_id}-customers-{customer_id}-
 func (service *CustomersService) Get(businessID string, customerID uint64) (*Customer, *Response, error) {
+	return service.GetContext(context.Background(), businessID, customerID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *CustomersService) GetContext(ctx context.Context, businessID string, customerID uint64) (*Customer, *Response, error) {
+	ctx = withOperation(ctx, "Customers.Get")
 	url := fmt.Sprintf("businesses/%v/customers/%v/", businessID, customerID)
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -124,8 +152,14 @@ func (service *CustomersService) Get(businessID string, customerID uint64) (*Cus
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/customers.html#post--businesses-{business_id}-customers-
 func (service *CustomersService) Create(businessID string, customer *Customer) (*Customer, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, customer)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *CustomersService) CreateContext(ctx context.Context, businessID string, customer *Customer) (*Customer, *Response, error) {
+	ctx = withOperation(ctx, "Customers.Create")
 	url := fmt.Sprintf("businesses/%v/customers/", businessID)
-	req, err := service.client.NewRequest("POST", url, customer)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, customer)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -141,8 +175,14 @@ func (service *CustomersService) Create(businessID string, customer *Customer) (
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/customers.html#put--businesses-{business_id}-customers-{customer_id}-
 func (service *CustomersService) Replace(businessID string, customerID uint64, customer *Customer) (*Customer, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, customerID, customer)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *CustomersService) ReplaceContext(ctx context.Context, businessID string, customerID uint64, customer *Customer) (*Customer, *Response, error) {
+	ctx = withOperation(ctx, "Customers.Replace")
 	url := fmt.Sprintf("businesses/%v/customers/%v/", businessID, customerID)
-	req, err := service.client.NewRequest("PUT", url, customer)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, customer)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -158,8 +198,14 @@ func (service *CustomersService) Replace(businessID string, customerID uint64, c
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/customers.html#patch--businesses-{business_id}-customers-{customer_id}-
 func (service *CustomersService) Update(businessID string, customerID uint64, customer *Customer) (*Customer, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, customerID, customer)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *CustomersService) UpdateContext(ctx context.Context, businessID string, customerID uint64, customer *Customer) (*Customer, *Response, error) {
+	ctx = withOperation(ctx, "Customers.Update")
 	url := fmt.Sprintf("businesses/%v/customers/%v/", businessID, customerID)
-	req, err := service.client.NewRequest("PATCH", url, customer)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, customer)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -175,8 +221,14 @@ func (service *CustomersService) Update(businessID string, customerID uint64, cu
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#delete--businesses-{business_id}-accounts-{account_id}-
 func (service *CustomersService) Delete(businessID string, customerID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, customerID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *CustomersService) DeleteContext(ctx context.Context, businessID string, customerID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Customers.Delete")
 	url := fmt.Sprintf("businesses/%v/customers/%v/", businessID, customerID)
-	req, err := service.client.NewRequest("DELETE", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -198,6 +198,11 @@ func TestCustomersService(t *testing.T) {
 		checkInvalidURLError(nil, resp, err)
 	})
 
+	Convey("GET a specific Customer with a cancelled context", t, func() {
+		customer, resp, err := client.Customers.GetContext(canceledContext(), "1", 1)
+		checkCanceledError(customer, resp, err)
+	})
+
 	Convey("FullName method on Customer", t, func() {
 		Convey("No FirstName and LastName should return ''", func() {
 			c := new(Customer)
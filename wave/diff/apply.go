@@ -0,0 +1,185 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// ApplyOptions specifies how differences are applied.
+type ApplyOptions struct {
+	// Delete deletes the records which are only in the target business.
+	// They are left as they are otherwise.
+	Delete bool
+}
+
+// errSystemAccount is the error of an added or removed system account, which
+// cannot be created or deleted.
+var errSystemAccount = errors.New("diff: system accounts cannot be created or deleted")
+
+// Apply makes the target business match the source: added records are
+// created in it, changed records are replaced with their source, and removed
+// records are deleted if opts.Delete is set. Products are given the accounts
+// of the target business with the keys of their accounts in the source.
+//
+// Apply carries on past differences which cannot be applied, setting their
+// Err, and returns an error if any could not be. It stops early if ctx is
+// done. Apply once per Diff; compare the businesses again afterwards to check
+// the result.
+func (d *Diff) Apply(ctx context.Context, client *wave.Client, opts *ApplyOptions) error {
+	if opts == nil {
+		opts = new(ApplyOptions)
+	}
+	a := &applier{client: client, businessID: d.TargetBusinessID, accounts: make(map[string]int)}
+	for _, account := range d.target.Accounts {
+		field, key := accountKey(account)
+		match := strings.ToLower(field + "=" + key)
+		if _, ok := a.accounts[match]; !ok && account.ID != nil {
+			a.accounts[match] = *account.ID
+		}
+	}
+
+	// Removed records are deleted last, and products before the accounts
+	// they may refer to.
+	var removed []*Difference
+	for _, difference := range d.Differences {
+		if difference.Kind == Removed {
+			if opts.Delete {
+				removed = append([]*Difference{difference}, removed...)
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		difference.Err = a.apply(ctx, difference)
+		difference.Applied = difference.Err == nil
+	}
+	for _, difference := range removed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		difference.Err = a.remove(ctx, difference)
+		difference.Applied = difference.Err == nil
+	}
+
+	failed := 0
+	for _, difference := range d.Differences {
+		if difference.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("diff: %d of %d differences could not be applied", failed, len(d.Differences))
+	}
+	return nil
+}
+
+// applier applies differences to the target business.
+type applier struct {
+	client     *wave.Client
+	businessID string
+	accounts   map[string]int // the IDs of the accounts of the target, by key
+}
+
+// apply creates or replaces the target record of an added or changed
+// difference.
+func (a *applier) apply(ctx context.Context, difference *Difference) error {
+	id, _ := strconv.ParseUint(difference.TargetID, 10, 64)
+	switch v := difference.source.value.(type) {
+	case wave.Account:
+		if v.CanDelete != nil && !*v.CanDelete && difference.Kind == Added {
+			return errSystemAccount
+		}
+		v.ID, v.URL, v.DateCreated, v.DateModified = nil, nil, nil, nil
+		v.CanDelete, v.IsCurrencyEditable, v.IsNameEditable, v.IsPaymentEditable = nil, nil, nil, nil
+		var account *wave.Account
+		var err error
+		if difference.Kind == Added {
+			account, _, err = a.client.Accounts.CreateContext(ctx, a.businessID, &v)
+		} else {
+			account, _, err = a.client.Accounts.ReplaceContext(ctx, a.businessID, id, &v)
+		}
+		if err != nil {
+			return err
+		}
+		if account.ID != nil {
+			a.accounts[difference.source.match] = *account.ID
+			difference.TargetID = intID(account.ID)
+		}
+		return nil
+
+	case wave.Customer:
+		v.ID, v.URL, v.DateCreated, v.DateModified = 0, nil, nil, nil
+		if difference.Kind == Added {
+			customer, _, err := a.client.Customers.CreateContext(ctx, a.businessID, &v)
+			if err == nil {
+				difference.TargetID = fmt.Sprint(customer.ID)
+			}
+			return err
+		}
+		_, _, err := a.client.Customers.ReplaceContext(ctx, a.businessID, id, &v)
+		return err
+
+	case wave.Product:
+		v.ID, v.URL, v.DateCreated, v.DateModified = nil, nil, nil, nil
+		var err error
+		if v.IncomeAccount, err = a.account(difference.source.refs["income_account"]); err != nil {
+			return err
+		}
+		if v.ExpenseAccount, err = a.account(difference.source.refs["expense_account"]); err != nil {
+			return err
+		}
+		if difference.Kind == Added {
+			product, _, err := a.client.Products.CreateContext(ctx, a.businessID, &v)
+			if err == nil {
+				difference.TargetID = intID(product.ID)
+			}
+			return err
+		}
+		_, _, err = a.client.Products.ReplaceContext(ctx, a.businessID, id, &v)
+		return err
+	}
+	return fmt.Errorf("diff: cannot apply %v", difference.Resource)
+}
+
+// account returns a reference to the account of the target business with the
+// given key, or nil if the key is empty.
+func (a *applier) account(key string) (*wave.Account, error) {
+	if key == "" {
+		return nil, nil
+	}
+	id, ok := a.accounts[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("diff: the target business has no account with %v", key)
+	}
+	return &wave.Account{ID: &id}, nil
+}
+
+// remove deletes the target record of a removed difference.
+func (a *applier) remove(ctx context.Context, difference *Difference) error {
+	id, _ := strconv.ParseUint(difference.TargetID, 10, 64)
+	var err error
+	switch v := difference.target.value.(type) {
+	case wave.Account:
+		if v.CanDelete != nil && !*v.CanDelete {
+			return errSystemAccount
+		}
+		_, err = a.client.Accounts.DeleteContext(ctx, a.businessID, id)
+	case wave.Customer:
+		_, err = a.client.Customers.DeleteContext(ctx, a.businessID, id)
+	case wave.Product:
+		_, err = a.client.Products.DeleteContext(ctx, a.businessID, id)
+	}
+	return err
+}
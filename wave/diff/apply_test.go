@@ -0,0 +1,91 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestApply(t *testing.T) {
+	Convey("Applying a diff", t, func() {
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		staging, production := seed(srv)
+		ctx := context.Background()
+		client := srv.Client()
+
+		d, err := Compare(ctx, client, staging, production)
+		So(err, ShouldBeNil)
+
+		Convey("should make the target match the source", func() {
+			So(d.Apply(ctx, client, &ApplyOptions{Delete: true}), ShouldBeNil)
+			for _, difference := range d.Differences {
+				So(difference.Applied, ShouldBeTrue)
+			}
+
+			after, err := Compare(ctx, client, staging, production)
+			So(err, ShouldBeNil)
+			So(after.Differences, ShouldBeEmpty)
+
+			products, _, err := client.Products.List(production, &wave.ProductListOptions{EmbedAccounts: true})
+			So(err, ShouldBeNil)
+			So(products, ShouldHaveLength, 2)
+			So(*products[1].ExpenseAccount.Name, ShouldEqual, "Rent")
+			So(d.Differences[6].TargetID, ShouldNotBeEmpty)
+		})
+
+		Convey("should keep removed records unless asked to delete them", func() {
+			So(d.Apply(ctx, client, nil), ShouldBeNil)
+			after, err := Compare(ctx, client, staging, production)
+			So(err, ShouldBeNil)
+			So(after.String(), ShouldEqual, "0 added, 2 removed, 0 changed")
+		})
+
+		Convey("should carry on past differences which fail", func() {
+			srv.Fail(wavetest.Failure{Method: "POST", Path: "/businesses/*/accounts/", Status: http.StatusBadRequest})
+			err := d.Apply(ctx, client, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "diff: 2 of 7 differences could not be applied")
+			So(errors.Is(d.Differences[1].Err, wave.ErrValidation), ShouldBeTrue)
+			So(d.Differences[6].Err.Error(), ShouldEqual, "diff: the target business has no account with name=Rent")
+			So(d.Differences[3].Applied, ShouldBeTrue)
+			So(d.Differences[2].Applied, ShouldBeFalse)
+		})
+
+		Convey("should not create or delete system accounts", func() {
+			_, _, err := client.Accounts.Create(staging, &wave.Account{Name: wave.String("Cash"), AccountType: wave.String("asset")})
+			So(err, ShouldBeNil)
+			srv.AddAccount(production, &wave.Account{Name: wave.String("Payroll"), AccountType: wave.String("liability"), CanDelete: wave.Bool(false)})
+			srv.AddAccount(staging, &wave.Account{Name: wave.String("Tax"), AccountType: wave.String("liability"), CanDelete: wave.Bool(false)})
+			d, err := Compare(ctx, client, staging, production)
+			So(err, ShouldBeNil)
+			err = d.Apply(ctx, client, &ApplyOptions{Delete: true})
+			So(err, ShouldNotBeNil)
+			var failed []string
+			for _, difference := range d.Differences {
+				if difference.Err != nil {
+					So(difference.Err, ShouldEqual, errSystemAccount)
+					failed = append(failed, difference.Key)
+				}
+			}
+			So(failed, ShouldResemble, []string{"name=Tax", "name=Payroll"})
+		})
+
+		Convey("should stop when the context is done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			So(d.Apply(ctx, client, nil), ShouldEqual, context.Canceled)
+		})
+	})
+}
@@ -0,0 +1,393 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package diff compares the accounts, customers and products of two Wave
+businesses, such as a staging and a production business, and applies the
+differences from one to the other.
+
+Records are matched by a natural key rather than by ID, which differs between
+businesses: accounts by their account number, or their name if they have none,
+customers by their email, or their name if they have none, and products by
+their name. Keys are compared ignoring case, and records with the same key in
+one business are matched in the order they are listed.
+
+	d, err := diff.Compare(ctx, client, stagingID, productionID)
+	if err != nil {
+		// A request failed
+	}
+	for _, difference := range d.Differences {
+		fmt.Println(difference.Kind, difference.Resource, difference.Key, difference.Changes)
+	}
+
+Apply makes the target business match the source:
+
+	err = d.Apply(ctx, client, &diff.ApplyOptions{Delete: true})
+*/
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/backup"
+)
+
+// Kind is the kind of a difference.
+type Kind string
+
+const (
+	// Added is a record which is only in the source business.
+	Added Kind = "added"
+
+	// Removed is a record which is only in the target business.
+	Removed Kind = "removed"
+
+	// Changed is a record in both businesses whose fields differ.
+	Changed Kind = "changed"
+)
+
+// Change is a field which differs between the source and target record.
+type Change struct {
+	Field  string `json:"field"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// String returns the change as applying it would make it, from the target
+// value to the source value.
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %q -> %q", c.Field, c.Target, c.Source)
+}
+
+// Difference is a record which differs between the two businesses.
+type Difference struct {
+	Resource string   `json:"resource"` // "accounts", "customers" or "products"
+	Key      string   `json:"key"`      // such as "email=jane@example.com"
+	Kind     Kind     `json:"kind"`
+	SourceID string   `json:"source_id,omitempty"`
+	TargetID string   `json:"target_id,omitempty"`
+	Changes  []Change `json:"changes,omitempty"`
+
+	// Applied is set by Apply once the difference is applied, and Err if it
+	// could not be.
+	Applied bool  `json:"-"`
+	Err     error `json:"-"`
+
+	source, target *record
+}
+
+// Diff is the differences between a source and a target business.
+type Diff struct {
+	SourceBusinessID string
+	TargetBusinessID string
+
+	// Differences lists the accounts, then the customers, then the products
+	// which differ. Records of the source come in its order, followed by the
+	// records removed from the target.
+	Differences []*Difference
+
+	target *backup.Snapshot
+}
+
+// Count returns the number of differences of the given kind.
+func (d *Diff) Count(kind Kind) int {
+	n := 0
+	for _, difference := range d.Differences {
+		if difference.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *Diff) String() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed", d.Count(Added), d.Count(Removed), d.Count(Changed))
+}
+
+// Compare fetches the businesses with the given IDs and compares them.
+func Compare(ctx context.Context, client *wave.Client, sourceID, targetID string) (*Diff, error) {
+	source, err := backup.Fetch(ctx, client, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := backup.Fetch(ctx, client, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return Snapshots(source, target), nil
+}
+
+// Snapshots compares two snapshots of businesses, such as ones read from
+// backup archives.
+func Snapshots(source, target *backup.Snapshot) *Diff {
+	d := &Diff{target: target}
+	if source.Business != nil && source.Business.ID != nil {
+		d.SourceBusinessID = *source.Business.ID
+	}
+	if target.Business != nil && target.Business.ID != nil {
+		d.TargetBusinessID = *target.Business.ID
+	}
+	for _, r := range resources {
+		d.Differences = append(d.Differences, compare(r.records(source), r.records(target))...)
+	}
+	return d
+}
+
+// compare matches the source and target records of a type by key.
+func compare(source, target []*record) []*Difference {
+	unmatched := make(map[string][]*record)
+	for _, t := range target {
+		unmatched[t.match] = append(unmatched[t.match], t)
+	}
+	var differences []*Difference
+	for _, s := range source {
+		candidates := unmatched[s.match]
+		if len(candidates) == 0 {
+			differences = append(differences, &Difference{Resource: s.resource, Key: s.key, Kind: Added, SourceID: s.id, source: s})
+			continue
+		}
+		t := candidates[0]
+		unmatched[s.match] = candidates[1:]
+		if changes := changes(s.fields, t.fields); len(changes) > 0 {
+			differences = append(differences, &Difference{
+				Resource: s.resource,
+				Key:      s.key,
+				Kind:     Changed,
+				SourceID: s.id,
+				TargetID: t.id,
+				Changes:  changes,
+				source:   s,
+				target:   t,
+			})
+		}
+	}
+	for _, t := range target {
+		for _, u := range unmatched[t.match] {
+			if u == t {
+				differences = append(differences, &Difference{Resource: t.resource, Key: t.key, Kind: Removed, TargetID: t.id, target: t})
+			}
+		}
+	}
+	return differences
+}
+
+// changes returns the fields which differ, sorted by name.
+func changes(source, target map[string]interface{}) []Change {
+	names := make(map[string]bool)
+	for name := range source {
+		names[name] = true
+	}
+	for name := range target {
+		names[name] = true
+	}
+	var changes []Change
+	for name := range names {
+		s, t := source[name], target[name]
+		if !equal(s, t) {
+			changes = append(changes, Change{Field: name, Source: format(s), Target: format(t)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// equal reports whether two field values are the same. Numbers are compared
+// by value, so that an amount of 10 equals 10.00.
+func equal(a, b interface{}) bool {
+	if x, ok := a.(json.Number); ok {
+		if y, ok := b.(json.Number); ok {
+			xr, xok := new(big.Rat).SetString(string(x))
+			yr, yok := new(big.Rat).SetString(string(y))
+			if xok && yok {
+				return xr.Cmp(yr) == 0
+			}
+		}
+	}
+	return format(a) == format(b)
+}
+
+// format returns a field value as a string; lists are written as JSON.
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return string(v)
+	case bool:
+		return fmt.Sprint(v)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// serverFields are set by the API and differ between businesses, so they are
+// not compared.
+var serverFields = map[string]bool{
+	"id":                   true,
+	"url":                  true,
+	"date_created":         true,
+	"date_modified":        true,
+	"can_delete":           true,
+	"is_currency_editable": true,
+	"is_name_editable":     true,
+	"is_payment_editable":  true,
+}
+
+// record is a record of one of the businesses, with the fields to compare.
+type record struct {
+	resource string
+	key      string // the natural key, as it is shown
+	match    string // the natural key, as it is matched
+	id       string
+	fields   map[string]interface{}
+	refs     map[string]string // the keys of the accounts a product refers to
+	value    interface{}       // the wave.Account, wave.Customer or wave.Product
+}
+
+// newRecord returns the record of v, flattening its fields into dotted names
+// such as "currency.code" and replacing the fields in refs with the value
+// given for them.
+func newRecord(resource, keyField, key, id string, v interface{}, refs map[string]string) *record {
+	r := &record{
+		resource: resource,
+		key:      keyField + "=" + key,
+		match:    keyField + "=" + strings.ToLower(key),
+		id:       id,
+		fields:   make(map[string]interface{}),
+		refs:     refs,
+		value:    v,
+	}
+	data, _ := json.Marshal(v)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	dec.Decode(&fields)
+	for name, value := range fields {
+		if serverFields[name] {
+			continue
+		}
+		if ref, ok := refs[name]; ok {
+			value = ref
+		}
+		flatten(name, value, r.fields)
+	}
+	return r
+}
+
+func flatten(name string, v interface{}, fields map[string]interface{}) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		fields[name] = v
+		return
+	}
+	for inner, value := range object {
+		flatten(name+"."+inner, value, fields)
+	}
+}
+
+// resource is a type of record compared between businesses.
+type resource struct {
+	name    string
+	records func(s *backup.Snapshot) []*record
+}
+
+// resources are the types of records compared, in the order they are
+// applied: products refer to accounts, so accounts come first.
+var resources = []resource{
+	{"accounts", func(s *backup.Snapshot) []*record {
+		records := make([]*record, len(s.Accounts))
+		for i, a := range s.Accounts {
+			field, key := accountKey(a)
+			records[i] = newRecord("accounts", field, key, intID(a.ID), a, nil)
+		}
+		return records
+	}},
+	{"customers", func(s *backup.Snapshot) []*record {
+		records := make([]*record, len(s.Customers))
+		for i, c := range s.Customers {
+			field, key := "name", c.String()
+			if c.Email != nil && *c.Email != "" {
+				field, key = "email", *c.Email
+			}
+			records[i] = newRecord("customers", field, key, fmt.Sprint(c.ID), c, nil)
+		}
+		return records
+	}},
+	{"products", func(s *backup.Snapshot) []*record {
+		records := make([]*record, len(s.Products))
+		for i, p := range s.Products {
+			refs := make(map[string]string)
+			if p.IncomeAccount != nil {
+				refs["income_account"] = accountRef(s, p.IncomeAccount)
+			}
+			if p.ExpenseAccount != nil {
+				refs["expense_account"] = accountRef(s, p.ExpenseAccount)
+			}
+			records[i] = newRecord("products", "name", deref(p.Name), intID(p.ID), p, refs)
+		}
+		return records
+	}},
+}
+
+// accountKey returns the natural key of an account: its account number, or
+// its name if it has none.
+func accountKey(a wave.Account) (string, string) {
+	if a.AccountNumber != nil {
+		return "account_number", fmt.Sprint(*a.AccountNumber)
+	}
+	return "name", deref(a.Name)
+}
+
+// accountRef returns the key of the account a product refers to, looking it
+// up in the snapshot in case it is not embedded in the product.
+func accountRef(s *backup.Snapshot, ref *wave.Account) string {
+	account := findAccount(s, ref.ID)
+	if account == nil {
+		if ref.Name == nil && ref.AccountNumber == nil {
+			return "id=" + intID(ref.ID)
+		}
+		account = ref
+	}
+	field, key := accountKey(*account)
+	return field + "=" + key
+}
+
+// findAccount returns the account of the snapshot with the given ID, or nil
+// if there is none.
+func findAccount(s *backup.Snapshot, id *int) *wave.Account {
+	if id == nil {
+		return nil
+	}
+	for i, a := range s.Accounts {
+		if a.ID != nil && *a.ID == *id {
+			return &s.Accounts[i]
+		}
+	}
+	return nil
+}
+
+func intID[T int | uint64](id *T) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprint(*id)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/backup"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// seed adds a staging and a production business to srv which differ in a
+// record of every kind, and returns their IDs.
+func seed(srv *wavetest.Server) (string, string) {
+	staging := *srv.AddBusiness(&wave.Business{CompanyName: wave.String("Staging"), PrimaryCurrencyCode: wave.String("USD")}).ID
+	production := *srv.AddBusiness(&wave.Business{CompanyName: wave.String("Production"), PrimaryCurrencyCode: wave.String("USD")}).ID
+
+	for _, bID := range []string{staging, production} {
+		srv.AddAccount(bID, &wave.Account{Name: wave.String("Accounts Receivable"), AccountType: wave.String("asset"), CanDelete: wave.Bool(false)})
+	}
+	sales := srv.AddAccount(staging, &wave.Account{Name: wave.String("Sales"), AccountType: wave.String("income"), AccountNumber: wave.Int(4000)})
+	rent := srv.AddAccount(staging, &wave.Account{Name: wave.String("Rent"), AccountType: wave.String("expense")})
+	revenue := srv.AddAccount(production, &wave.Account{Name: wave.String("Revenue"), AccountType: wave.String("income"), AccountNumber: wave.Int(4000)})
+	srv.AddAccount(production, &wave.Account{Name: wave.String("Old"), AccountType: wave.String("expense")})
+
+	srv.AddCustomer(staging, &wave.Customer{Name: wave.String("Jane Doe"), Email: wave.String("jane@example.com"), Address: &wave.Address{City: wave.String("Ottawa")}})
+	srv.AddCustomer(staging, &wave.Customer{Name: wave.String("Bob")})
+	srv.AddCustomer(production, &wave.Customer{Name: wave.String("Jane Doe"), Email: wave.String("JANE@example.com"), Address: &wave.Address{City: wave.String("Toronto")}})
+	srv.AddCustomer(production, &wave.Customer{Name: wave.String("Bob")})
+	srv.AddCustomer(production, &wave.Customer{Name: wave.String("Carl"), Email: wave.String("carl@example.com")})
+
+	srv.AddProduct(staging, &wave.Product{Name: wave.String("Widget"), Price: wave.Amount("10"), IncomeAccount: &wave.Account{ID: sales.ID}})
+	srv.AddProduct(staging, &wave.Product{Name: wave.String("Gadget"), Price: wave.Amount("5"), ExpenseAccount: &wave.Account{ID: rent.ID}})
+	srv.AddProduct(production, &wave.Product{Name: wave.String("widget"), Price: wave.Amount("10.00"), IncomeAccount: &wave.Account{ID: revenue.ID}})
+	return staging, production
+}
+
+func TestCompare(t *testing.T) {
+	Convey("Comparing two businesses", t, func() {
+		srv := wavetest.NewServer()
+		defer srv.Close()
+		staging, production := seed(srv)
+		ctx := context.Background()
+
+		d, err := Compare(ctx, srv.Client(), staging, production)
+		So(err, ShouldBeNil)
+		So(d.SourceBusinessID, ShouldEqual, staging)
+		So(d.TargetBusinessID, ShouldEqual, production)
+
+		Convey("should list the differences of every kind", func() {
+			var got []string
+			for _, difference := range d.Differences {
+				got = append(got, fmt.Sprintf("%v %v %v", difference.Kind, difference.Resource, difference.Key))
+			}
+			So(got, ShouldResemble, []string{
+				"changed accounts account_number=4000",
+				"added accounts name=Rent",
+				"removed accounts name=Old",
+				"changed customers email=jane@example.com",
+				"removed customers email=carl@example.com",
+				"changed products name=Widget",
+				"added products name=Gadget",
+			})
+			So(d.String(), ShouldEqual, "2 added, 2 removed, 3 changed")
+		})
+
+		Convey("should match records by key, ignoring case", func() {
+			jane := d.Differences[3]
+			So(jane.SourceID, ShouldNotBeEmpty)
+			So(jane.TargetID, ShouldNotBeEmpty)
+			So(jane.Changes, ShouldResemble, []Change{
+				{Field: "city", Source: "Ottawa", Target: "Toronto"},
+				{Field: "email", Source: "jane@example.com", Target: "JANE@example.com"},
+			})
+			So(jane.Changes[0].String(), ShouldEqual, `city: "Toronto" -> "Ottawa"`)
+		})
+
+		Convey("should compare the accounts of products by key and amounts by value", func() {
+			So(d.Differences[5].Changes, ShouldResemble, []Change{{Field: "name", Source: "Widget", Target: "widget"}})
+			So(d.Differences[0].Changes, ShouldResemble, []Change{{Field: "name", Source: "Sales", Target: "Revenue"}})
+		})
+
+		Convey("should find no differences between a business and itself", func() {
+			d, err := Compare(ctx, srv.Client(), staging, staging)
+			So(err, ShouldBeNil)
+			So(d.Differences, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Records with the same key should be matched in order", t, func() {
+		source := &backup.Snapshot{Customers: []wave.Customer{
+			{ID: 1, Name: wave.String("Bob"), PhoneNumber: wave.String("1")},
+			{ID: 2, Name: wave.String("Bob"), PhoneNumber: wave.String("2")},
+		}}
+		target := &backup.Snapshot{Customers: []wave.Customer{
+			{ID: 3, Name: wave.String("Bob"), PhoneNumber: wave.String("1")},
+		}}
+		d := Snapshots(source, target)
+		So(d.Differences, ShouldHaveLength, 1)
+		So(d.Differences[0].Kind, ShouldEqual, Added)
+		So(d.Differences[0].SourceID, ShouldEqual, "2")
+	})
+}
@@ -14,7 +14,7 @@ http://docs.waveapps.com/
 
 Installation
 
-wave requires Go version 1.2 or greater.
+wave requires Go version 1.18 or greater.
 To download, build and install wave, run:
 
 	go get github.com/NickPresta/gowave/wave
@@ -28,31 +28,77 @@ Import wave into your source:
 	)
 
 You will need to create a new Wave client, which will allow you to make requests
-on behalf of a user. The wave package does not handle authentication and
+on behalf of a user. The wave package itself does not handle authentication and
 requires you to provide an http.Client that can handle appropriate
-authentication.
+authentication. The wave/auth package provides one.
 
-	t := &oauth.Transport{
-	  Token: &oauth.Token{AccessToken: "... your access token ..."},
-	}
+If you already have an access token, such as one generated from the Wave
+developer portal:
+
+	client := wave.NewClient(auth.StaticClient(ctx, "... your access token ..."))
 
-	client := wave.NewClient(t.Client())
+Otherwise, auth.Config runs the OAuth2 authorization-code flow, storing the
+token in a pluggable TokenStore and refreshing it when it expires. See the
+documentation of the auth package for details.
 
 Creating and Updating Resources
 
 All structs in this library use pointer values so that there is differentiation
 between an unset value and a zero value. This also allows the same structs to be
 encoded and decoded without having to learn two different data types.  Helper
-methods are provided to create pointer values for string, int, float64, and
-bool:
+methods are provided to create pointer values for string, int, uint64, float64,
+bool and monetary amounts:
 
 	product := &wave.Product{
 		Name: wave.String("Widgets"),
-		Price: wave.Float64(42.34),
+		Price: wave.Amount("42.34"),
 		IsSold: wave.Bool(true),
 	}
 	client.Products.Create(bID, product)
 
+Monetary Amounts
+
+Prices, totals, payments and other monetary fields use Money, an exact decimal
+amount plus an ISO 4217 currency code, rather than float64. Amounts decode from
+and encode to the API's plain JSON numbers without losing digits, and
+arithmetic rounds to the minor units of the currency (cents for USD, none for
+JPY):
+
+	price := wave.MustParseMoney("19.99", "USD")
+	subtotal, err := price.Mul(3)
+	total, err := subtotal.Add(wave.MustParseMoney("4.50", "USD"))
+	fmt.Println(total) // 64.47 USD
+
+The amounts of a decoded Invoice or Bill, and of its items, take its
+currency. Other amounts decoded from a response, such as product prices,
+payments and transaction lines, carry no currency and round to two decimal
+places; attach one with WithCurrency(code) before rounding or doing arithmetic
+in currencies which do not use two. Adding amounts in two different currencies
+returns a *MixedCurrencyError, and results too large for 64 bits of minor
+units, including Round and MinorUnits of an amount which decoded fine, return
+ErrAmountOutOfRange.
+
+To migrate code which used float64 amounts, replace wave.Float64(42.34) with
+wave.Amount("42.34") (or wave.MoneyFromFloat(f, code) for computed values), and
+read amounts back with Decimal, MinorUnits or, for display only, Float64.
+
+Currencies and Countries
+
+The package bundles the ISO 4217 currencies, with their symbols and minor
+units, and the ISO 3166-1 countries, with their ISO 3166-2 subdivisions as
+provinces, so that they can be looked up and checked without asking the API.
+Lookup returns them as the usual Currency and Country types:
+
+	cad := client.Currencies.Lookup("CAD") // *cad.MinorUnits == 2
+	ca := client.Countries.Lookup("CA")
+	ontario := ca.Province("ON") // by slug, name or ISO 3166-2 code
+
+BundledReference returns the bundled data itself, whose Version is that of the
+iso-codes release it was generated from. Money takes its minor units from it.
+Client.RefreshReference updates the client's copy with the currencies and
+countries the API lists, such as the provinces Wave knows about, for the
+lookups which follow.
+
 Optional Parameters
 
 Some endpoints take optional parameters -- usually LIST and GET methods. For
@@ -73,6 +119,264 @@ Pagination options are passed in the optional parameters:
 
 Again, omitting the PageOptions struct will not send any pagination parameters.
 
+Rather than looping over pages yourself, the Bills, Businesses, Customers,
+Invoices, Products, Transactions and Vendors services provide a ListIter
+method which returns an Iterator. Pages are fetched lazily as the iterator advances, and iteration
+stops after the last page:
+
+	it := client.Customers.ListIter(bID, nil)
+	for it.Next(ctx) {
+		customer := it.Item()
+		// Break out of the loop at any point to stop early.
+	}
+	if err := it.Err(); err != nil {
+		// The page that failed to load.
+	}
+
+ListAll collects the items of an iterator into a slice, up to a maximum
+number of items (zero means no limit):
+
+	customers, err := wave.ListAll(ctx, client.Customers.ListIter(bID, nil), 500)
+
+Cancellation and Deadlines
+
+Every service method has a Context variant which takes a context.Context as
+its first argument. The context is passed down to the underlying http.Client,
+so cancelling it (or letting its deadline expire) aborts the request:
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	customers, _, err := client.Customers.ListContext(ctx, bID, nil)
+	if err == context.DeadlineExceeded {
+		// The request took too long; this is not an API error.
+	}
+
+When a request fails because its context is done, the context's error is
+returned as-is so that it can be told apart from an *ErrorResponse.
+
+Retries
+
+Requests which fail with a transport error, a 429 Too Many Requests or a 500,
+502, 503 or 504 response can be retried with exponential backoff by setting a
+RetryPolicy on the client. A Retry-After header sent by the API takes
+precedence over the backoff, up to MaxBackoff. Only idempotent requests (GET,
+PUT and DELETE) are retried unless RetryPOST is set:
+
+	client.RetryPolicy = &wave.RetryPolicy{
+		MaxAttempts: 5,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Minute,
+		OnRetry: func(e wave.RetryEvent) {
+			log.Printf("retrying %v %v in %v (attempt %d)", e.Request.Method, e.Request.URL, e.Wait, e.Attempt)
+		},
+	}
+
+Rate Limiting
+
+A RateLimiter is a token bucket which holds requests back so that they stay
+under the API's throttle. It is safe for concurrent use and can be shared
+between several clients, for example one client per business using the same
+OAuth application:
+
+	limiter := wave.NewRateLimiter(5, 10) // 5 requests per second, bursts of 10
+	client.RateLimiter = limiter
+
+The rate limit headers of every response are parsed into Response.Rate, so
+you can see the remaining quota and when it resets. When a response reports
+that the quota is exhausted, a client's RateLimiter holds further requests
+back until the reset time.
+
+Middleware
+
+Middleware runs around every request a client sends, including its retries,
+so that tenant headers, auditing or custom authentication can be added without
+wrapping the http.Client. A Middleware wraps the Handler which sends a
+request: it can change the request before calling the next handler, inspect
+the Response and the decoded result after it, or return a response of its own
+without calling it at all. The first middleware given to Use is the outermost:
+
+	client.Use(wave.SetHeader("X-Tenant", "acme"), func(next wave.Handler) wave.Handler {
+		return func(req *http.Request, v interface{}) (*wave.Response, error) {
+			resp, err := next(req, v)
+			log.Printf("%v %v: %v", req.Method, req.URL, err)
+			return resp, err
+		}
+	})
+
+Logging
+
+Logging returns a middleware which logs the method, URL, status, latency and
+X-Total-Count of every request to a Logger, and with Bodies set the headers
+and bodies of the request and its response. Tokens, credentials,
+Authorization headers and the fields marked as sensitive, such as the email
+and phone numbers of a customer, are redacted. NewLogger prints entries to a
+*log.Logger, and any other destination can implement Logger:
+
+	client.Use(wave.Logging(wave.NewLogger(nil), &wave.LogOptions{Bodies: true}))
+
+	// GET https://api.waveapps.com/businesses/.../customers/ 200 184ms total=42
+	// < [{"email":"REDACTED","name":"Jane Doe",...}]
+
+Metrics
+
+Instrument returns a middleware which records every request in a Collector,
+labelled by the service method which sent it, such as Customers.List, rather
+than by URL, along with its status code, the class of its error and its
+latency. The built-in Metrics collector keeps request and error counts and
+latency histograms, and serves them in the Prometheus text format:
+
+	metrics := wave.NewMetrics(nil) // the default latency buckets
+	client.Use(wave.Instrument(metrics))
+	http.Handle("/metrics", metrics)
+
+	// gowave_requests_total{operation="Customers.List",code="200"} 12
+	// gowave_request_errors_total{operation="Customers.Get",class="not_found"} 1
+	// gowave_request_duration_seconds_bucket{operation="Customers.List",le="0.25"} 11
+
+Operation returns the service method of a request, for use in other
+middleware.
+
+Caching
+
+Setting Client.Cache caches the responses of GET requests. Responses with an
+ETag or Last-Modified header are revalidated with If-None-Match and
+If-Modified-Since, and a 304 Not Modified is answered with the cached body.
+Static reference data can instead be kept fresh for a while, per service
+method, and served without asking the API at all:
+
+	client.Cache = wave.NewCache(wave.NewMemoryStore(1000)) // at most 1000 responses
+	client.Cache.TTL["Currencies.List"] = 24 * time.Hour
+	client.Cache.TTL["Countries.List"] = 24 * time.Hour
+
+	currencies, resp, err := client.Currencies.List()
+	fromCache := resp.Header.Get(wave.HeaderFromCache) != ""
+
+NewDiskStore keeps responses on disk instead, so they outlive the process, and
+any CacheStore can be plugged in. Creating, replacing, updating or deleting a
+resource through the client, or acting on it, such as approving an invoice,
+invalidates the cached responses of its top-level collection, such as the
+invoices of the business; if that fails, the response to the change is
+returned with an *InvalidationError. Cache.Purge deletes cached responses by
+URL prefix. Responses are keyed by URL, so a store must not be shared by
+clients of different users.
+
+Errors
+
+A response with a status code outside the 200 range is returned as an
+*ErrorResponse, which keeps the raw response body in Body for debugging. Use
+errors.Is with ErrUnauthorized, ErrForbidden, ErrNotFound, ErrValidation,
+ErrRateLimited or ErrServer to check what went wrong, and errors.As with the
+matching typed error to get at the details:
+
+	_, _, err := client.Customers.Create(businessID, customer)
+
+	var verr *wave.ValidationError
+	var rerr *wave.RateLimitError
+	switch {
+	case errors.As(err, &verr):
+		for field, messages := range verr.Fields {
+			fmt.Println(field, messages) // email [Enter a valid email.]
+		}
+	case errors.As(err, &rerr):
+		time.Sleep(rerr.RetryAfter)
+	case errors.Is(err, wave.ErrNotFound):
+		// The business does not exist
+	}
+
+Bulk Import
+
+The wave/importer package creates and updates customers and products from CSV
+files. The header names the field set by each column, such as name, email,
+city, shipping_details.address.city or price; a currency, country or province
+column takes a code, or the slug of a province. Every row is validated before
+anything is sent, with its codes and provinces checked against the bundled ISO
+data, and matched to the existing records on its key (the email of a customer
+or the name of a product by default). Matching rows update the fields they set,
+the others create a record, and requests are sent a few at a time:
+
+	imp := &importer.Importer{Client: client, BusinessID: bID, DryRun: true}
+	report, err := imp.Customers(ctx, file)
+
+	// One row per line of the file: its action, the fields which change and any error
+	report.WriteCSV(os.Stdout)
+
+Backups
+
+The wave/backup package exports a business, with all its accounts, customers
+and products, to a versioned zip archive holding a JSON file per type of
+resource and a manifest with the number of resources and the SHA-256 checksum
+of each file. Reading an archive back checks it against its manifest:
+
+	snapshot, err := backup.Fetch(ctx, client, bID)
+	manifest, err := snapshot.WriteFile("acme.zip")
+
+	snapshot, manifest, err = backup.ReadFile("acme.zip")
+	if errors.Is(err, backup.ErrCorrupt) {
+		// The archive was modified or truncated
+	}
+
+A backup.Restorer recreates the accounts, customers and products of an archive
+in another business, such as to clone a template business for a new franchise.
+Products are given the accounts created from theirs. System accounts, which
+cannot be deleted or created, are matched to the account of the same name and
+type in the target business instead. A restore carries on past resources which
+fail, and its report says what happened to each one; with a journal file,
+running it again only creates what is missing:
+
+	r := &backup.Restorer{Client: client, BusinessID: targetID, JournalPath: "acme.journal"}
+	report, err := r.Restore(ctx, snapshot)
+	if err != nil {
+		// The restore could not run
+	}
+	fmt.Println(report) // 19 created, 0 resumed, 1 matched, 0 skipped, 1 failed
+
+Comparing Businesses
+
+The wave/diff package compares the accounts, customers and products of two
+businesses, such as a staging and a production business. Records are matched
+by a natural key rather than by ID: accounts by their account number, or their
+name if they have none, customers by their email, or their name if they have
+none, and products by their name. Each difference is a record added to or
+removed from the target, or a record whose fields changed. Apply makes the
+target business match the source, giving products the target's accounts:
+
+	d, err := diff.Compare(ctx, client, stagingID, productionID)
+	for _, difference := range d.Differences {
+		fmt.Println(difference.Kind, difference.Resource, difference.Key, difference.Changes)
+	}
+
+	// Delete removes the records which are only in production
+	err = d.Apply(ctx, client, &diff.ApplyOptions{Delete: true})
+
+Testing
+
+The wave/wavetest package provides an in-memory fake of the Wave API for
+testing code which uses this library. A wavetest.Server serves businesses,
+accounts, customers, products, the user, currencies and countries with real
+CRUD semantics and pagination, and its Client method returns a *wave.Client
+pointed at it:
+
+	srv := wavetest.NewServer()
+	defer srv.Close()
+
+	business := srv.AddBusiness(&wave.Business{CompanyName: wave.String("Acme")})
+	client := srv.Client()
+
+	// Make the next request fail, to test error handling
+	srv.Fail(wavetest.Failure{Status: http.StatusServiceUnavailable})
+
+To test against real responses instead, the wave/cassette package records
+requests to the Wave API and their responses to a fixture file, with
+Authorization headers and access tokens redacted, and replays them later
+without a network connection or token:
+
+	recorder, err := cassette.New("testdata/customers.json", cassette.ModeAuto)
+	defer recorder.Stop()
+	recorder.Transport = auth.StaticClient(ctx, token).Transport
+
+	client := wave.NewClient(recorder.Client())
+
 Examples
 
 Fetch all Accounts for a given Business:
@@ -108,6 +412,60 @@ Create a Business:
 	business, _, err = client.Businesses.Create(b)
 	// Do something with business
 
+Create, approve and send an Invoice:
+
+	invoice, _, err := client.Invoices.Create(businessID, &wave.Invoice{
+		Customer: &wave.Customer{ID: customerID},
+		Items: []wave.InvoiceItem{
+			{Product: &wave.Product{ID: wave.Uint64(productID)}, Quantity: wave.Float64(2)},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	client.Invoices.Approve(businessID, invoice.ID)
+	client.Invoices.Send(businessID, invoice.ID, &wave.InvoiceSendOptions{
+		To: []string{"customer@example.com"},
+	})
+
+Record and pay a Bill:
+
+	due := wave.Date(time.Now().AddDate(0, 0, 30))
+	bill, _, err := client.Bills.Create(businessID, &wave.Bill{
+		Vendor:  &wave.Vendor{ID: vendorID},
+		DueDate: &due,
+		Items: []wave.BillItem{
+			// Expensed to the product's ExpenseAccount
+			{Product: &wave.Product{ID: wave.Uint64(productID)}, Quantity: wave.Float64(2)},
+			// Expensed directly to an account
+			{Account: &wave.Account{ID: wave.Int(accountID)}, Price: wave.Amount("100")},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	client.Bills.RecordPayment(businessID, bill.ID, &wave.BillPayment{
+		Amount:  bill.AmountDue,
+		Account: &wave.Account{ID: wave.Int(bankAccountID)},
+	})
+
+Post a journal entry. Transactions are checked before they are sent: if there
+are no lines, a line sets both a debit and a credit, the debits do not equal
+the credits, or the accounts use different currencies, Create fails with
+ErrEmptyTransaction, a *DoubleSidedLineError, an *UnbalancedTransactionError or
+a *CurrencyMismatchError without making a request.
+
+	transaction, _, err := client.Transactions.Create(businessID, &wave.Transaction{
+		Description: wave.String("Owner investment"),
+		Lines: []wave.TransactionLine{
+			{Account: &wave.Account{ID: wave.Int(bankAccountID)}, Debit: wave.Amount("1000")},
+			{Account: &wave.Account{ID: wave.Int(equityAccountID)}, Credit: wave.Amount("1000")},
+		},
+	})
+	if _, ok := err.(*wave.UnbalancedTransactionError); ok {
+		// Fix the entry; nothing was sent to Wave
+	}
+
 Delete a Customer
 
 	resp, err := client.Customers.Delete(businessID, customerID)
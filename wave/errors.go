@@ -0,0 +1,256 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by an *ErrorResponse, depending on its status code,
+// when passed to errors.Is.
+var (
+	ErrUnauthorized = errors.New("unauthorized")      // 401
+	ErrForbidden    = errors.New("forbidden")         // 403
+	ErrNotFound     = errors.New("not found")         // 404
+	ErrValidation   = errors.New("validation failed") // 400 and 422
+	ErrRateLimited  = errors.New("rate limited")      // 429
+	ErrServer       = errors.New("server error")      // 5xx
+)
+
+// kind returns the sentinel error matching the status code of the response,
+// or nil if there is none.
+func (r *ErrorResponse) kind() error {
+	if r.Response == nil {
+		return nil
+	}
+	switch code := r.Response.StatusCode; {
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// Is reports whether target is the sentinel error for the status code of the
+// response, so that errors.Is(err, wave.ErrNotFound) is true for a 404.
+func (r *ErrorResponse) Is(target error) bool {
+	return target != nil && target == r.kind()
+}
+
+// As converts the response into the typed error for its status code, so that
+// errors.As can be used to get at the details of each kind of error:
+//
+//	var verr *wave.ValidationError
+//	if errors.As(err, &verr) {
+//		fmt.Println(verr.Fields["email"])
+//	}
+func (r *ErrorResponse) As(target interface{}) bool {
+	kind := r.kind()
+	switch t := target.(type) {
+	case **UnauthorizedError:
+		if kind == ErrUnauthorized {
+			*t = &UnauthorizedError{r}
+			return true
+		}
+	case **ForbiddenError:
+		if kind == ErrForbidden {
+			*t = &ForbiddenError{r}
+			return true
+		}
+	case **NotFoundError:
+		if kind == ErrNotFound {
+			*t = &NotFoundError{r}
+			return true
+		}
+	case **ValidationError:
+		if kind == ErrValidation {
+			*t = &ValidationError{r}
+			return true
+		}
+	case **RateLimitError:
+		if kind == ErrRateLimited {
+			e := &RateLimitError{ErrorResponse: r}
+			e.Rate, _ = parseRate(r.Response.Header)
+			e.RetryAfter, _ = parseRetryAfter(r.Response.Header.Get("Retry-After"))
+			*t = e
+			return true
+		}
+	case **ServerError:
+		if kind == ErrServer {
+			*t = &ServerError{r}
+			return true
+		}
+	}
+	return false
+}
+
+// UnauthorizedError is an API error with a 401 status code: the access token
+// is missing, invalid or expired.
+type UnauthorizedError struct{ *ErrorResponse }
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *UnauthorizedError) Unwrap() error { return e.ErrorResponse }
+
+// ForbiddenError is an API error with a 403 status code: the access token
+// does not grant access to the resource.
+type ForbiddenError struct{ *ErrorResponse }
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *ForbiddenError) Unwrap() error { return e.ErrorResponse }
+
+// NotFoundError is an API error with a 404 status code.
+type NotFoundError struct{ *ErrorResponse }
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *NotFoundError) Unwrap() error { return e.ErrorResponse }
+
+// ValidationError is an API error with a 400 or 422 status code: the request
+// was rejected, usually because of invalid fields. The per-field messages are
+// in Fields.
+type ValidationError struct{ *ErrorResponse }
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *ValidationError) Unwrap() error { return e.ErrorResponse }
+
+// RateLimitError is an API error with a 429 status code.
+type RateLimitError struct {
+	*ErrorResponse
+
+	// RetryAfter is how long the API asked to wait before trying again, or 0
+	// if it did not say.
+	RetryAfter time.Duration
+
+	// Rate is the quota reported by the response.
+	Rate Rate
+}
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *RateLimitError) Unwrap() error { return e.ErrorResponse }
+
+// ServerError is an API error with a 5xx status code.
+type ServerError struct{ *ErrorResponse }
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *ServerError) Unwrap() error { return e.ErrorResponse }
+
+// parseErrorBody fills in the message and per-field errors of r from an API
+// error body. The message is read from "error.message", or from a top-level
+// "message" or "detail". Field errors are only read from validation errors,
+// with a 400 or 422 status code, in one of the two shapes the API uses: an
+// "error.fields" object, whose values may be a message, a list of messages or
+// an object of nested fields, or a body that is a flat object of lists of
+// messages by field name. Other bodies are not taken as field errors, so that
+// keys such as "request_id" or "status" are not mistaken for fields.
+func (r *ErrorResponse) parseErrorBody(data []byte) {
+	var body map[string]json.RawMessage
+	if json.Unmarshal(data, &body) != nil {
+		return
+	}
+	for _, key := range []string{"detail", "message"} {
+		var message string
+		if json.Unmarshal(body[key], &message) == nil {
+			r.Err.Message = message
+		}
+	}
+	var e struct {
+		Message string                     `json:"message"`
+		Fields  map[string]json.RawMessage `json:"fields"`
+	}
+	raw, nested := body["error"]
+	if nested && json.Unmarshal(raw, &e) != nil {
+		// "error" may be a bare message rather than an object.
+		json.Unmarshal(raw, &e.Message)
+	}
+	if e.Message != "" {
+		r.Err.Message = e.Message
+	}
+
+	if r.kind() != ErrValidation {
+		return
+	}
+	fields := make(map[string][]string)
+	if nested {
+		for name, v := range e.Fields {
+			collectFieldErrors(fields, name, v)
+		}
+	} else {
+		flatFieldErrors(fields, body)
+	}
+	if len(fields) > 0 {
+		r.Fields = fields
+	}
+}
+
+// flatFieldErrors adds the field errors of a body that is an object of lists
+// of messages by field name, besides a top-level "message" or "detail". It
+// adds nothing if any other key has a value of a different type.
+func flatFieldErrors(fields map[string][]string, body map[string]json.RawMessage) {
+	found := make(map[string][]string, len(body))
+	for key, raw := range body {
+		var message string
+		if (key == "message" || key == "detail") && json.Unmarshal(raw, &message) == nil {
+			continue
+		}
+		var messages []string
+		if json.Unmarshal(raw, &messages) != nil {
+			return
+		}
+		found[key] = messages
+	}
+	for key, messages := range found {
+		fields[key] = messages
+	}
+}
+
+func collectFieldErrors(fields map[string][]string, name string, raw json.RawMessage) {
+	var message string
+	if json.Unmarshal(raw, &message) == nil {
+		fields[name] = append(fields[name], message)
+		return
+	}
+	var messages []string
+	if json.Unmarshal(raw, &messages) == nil {
+		fields[name] = append(fields[name], messages...)
+		return
+	}
+	var nested map[string]json.RawMessage
+	if json.Unmarshal(raw, &nested) == nil {
+		for key, v := range nested {
+			collectFieldErrors(fields, name+"."+key, v)
+		}
+	}
+}
+
+// fieldSummary formats the per-field messages in a stable order, such as
+// "email: Enter a valid email.; name: This field is required."
+func (r *ErrorResponse) fieldSummary() string {
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + strings.Join(r.Fields[name], " ")
+	}
+	return strings.Join(parts, "; ")
+}
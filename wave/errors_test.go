@@ -0,0 +1,197 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// errorFor returns the error CheckResponse reports for a response with the
+// given status code and body.
+func errorFor(code int, body string) error {
+	u, _ := url.Parse("http://example.com/")
+	return CheckResponse(&http.Response{
+		Request:    &http.Request{Method: "POST", URL: u},
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	})
+}
+
+func TestErrorKinds(t *testing.T) {
+	Convey("API errors should match the sentinel for their status code", t, func() {
+		cases := []struct {
+			code     int
+			sentinel error
+		}{
+			{http.StatusUnauthorized, ErrUnauthorized},
+			{http.StatusForbidden, ErrForbidden},
+			{http.StatusNotFound, ErrNotFound},
+			{http.StatusBadRequest, ErrValidation},
+			{http.StatusUnprocessableEntity, ErrValidation},
+			{http.StatusTooManyRequests, ErrRateLimited},
+			{http.StatusInternalServerError, ErrServer},
+			{http.StatusServiceUnavailable, ErrServer},
+		}
+		all := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrValidation, ErrRateLimited, ErrServer}
+
+		for _, c := range cases {
+			err := errorFor(c.code, `{}`)
+			for _, sentinel := range all {
+				So(errors.Is(err, sentinel), ShouldEqual, sentinel == c.sentinel)
+			}
+		}
+
+		err := errorFor(http.StatusConflict, `{}`)
+		for _, sentinel := range all {
+			So(errors.Is(err, sentinel), ShouldBeFalse)
+		}
+	})
+
+	Convey("API errors should convert to the typed error for their status code", t, func() {
+		var unauthorized *UnauthorizedError
+		So(errors.As(errorFor(http.StatusUnauthorized, `{}`), &unauthorized), ShouldBeTrue)
+		So(unauthorized.Response.StatusCode, ShouldEqual, http.StatusUnauthorized)
+
+		var forbidden *ForbiddenError
+		So(errors.As(errorFor(http.StatusForbidden, `{}`), &forbidden), ShouldBeTrue)
+
+		var notFound *NotFoundError
+		So(errors.As(errorFor(http.StatusNotFound, `{}`), &notFound), ShouldBeTrue)
+		So(errors.As(errorFor(http.StatusForbidden, `{}`), &notFound), ShouldBeFalse)
+
+		var validation *ValidationError
+		So(errors.As(errorFor(http.StatusBadRequest, `{}`), &validation), ShouldBeTrue)
+
+		var server *ServerError
+		So(errors.As(errorFor(http.StatusBadGateway, `{}`), &server), ShouldBeTrue)
+
+		var resp *ErrorResponse
+		So(errors.As(errorFor(http.StatusNotFound, `{}`), &resp), ShouldBeTrue)
+
+		Convey("Typed errors should still match their sentinel and unwrap", func() {
+			So(errors.Is(notFound, ErrNotFound), ShouldBeTrue)
+			So(errors.Unwrap(notFound), ShouldEqual, notFound.ErrorResponse)
+
+			wrapped := fmt.Errorf("syncing customers: %w", errorFor(http.StatusNotFound, `{}`))
+			So(errors.Is(wrapped, ErrNotFound), ShouldBeTrue)
+			So(errors.As(wrapped, &notFound), ShouldBeTrue)
+		})
+	})
+
+	Convey("A RateLimitError should carry the Retry-After and quota", t, func() {
+		u, _ := url.Parse("http://example.com/")
+		err := CheckResponse(&http.Response{
+			Request:    &http.Request{Method: "GET", URL: u},
+			StatusCode: http.StatusTooManyRequests,
+			Header: http.Header{
+				"Retry-After":           {"30"},
+				"X-Ratelimit-Limit":     {"100"},
+				"X-Ratelimit-Remaining": {"0"},
+			},
+			Body: ioutil.NopCloser(strings.NewReader(`{"error": {"message": "Slow down"}}`)),
+		})
+
+		var rate *RateLimitError
+		So(errors.As(err, &rate), ShouldBeTrue)
+		So(rate.RetryAfter, ShouldEqual, 30*time.Second)
+		So(rate.Rate.Limit, ShouldEqual, 100)
+		So(rate.Rate.Remaining, ShouldEqual, 0)
+		So(rate.Err.Message, ShouldEqual, "Slow down")
+	})
+}
+
+func TestErrorBodies(t *testing.T) {
+	Convey("Parsing API error bodies", t, func() {
+		Convey("Should read field errors nested in the error object", func() {
+			err := errorFor(http.StatusBadRequest, `{"error": {"message": "Invalid data", "fields": {"email": ["Enter a valid email."], "name": "This field is required."}}}`).(*ErrorResponse)
+			So(err.Err.Message, ShouldEqual, "Invalid data")
+			So(err.Fields, ShouldResemble, map[string][]string{
+				"email": {"Enter a valid email."},
+				"name":  {"This field is required."},
+			})
+			So(err.Error(), ShouldEqual, "POST http://example.com/: 400 Invalid data (email: Enter a valid email.; name: This field is required.)")
+
+			err = errorFor(http.StatusUnprocessableEntity, `{"error": {"fields": {"address": {"postal_code": ["Invalid postal code."]}}}}`).(*ErrorResponse)
+			So(err.Fields, ShouldResemble, map[string][]string{
+				"address.postal_code": {"Invalid postal code."},
+			})
+		})
+
+		Convey("Should read field errors from a flat object of top-level keys", func() {
+			err := errorFor(http.StatusBadRequest, `{"first_name": ["Too long.", "Invalid characters."], "email": ["Enter a valid email."], "detail": "Invalid input"}`).(*ErrorResponse)
+			So(err.Err.Message, ShouldEqual, "Invalid input")
+			So(err.Fields, ShouldResemble, map[string][]string{
+				"first_name": {"Too long.", "Invalid characters."},
+				"email":      {"Enter a valid email."},
+			})
+
+			err = errorFor(http.StatusBadRequest, `{"first_name": ["Too long."], "code": 17}`).(*ErrorResponse)
+			So(err.Fields, ShouldBeNil)
+		})
+
+		Convey("Should not read field errors from other bodies", func() {
+			err := errorFor(http.StatusInternalServerError, `{"error": {"message": "Something went wrong"}, "request_id": "abc123", "status": "error"}`).(*ErrorResponse)
+			So(err.Err.Message, ShouldEqual, "Something went wrong")
+			So(err.Fields, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "POST http://example.com/: 500 Something went wrong")
+
+			err = errorFor(http.StatusNotFound, `{"customer": ["No such customer."]}`).(*ErrorResponse)
+			So(err.Fields, ShouldBeNil)
+
+			err = errorFor(http.StatusBadRequest, `{"error": {"message": "Invalid data"}, "request_id": "abc123"}`).(*ErrorResponse)
+			So(err.Err.Message, ShouldEqual, "Invalid data")
+			So(err.Fields, ShouldBeNil)
+		})
+
+		Convey("Should accept a bare error message", func() {
+			err := errorFor(http.StatusNotFound, `{"error": "No such customer"}`).(*ErrorResponse)
+			So(err.Err.Message, ShouldEqual, "No such customer")
+			So(err.Fields, ShouldBeNil)
+		})
+
+		Convey("Should keep the raw body of anything else", func() {
+			body := "<html><body>Bad Gateway</body></html>"
+			err := errorFor(http.StatusBadGateway, body).(*ErrorResponse)
+			So(string(err.Body), ShouldEqual, body)
+			So(err.Err.Message, ShouldBeBlank)
+			So(err.Error(), ShouldEqual, "POST http://example.com/: 502 Bad Gateway")
+
+			again, _ := ioutil.ReadAll(err.Response.Body)
+			So(string(again), ShouldEqual, body)
+		})
+	})
+}
+
+func TestValidationErrorFromService(t *testing.T) {
+	Convey("A rejected CREATE should report which fields were invalid", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "Invalid data", "fields": {"email": ["Enter a valid email."]}}}`)
+		})
+
+		_, _, err := client.Customers.Create("1", &Customer{Email: String("nope")})
+		So(errors.Is(err, ErrValidation), ShouldBeTrue)
+
+		var verr *ValidationError
+		So(errors.As(err, &verr), ShouldBeTrue)
+		So(verr.Fields["email"], ShouldResemble, []string{"Enter a valid email."})
+		So(string(verr.Body), ShouldContainSubstring, "Enter a valid email.")
+	})
+}
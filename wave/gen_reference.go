@@ -0,0 +1,343 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+// gen_reference generates data/reference.json, the currencies and countries
+// bundled with the wave package, from the JSON files of the iso-codes package
+// (https://salsa.debian.org/iso-codes-team/iso-codes) and the tables below,
+// which hold what iso-codes does not: minor units, symbols and the currency of
+// each country.
+//
+// Usage:
+//
+//	go run gen_reference.go -iso-codes /usr/share/iso-codes/json -version 4.15.0
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minorUnits are the ISO 4217 minor units of the currencies which do not use
+// 2, with -1 for those which have none, such as gold.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+	"XAG": -1, "XAU": -1, "XBA": -1, "XBB": -1, "XBC": -1, "XBD": -1,
+	"XDR": -1, "XPD": -1, "XPT": -1, "XSU": -1, "XTS": -1, "XUA": -1, "XXX": -1,
+}
+
+// symbols are the local symbols of the currencies. Currencies without one,
+// such as funds and precious metals, use their code.
+var symbols = map[string]string{
+	"AED": "د.إ", "AFN": "؋", "ALL": "L", "AMD": "֏", "ANG": "ƒ", "AOA": "Kz",
+	"ARS": "$", "AUD": "$", "AWG": "ƒ", "AZN": "₼", "BAM": "KM", "BBD": "$",
+	"BDT": "৳", "BGN": "лв", "BHD": ".د.ب", "BIF": "FBu", "BMD": "$", "BND": "$",
+	"BOB": "Bs", "BRL": "R$", "BSD": "$", "BTN": "Nu.", "BWP": "P", "BYN": "Br",
+	"BZD": "$", "CAD": "$", "CDF": "FC", "CHF": "CHF", "CLP": "$", "CNY": "¥",
+	"COP": "$", "CRC": "₡", "CUC": "$", "CUP": "$", "CVE": "$", "CZK": "Kč",
+	"DJF": "Fdj", "DKK": "kr", "DOP": "$", "DZD": "د.ج", "EGP": "£", "ERN": "Nfk",
+	"ETB": "Br", "EUR": "€", "FJD": "$", "FKP": "£", "GBP": "£", "GEL": "₾",
+	"GHS": "₵", "GIP": "£", "GMD": "D", "GNF": "FG", "GTQ": "Q", "GYD": "$",
+	"HKD": "$", "HNL": "L", "HRK": "kn", "HTG": "G", "HUF": "Ft", "IDR": "Rp",
+	"ILS": "₪", "INR": "₹", "IQD": "ع.د", "IRR": "﷼", "ISK": "kr", "JMD": "$",
+	"JOD": "د.ا", "JPY": "¥", "KES": "KSh", "KGS": "сом", "KHR": "៛", "KMF": "CF",
+	"KPW": "₩", "KRW": "₩", "KWD": "د.ك", "KYD": "$", "KZT": "₸", "LAK": "₭",
+	"LBP": "ل.ل", "LKR": "Rs", "LRD": "$", "LSL": "L", "LYD": "ل.د", "MAD": "د.م.",
+	"MDL": "L", "MGA": "Ar", "MKD": "ден", "MMK": "K", "MNT": "₮", "MOP": "MOP$",
+	"MRU": "UM", "MUR": "Rs", "MVR": "Rf", "MWK": "MK", "MXN": "$", "MYR": "RM",
+	"MZN": "MT", "NAD": "$", "NGN": "₦", "NIO": "C$", "NOK": "kr", "NPR": "Rs",
+	"NZD": "$", "OMR": "ر.ع.", "PAB": "B/.", "PEN": "S/", "PGK": "K", "PHP": "₱",
+	"PKR": "Rs", "PLN": "zł", "PYG": "₲", "QAR": "ر.ق", "RON": "lei", "RSD": "дин.",
+	"RUB": "₽", "RWF": "FRw", "SAR": "ر.س", "SBD": "$", "SCR": "Rs", "SDG": "ج.س.",
+	"SEK": "kr", "SGD": "$", "SHP": "£", "SLE": "Le", "SLL": "Le", "SOS": "Sh",
+	"SRD": "$", "SSP": "£", "STN": "Db", "SVC": "₡", "SYP": "£", "SZL": "E",
+	"THB": "฿", "TJS": "SM", "TMT": "m", "TND": "د.ت", "TOP": "T$", "TRY": "₺",
+	"TTD": "$", "TWD": "$", "TZS": "TSh", "UAH": "₴", "UGX": "USh", "USD": "$",
+	"UYU": "$", "UZS": "soʻm", "VED": "Bs.D", "VES": "Bs.S", "VND": "₫", "VUV": "VT",
+	"WST": "T", "XAF": "FCFA", "XCD": "$", "XOF": "CFA", "XPF": "₣", "YER": "﷼",
+	"ZAR": "R", "ZMW": "ZK", "ZWL": "$",
+}
+
+// currencies are the currencies in use in each country. Antarctica has none.
+var currencies = map[string]string{
+	"AD": "EUR", "AE": "AED", "AF": "AFN", "AG": "XCD", "AI": "XCD", "AL": "ALL",
+	"AM": "AMD", "AO": "AOA", "AR": "ARS", "AS": "USD", "AT": "EUR", "AU": "AUD",
+	"AW": "AWG", "AX": "EUR", "AZ": "AZN", "BA": "BAM", "BB": "BBD", "BD": "BDT",
+	"BE": "EUR", "BF": "XOF", "BG": "BGN", "BH": "BHD", "BI": "BIF", "BJ": "XOF",
+	"BL": "EUR", "BM": "BMD", "BN": "BND", "BO": "BOB", "BQ": "USD", "BR": "BRL",
+	"BS": "BSD", "BT": "BTN", "BV": "NOK", "BW": "BWP", "BY": "BYN", "BZ": "BZD",
+	"CA": "CAD", "CC": "AUD", "CD": "CDF", "CF": "XAF", "CG": "XAF", "CH": "CHF",
+	"CI": "XOF", "CK": "NZD", "CL": "CLP", "CM": "XAF", "CN": "CNY", "CO": "COP",
+	"CR": "CRC", "CU": "CUP", "CV": "CVE", "CW": "ANG", "CX": "AUD", "CY": "EUR",
+	"CZ": "CZK", "DE": "EUR", "DJ": "DJF", "DK": "DKK", "DM": "XCD", "DO": "DOP",
+	"DZ": "DZD", "EC": "USD", "EE": "EUR", "EG": "EGP", "EH": "MAD", "ER": "ERN",
+	"ES": "EUR", "ET": "ETB", "FI": "EUR", "FJ": "FJD", "FK": "FKP", "FM": "USD",
+	"FO": "DKK", "FR": "EUR", "GA": "XAF", "GB": "GBP", "GD": "XCD", "GE": "GEL",
+	"GF": "EUR", "GG": "GBP", "GH": "GHS", "GI": "GIP", "GL": "DKK", "GM": "GMD",
+	"GN": "GNF", "GP": "EUR", "GQ": "XAF", "GR": "EUR", "GS": "GBP", "GT": "GTQ",
+	"GU": "USD", "GW": "XOF", "GY": "GYD", "HK": "HKD", "HM": "AUD", "HN": "HNL",
+	"HR": "EUR", "HT": "HTG", "HU": "HUF", "ID": "IDR", "IE": "EUR", "IL": "ILS",
+	"IM": "GBP", "IN": "INR", "IO": "USD", "IQ": "IQD", "IR": "IRR", "IS": "ISK",
+	"IT": "EUR", "JE": "GBP", "JM": "JMD", "JO": "JOD", "JP": "JPY", "KE": "KES",
+	"KG": "KGS", "KH": "KHR", "KI": "AUD", "KM": "KMF", "KN": "XCD", "KP": "KPW",
+	"KR": "KRW", "KW": "KWD", "KY": "KYD", "KZ": "KZT", "LA": "LAK", "LB": "LBP",
+	"LC": "XCD", "LI": "CHF", "LK": "LKR", "LR": "LRD", "LS": "LSL", "LT": "EUR",
+	"LU": "EUR", "LV": "EUR", "LY": "LYD", "MA": "MAD", "MC": "EUR", "MD": "MDL",
+	"ME": "EUR", "MF": "EUR", "MG": "MGA", "MH": "USD", "MK": "MKD", "ML": "XOF",
+	"MM": "MMK", "MN": "MNT", "MO": "MOP", "MP": "USD", "MQ": "EUR", "MR": "MRU",
+	"MS": "XCD", "MT": "EUR", "MU": "MUR", "MV": "MVR", "MW": "MWK", "MX": "MXN",
+	"MY": "MYR", "MZ": "MZN", "NA": "NAD", "NC": "XPF", "NE": "XOF", "NF": "AUD",
+	"NG": "NGN", "NI": "NIO", "NL": "EUR", "NO": "NOK", "NP": "NPR", "NR": "AUD",
+	"NU": "NZD", "NZ": "NZD", "OM": "OMR", "PA": "PAB", "PE": "PEN", "PF": "XPF",
+	"PG": "PGK", "PH": "PHP", "PK": "PKR", "PL": "PLN", "PM": "EUR", "PN": "NZD",
+	"PR": "USD", "PS": "ILS", "PT": "EUR", "PW": "USD", "PY": "PYG", "QA": "QAR",
+	"RE": "EUR", "RO": "RON", "RS": "RSD", "RU": "RUB", "RW": "RWF", "SA": "SAR",
+	"SB": "SBD", "SC": "SCR", "SD": "SDG", "SE": "SEK", "SG": "SGD", "SH": "SHP",
+	"SI": "EUR", "SJ": "NOK", "SK": "EUR", "SL": "SLE", "SM": "EUR", "SN": "XOF",
+	"SO": "SOS", "SR": "SRD", "SS": "SSP", "ST": "STN", "SV": "USD", "SX": "ANG",
+	"SY": "SYP", "SZ": "SZL", "TC": "USD", "TD": "XAF", "TF": "EUR", "TG": "XOF",
+	"TH": "THB", "TJ": "TJS", "TK": "NZD", "TL": "USD", "TM": "TMT", "TN": "TND",
+	"TO": "TOP", "TR": "TRY", "TT": "TTD", "TV": "AUD", "TW": "TWD", "TZ": "TZS",
+	"UA": "UAH", "UG": "UGX", "UM": "USD", "US": "USD", "UY": "UYU", "UZ": "UZS",
+	"VA": "EUR", "VC": "XCD", "VE": "VES", "VG": "USD", "VI": "USD", "VN": "VND",
+	"VU": "VUV", "WF": "XPF", "WS": "WST", "YE": "YER", "YT": "EUR", "ZA": "ZAR",
+	"ZM": "ZMW", "ZW": "ZWL",
+}
+
+// folds spells the letters with diacritics found in province names without
+// them, for their slugs.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a", 'ǎ': "a", 'ạ': "a", 'ả': "a", 'ấ': "a", 'ầ': "a", 'ẩ': "a", 'ậ': "a", 'ắ': "a", 'ằ': "a", 'ẵ': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'ċ': "c", 'ĉ': "c",
+	'ď': "d", 'đ': "d", 'ḍ': "d", 'ḑ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ĕ': "e", 'ə': "e", 'ẹ': "e", 'ế': "e", 'ề': "e", 'ể': "e", 'ệ': "e",
+	'ğ': "g", 'ġ': "g", 'ģ': "g", 'ǧ': "g",
+	'ħ': "h", 'ḥ': "h", 'ḩ': "h", 'ĥ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i", 'ǐ': "i", 'ĭ': "i", 'ị': "i", 'ỉ': "i", 'ĩ': "i",
+	'ķ': "k", 'ĸ': "k", 'ḳ': "k",
+	'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ł': "l", 'ŀ': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n", 'ṇ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'ǒ': "o", 'ŏ': "o", 'ọ': "o", 'ỏ': "o", 'ố': "o", 'ồ': "o", 'ổ': "o", 'ộ': "o", 'ơ': "o", 'ớ': "o", 'ờ': "o", 'ợ': "o",
+	'œ': "oe",
+	'ŕ': "r", 'ř': "r", 'ŗ': "r", 'ṛ': "r", 'ṟ': "r", 'ṙ': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'ṣ': "s", 'ŝ': "s",
+	'ß': "ss",
+	'ţ': "t", 'ť': "t", 'ț': "t", 'ṭ': "t", 'ŧ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u", 'ǔ': "u", 'ŭ': "u", 'ụ': "u", 'ủ': "u", 'ũ': "u", 'ư': "u", 'ứ': "u", 'ừ': "u", 'ữ': "u", 'ự': "u",
+	'ý': "y", 'ÿ': "y", 'ŷ': "y", 'ỳ': "y", 'ỹ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z", 'ẓ': "z", 'ẕ': "z",
+	'þ': "th", 'ð': "d",
+}
+
+// slug returns the slug of a province name, as the API spells them, such as
+// "new-mexico" for "New Mexico".
+func slug(name string) (string, error) {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		s, ok := folds[r]
+		switch {
+		case ok:
+		case strings.ContainsRune("'’ʻʼ`", r):
+			// Apostrophes and okinas join the letters around them.
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			s = string(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return "", fmt.Errorf("no folding for %q in %q", r, name)
+		default:
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+type currency struct {
+	Code       string `json:"code"`
+	Symbol     string `json:"symbol"`
+	Name       string `json:"name"`
+	MinorUnits *int   `json:"minor_units"`
+}
+
+type province struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Code string `json:"code"`
+}
+
+type country struct {
+	Name         string     `json:"name"`
+	CountryCode  string     `json:"country_code"`
+	CurrencyCode string     `json:"currency_code,omitempty"`
+	Provinces    []province `json:"provinces"`
+}
+
+func main() {
+	dir := flag.String("iso-codes", "/usr/share/iso-codes/json", "directory of the iso-codes JSON files")
+	version := flag.String("version", "", "version of the iso-codes package")
+	out := flag.String("o", filepath.Join("data", "reference.json"), "file to write")
+	flag.Parse()
+	if *version == "" {
+		log.Fatal("-version is required")
+	}
+
+	var iso4217 struct {
+		Currencies []struct {
+			Code string `json:"alpha_3"`
+			Name string `json:"name"`
+		} `json:"4217"`
+	}
+	var iso3166 struct {
+		Countries []struct {
+			Code       string `json:"alpha_2"`
+			Name       string `json:"name"`
+			CommonName string `json:"common_name"`
+		} `json:"3166-1"`
+	}
+	var iso31662 struct {
+		Subdivisions []struct {
+			Code   string `json:"code"`
+			Name   string `json:"name"`
+			Type   string `json:"type"`
+			Parent string `json:"parent"`
+		} `json:"3166-2"`
+	}
+	read(filepath.Join(*dir, "iso_4217.json"), &iso4217)
+	read(filepath.Join(*dir, "iso_3166-1.json"), &iso3166)
+	read(filepath.Join(*dir, "iso_3166-2.json"), &iso31662)
+
+	var cs []currency
+	known := make(map[string]bool)
+	for _, c := range iso4217.Currencies {
+		units := 2
+		if u, ok := minorUnits[c.Code]; ok {
+			units = u
+		}
+		symbol := symbols[c.Code]
+		if symbol == "" {
+			symbol = c.Code
+		}
+		cur := currency{Code: c.Code, Symbol: symbol, Name: c.Name}
+		if units >= 0 {
+			cur.MinorUnits = &units
+		}
+		cs = append(cs, cur)
+		known[c.Code] = true
+	}
+
+	// Only top-level subdivisions are provinces. The slugs of those with
+	// the same name in a country, such as the city and the province of
+	// Maputo, are followed by their type.
+	provinces := make(map[string][]province)
+	types := make(map[string]string)
+	named := make(map[[2]string]int)
+	for _, s := range iso31662.Subdivisions {
+		if s.Parent != "" {
+			continue
+		}
+		sl, err := slug(s.Name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cc := s.Code[:2]
+		provinces[cc] = append(provinces[cc], province{Name: s.Name, Slug: sl, Code: s.Code})
+		types[s.Code] = s.Type
+		named[[2]string{cc, sl}]++
+	}
+	for cc, ps := range provinces {
+		for i, p := range ps {
+			if named[[2]string{cc, p.Slug}] > 1 {
+				t, _ := slug(types[p.Code])
+				ps[i].Slug += "-" + t
+			}
+		}
+	}
+
+	var countries []country
+	for _, c := range iso3166.Countries {
+		name := c.Name
+		if c.CommonName != "" {
+			name = c.CommonName
+		}
+		cur := currencies[c.Code]
+		if cur != "" && !known[cur] {
+			log.Fatalf("country %v uses unknown currency %v", c.Code, cur)
+		}
+		ps := provinces[c.Code]
+		sort.Slice(ps, func(i, j int) bool { return ps[i].Slug < ps[j].Slug })
+		for i := 1; i < len(ps); i++ {
+			if ps[i].Slug == ps[i-1].Slug {
+				log.Fatalf("provinces %v and %v have the same slug %q", ps[i-1].Code, ps[i].Code, ps[i].Slug)
+			}
+		}
+		if ps == nil {
+			ps = []province{}
+		}
+		countries = append(countries, country{Name: name, CountryCode: c.Code, CurrencyCode: cur, Provinces: ps})
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Code < cs[j].Code })
+	sort.Slice(countries, func(i, j int) bool { return countries[i].CountryCode < countries[j].CountryCode })
+
+	// Each currency and country is on a line of its own, so that changes to
+	// the data make readable diffs.
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "{\n\"version\": %q,\n\"currencies\": [\n", *version)
+	for i, c := range cs {
+		line(&b, c, i == len(cs)-1)
+	}
+	b.WriteString("],\n\"countries\": [\n")
+	for i, c := range countries {
+		line(&b, c, i == len(countries)-1)
+	}
+	b.WriteString("]\n}\n")
+	if err := os.WriteFile(*out, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func read(path string, v interface{}) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Fatalf("%v: %v", path, err)
+	}
+}
+
+func line(b *bytes.Buffer, v interface{}, last bool) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+	b.Write(bytes.TrimSpace(buf.Bytes()))
+	if !last {
+		b.WriteByte(',')
+	}
+	b.WriteByte('\n')
+}
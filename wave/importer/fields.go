@@ -0,0 +1,241 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// keyFields are the fields set by a column naming a nested resource, so that
+// a "currency" column holds a currency code rather than a whole currency.
+var keyFields = map[reflect.Type]string{
+	reflect.TypeOf(wave.Currency{}): "code",
+	reflect.TypeOf(wave.Country{}):  "country_code",
+	reflect.TypeOf(wave.Province{}): "slug",
+	reflect.TypeOf(wave.Account{}):  "id",
+}
+
+// readOnly are the fields set by the API, which cannot be imported.
+var readOnly = map[string]bool{"url": true, "date_created": true, "date_modified": true}
+
+var moneyType = reflect.TypeOf(wave.Money{})
+
+// resolve returns the path of the field of t with the given name, such as
+// "shipping_details.address.city".
+func resolve(t reflect.Type, name string) ([]string, error) {
+	path := strings.Split(name, ".")
+	for i, segment := range path {
+		f, ok := typeField(t, segment)
+		if !ok || readOnly[segment] {
+			return nil, fmt.Errorf("there is no field %q", name)
+		}
+		t = f.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if i == len(path)-1 {
+			if key, ok := keyFields[t]; ok {
+				path = append(path, key)
+				f, _ = typeField(t, key)
+				t = f.Type
+			}
+		}
+	}
+	if !isLeaf(t) {
+		return nil, fmt.Errorf("field %q is not a single value", name)
+	}
+	return path, nil
+}
+
+// isLeaf reports whether values of t can be read from a single cell.
+func isLeaf(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// jsonName returns the name of the struct field in JSON, and whether it is an
+// embedded struct whose fields are promoted.
+func jsonName(f reflect.StructField) (string, bool) {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if f.Anonymous && name == "" {
+		return "", true
+	}
+	if f.PkgPath != "" || name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+// typeField returns the field of the struct type t with the given JSON name,
+// looking through embedded structs such as the Address of a Customer.
+func typeField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fname, embedded := jsonName(f)
+		if embedded {
+			if inner, ok := typeField(f.Type, name); ok {
+				return inner, true
+			}
+		} else if fname == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// field returns the field of the struct v with the given JSON name. If alloc
+// is true, nil embedded structs are allocated on the way to it; otherwise the
+// returned Value is invalid if one of them is nil.
+func field(v reflect.Value, name string, alloc bool) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fname, embedded := jsonName(f)
+		if embedded {
+			if _, ok := typeField(f.Type, name); !ok {
+				continue
+			}
+			inner := deref(v.Field(i), alloc)
+			if !inner.IsValid() {
+				return inner
+			}
+			return field(inner, name, alloc)
+		}
+		if fname == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// deref follows the pointer v, allocating it if it is nil and alloc is true.
+// It returns the zero Value for a nil pointer otherwise.
+func deref(v reflect.Value, alloc bool) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !alloc {
+				return reflect.Value{}
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// set parses s into the field of the struct v at path.
+func set(v reflect.Value, path []string, s string) error {
+	for _, name := range path {
+		v = field(deref(v, true), name, true)
+	}
+	v = deref(v, true)
+	if u, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+		return u.UnmarshalJSON([]byte(strconv.Quote(s)))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot be set from a CSV cell")
+	}
+	return nil
+}
+
+// get returns the field of the struct v at path, or the zero Value if it, or
+// one of the structs on the way to it, is nil.
+func get(v reflect.Value, path []string) reflect.Value {
+	for _, name := range path {
+		v = deref(v, false)
+		if !v.IsValid() {
+			return v
+		}
+		v = field(v, name, false)
+	}
+	return deref(v, false)
+}
+
+// format returns the value of a field as it would be written in a cell.
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Type() == moneyType {
+		return v.Interface().(wave.Money).Decimal()
+	}
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return ""
+		}
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			return s
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// equal reports whether two field values are the same. Amounts of money are
+// compared by value, so that 10 equals 10.00.
+func equal(a, b reflect.Value) bool {
+	if a.IsValid() && b.IsValid() && a.Type() == moneyType && b.Type() == moneyType {
+		return a.Interface().(wave.Money).Cmp(b.Interface().(wave.Money)) == 0
+	}
+	return format(a) == format(b)
+}
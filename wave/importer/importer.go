@@ -0,0 +1,486 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package importer creates and updates customers and products of a Wave business
+in bulk from CSV files.
+
+The header of the file names the field set by each column, using the JSON
+names of the fields: "name", "email", "city" or "shipping_details.address.city"
+for a Customer, and "name", "price" or "is_sold" for a Product. Columns naming
+a nested resource take its code or ID, so "currency" holds a currency code,
+"country" a country code, "province" a province slug and "income_account" an
+account ID. Headers are matched ignoring case, and spaces and dashes count as
+underscores, so "Postal Code" sets postal_code. Blank cells are left unset.
+
+Every row is checked before anything is sent, then matched to the existing
+records of the business on its key, which is the email of a customer or the
+name of a product unless Importer.Key says otherwise, or on an "id" column if
+the file has one. Matching rows update the fields they set, and the others
+create a record:
+
+	imp := &importer.Importer{Client: client, BusinessID: bID, DryRun: true}
+	report, err := imp.Customers(ctx, file)
+	if err != nil {
+		// The file could not be read, or has an unknown column
+	}
+	report.WriteCSV(os.Stdout)
+
+A dry run only lists what would be created and updated, along with the fields
+which would change.
+*/
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// DefaultConcurrency is the number of requests sent at once if
+// Importer.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// Action is what is done with a row.
+type Action string
+
+const (
+	// Create creates a new record from the row.
+	Create Action = "create"
+
+	// Update updates the existing record matching the row.
+	Update Action = "update"
+
+	// Unchanged is a row whose fields all have the values of the existing
+	// record it matches, so nothing is sent.
+	Unchanged Action = "unchanged"
+
+	// Invalid is a row which failed validation, so nothing is sent.
+	Invalid Action = "invalid"
+)
+
+// FieldError is a problem with a field of a row, found before anything is
+// sent.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Message)
+}
+
+// FieldErrors are all the problems with the fields of a row.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Change is a field whose value in a row differs from the existing record.
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// Result is what happened to a row.
+type Result struct {
+	// Line is the line of the row in the file, the header being line 1.
+	Line int
+
+	// Key is the value of the key column of the row.
+	Key string
+
+	Action Action
+
+	// Changes are the fields being updated, in the order of the columns.
+	Changes []Change
+
+	// ID is the ID of the record which was created or updated, or which the
+	// row matched.
+	ID string
+
+	// Err is why the row is Invalid, or the error returned by the API. It is
+	// a FieldErrors for an invalid row.
+	Err error
+}
+
+// Report is the result of an import, with a Result for every row in the
+// order of the file.
+type Report struct {
+	DryRun  bool
+	Results []*Result
+}
+
+// Count returns the number of rows with the given action, whether or not
+// they succeeded.
+func (r *Report) Count(action Action) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of rows which were invalid or which the API
+// rejected.
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) String() string {
+	verb := ""
+	if r.DryRun {
+		verb = "to be "
+	}
+	return fmt.Sprintf("%d %vcreated, %d %vupdated, %d unchanged, %d failed",
+		r.Count(Create), verb, r.Count(Update), verb, r.Count(Unchanged), r.Failed())
+}
+
+// WriteCSV writes the report as CSV, with a row for every row of the file.
+// The status of a row is "ok", "failed" or, in a dry run, "planned".
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"line", "key", "action", "id", "status", "changes", "error"})
+	for _, res := range r.Results {
+		status := "ok"
+		switch {
+		case res.Err != nil:
+			status = "failed"
+		case r.DryRun && res.Action != Unchanged:
+			status = "planned"
+		}
+		changes := make([]string, len(res.Changes))
+		for i, c := range res.Changes {
+			changes[i] = c.String()
+		}
+		message := ""
+		if res.Err != nil {
+			message = res.Err.Error()
+		}
+		cw.Write([]string{strconv.Itoa(res.Line), res.Key, string(res.Action), res.ID, status, strings.Join(changes, "; "), message})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Importer imports CSV files into a business.
+type Importer struct {
+	Client     *wave.Client
+	BusinessID string
+
+	// Key is the field which matches a row to an existing record. If empty,
+	// customers are matched on "email" and products on "name". Rows with a
+	// blank key always create a record.
+	Key string
+
+	// Columns maps the headers of the file to the fields they set, for files
+	// whose headers are not field names, such as {"E-mail": "email"}.
+	Columns map[string]string
+
+	// Concurrency is the most requests sent at once. If zero,
+	// DefaultConcurrency is used.
+	Concurrency int
+
+	// DryRun reports what would be done without creating or updating
+	// anything.
+	DryRun bool
+}
+
+// kind describes how a type of record is imported.
+type kind[T any] struct {
+	key      string   // the default Key
+	required []string // the fields needed to create a record
+	validate func(*T) FieldErrors
+	list     func(ctx context.Context, imp *Importer) ([]T, error)
+	id       func(*T) uint64
+	create   func(ctx context.Context, imp *Importer, v *T) (*T, error)
+	update   func(ctx context.Context, imp *Importer, id uint64, v *T) (*T, error)
+}
+
+// row is a row of the file which is being imported.
+type row[T any] struct {
+	result *Result
+	value  *T
+	set    []*column // the columns with a value in the row
+	id     uint64    // the ID of the existing record
+}
+
+// column is a column of the file and the field it sets.
+type column struct {
+	name string
+	path []string
+}
+
+func (c *column) field() string {
+	return strings.Join(c.path, ".")
+}
+
+// Customers imports a file of customers.
+func (imp *Importer) Customers(ctx context.Context, r io.Reader) (*Report, error) {
+	return run(ctx, imp, r, customers)
+}
+
+// Products imports a file of products.
+func (imp *Importer) Products(ctx context.Context, r io.Reader) (*Report, error) {
+	return run(ctx, imp, r, products)
+}
+
+// run reads the rows of r, checks them, plans what to do with each one
+// against the existing records and carries it out unless imp.DryRun is set.
+// The error is only set for problems with the file as a whole.
+func run[T any](ctx context.Context, imp *Importer, r io.Reader, k *kind[T]) (*Report, error) {
+	rows, keyPath, err := read(imp, r, k)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{DryRun: imp.DryRun}
+	for _, row := range rows {
+		report.Results = append(report.Results, row.result)
+	}
+	if len(rows) == 0 {
+		return report, nil
+	}
+
+	existing, err := k.list(ctx, imp)
+	if err != nil {
+		return nil, err
+	}
+	plan(k, rows, keyPath, existing)
+	if !imp.DryRun {
+		send(ctx, imp, k, rows)
+	}
+	return report, nil
+}
+
+// read reads and validates the rows of the file.
+func read[T any](imp *Importer, r io.Reader, k *kind[T]) ([]*row[T], []string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("importer: the file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("importer: %v", err)
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	columns := make([]*column, len(header))
+	idColumn := -1
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		field := name
+		if mapped, ok := imp.Columns[name]; ok {
+			field = mapped
+		}
+		field = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(field))
+		if field == "id" {
+			idColumn = i
+			continue
+		}
+		path, err := resolve(t, field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("importer: column %q: %v", name, err)
+		}
+		columns[i] = &column{name: name, path: path}
+	}
+	key := imp.Key
+	if key == "" {
+		key = k.key
+	}
+	keyPath, err := resolve(t, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("importer: key: %v", err)
+	}
+
+	var rows []*row[T]
+	seen := make(map[string]int)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("importer: %v", err)
+		}
+		line, _ := cr.FieldPos(0)
+		rw := &row[T]{result: &Result{Line: line}, value: new(T)}
+		rows = append(rows, rw)
+
+		var errs FieldErrors
+		if len(record) != len(header) {
+			errs = append(errs, &FieldError{"row", fmt.Sprintf("has %d cells, want %d", len(record), len(header))})
+		}
+		v := reflect.ValueOf(rw.value).Elem()
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" || i >= len(header) {
+				continue
+			}
+			if i == idColumn {
+				id, err := strconv.ParseUint(cell, 10, 64)
+				if err != nil {
+					errs = append(errs, &FieldError{"id", fmt.Sprintf("%q is not an ID", cell)})
+				}
+				rw.id = id
+				continue
+			}
+			if err := set(v, columns[i].path, cell); err != nil {
+				errs = append(errs, &FieldError{columns[i].field(), err.Error()})
+				continue
+			}
+			rw.set = append(rw.set, columns[i])
+		}
+		errs = append(errs, k.validate(rw.value)...)
+
+		rw.result.Key = format(get(v, keyPath))
+		if normalized := normalize(rw.result.Key); normalized != "" && rw.id == 0 {
+			if first, ok := seen[normalized]; ok {
+				errs = append(errs, &FieldError{key, fmt.Sprintf("is the same as on line %d", first)})
+			} else {
+				seen[normalized] = line
+			}
+		}
+		if len(errs) > 0 {
+			rw.invalid(errs...)
+		}
+	}
+	return rows, keyPath, nil
+}
+
+// plan matches the rows to the existing records, and decides whether to
+// create or update each one.
+func plan[T any](k *kind[T], rows []*row[T], keyPath []string, existing []T) {
+	byKey := make(map[string]*T)
+	byID := make(map[uint64]*T)
+	for i := range existing {
+		e := &existing[i]
+		byID[k.id(e)] = e
+		key := normalize(format(get(reflect.ValueOf(e).Elem(), keyPath)))
+		if _, ok := byKey[key]; key != "" && !ok {
+			byKey[key] = e
+		}
+	}
+
+	for _, rw := range rows {
+		if rw.result.Action == Invalid {
+			continue
+		}
+		var match *T
+		if rw.id != 0 {
+			if match = byID[rw.id]; match == nil {
+				rw.invalid(&FieldError{"id", fmt.Sprintf("no record has ID %d", rw.id)})
+				continue
+			}
+		} else if key := normalize(rw.result.Key); key != "" {
+			match = byKey[key]
+		}
+
+		v := reflect.ValueOf(rw.value).Elem()
+		if match == nil {
+			var errs FieldErrors
+			for _, field := range k.required {
+				if !get(v, strings.Split(field, ".")).IsValid() {
+					errs = append(errs, &FieldError{field, "is required to create a record"})
+				}
+			}
+			if len(errs) > 0 {
+				rw.invalid(errs...)
+				continue
+			}
+			rw.result.Action = Create
+			continue
+		}
+
+		rw.id = k.id(match)
+		rw.result.ID = strconv.FormatUint(rw.id, 10)
+		old := reflect.ValueOf(match).Elem()
+		for _, c := range rw.set {
+			a, b := get(old, c.path), get(v, c.path)
+			if !equal(a, b) {
+				rw.result.Changes = append(rw.result.Changes, Change{Field: c.field(), Old: format(a), New: format(b)})
+			}
+		}
+		rw.result.Action = Update
+		if len(rw.result.Changes) == 0 {
+			rw.result.Action = Unchanged
+		}
+	}
+}
+
+// send creates and updates the records of the rows, sending at most
+// imp.Concurrency requests at once.
+func send[T any](ctx context.Context, imp *Importer, k *kind[T], rows []*row[T]) {
+	n := imp.Concurrency
+	if n <= 0 {
+		n = DefaultConcurrency
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for _, rw := range rows {
+		if rw.result.Action != Create && rw.result.Action != Update {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(rw *row[T]) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var saved *T
+			var err error
+			if rw.result.Action == Create {
+				saved, err = k.create(ctx, imp, rw.value)
+			} else {
+				saved, err = k.update(ctx, imp, rw.id, rw.value)
+			}
+			if err != nil {
+				rw.result.Err = err
+				return
+			}
+			rw.result.ID = strconv.FormatUint(k.id(saved), 10)
+		}(rw)
+	}
+	wg.Wait()
+}
+
+// invalid marks the row as Invalid.
+func (rw *row[T]) invalid(errs ...*FieldError) {
+	rw.result.Action = Invalid
+	rw.result.Err = FieldErrors(errs)
+}
+
+// normalize returns the key used to match a row to a record.
+func normalize(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/NickPresta/gowave/wave"
+	"github.com/NickPresta/gowave/wave/wavetest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	// srv is the fake API the importer talks to.
+	srv *wavetest.Server
+
+	// businessID is the ID of a business added to srv.
+	businessID string
+)
+
+func setUp() *Importer {
+	srv = wavetest.NewServer()
+	business := srv.AddBusiness(&wave.Business{
+		CompanyName:         wave.String("Acme"),
+		PrimaryCurrencyCode: wave.String("USD"),
+	})
+	businessID = *business.ID
+	return &Importer{Client: srv.Client(), BusinessID: businessID}
+}
+
+func tearDown() {
+	srv.Close()
+}
+
+// customersCSV has a row for each action, and the lines of the rows are their
+// index in the file plus two.
+const customersCSV = `Name,E-mail,City,Province,Country,Currency,shipping_details.address.city
+Jane Doe,jane@example.com,Ottawa,,,,
+Jim Smith,jim@example.com,Toronto,ontario,CA,CAD,
+Ann Lee,ann@example.com,,,US,,Boston
+Bad Email,not-an-email,,,,,
+,nameless@example.com,,,,,
+Copy,ann@example.com,,,,,
+Bad Country,bad@example.com,,,usa,cad,
+`
+
+func TestCustomers(t *testing.T) {
+	Convey("Importing customers", t, func() {
+		imp := setUp()
+		defer tearDown()
+		ctx := context.Background()
+		imp.Columns = map[string]string{"E-mail": "email"}
+
+		jane := srv.AddCustomer(businessID, &wave.Customer{
+			Name:    wave.String("Jane Doe"),
+			Email:   wave.String("Jane@Example.com"),
+			Address: &wave.Address{City: wave.String("Toronto")},
+		})
+		jim := srv.AddCustomer(businessID, &wave.Customer{
+			Name:     wave.String("Jim Smith"),
+			Email:    wave.String("jim@example.com"),
+			Currency: &wave.Currency{Code: wave.String("CAD")},
+			Address: &wave.Address{
+				City:     wave.String("Toronto"),
+				Province: &wave.Province{Slug: wave.String("ontario")},
+				Country:  &wave.Country{CountryCode: wave.String("CA")},
+			},
+		})
+
+		Convey("A dry run should plan every row without sending anything", func() {
+			imp.DryRun = true
+			report, err := imp.Customers(ctx, strings.NewReader(customersCSV))
+			So(err, ShouldBeNil)
+			So(report.Results, ShouldHaveLength, 7)
+
+			update := report.Results[0]
+			So(update.Line, ShouldEqual, 2)
+			So(update.Key, ShouldEqual, "jane@example.com")
+			So(update.Action, ShouldEqual, Update)
+			So(update.ID, ShouldEqual, fmt.Sprint(jane.ID))
+			So(update.Changes, ShouldResemble, []Change{
+				{Field: "email", Old: "Jane@Example.com", New: "jane@example.com"},
+				{Field: "city", Old: "Toronto", New: "Ottawa"},
+			})
+
+			So(report.Results[1].Action, ShouldEqual, Unchanged)
+			So(report.Results[1].ID, ShouldEqual, fmt.Sprint(jim.ID))
+			So(report.Results[2].Action, ShouldEqual, Create)
+			So(report.Results[2].Err, ShouldBeNil)
+
+			for _, res := range report.Results[3:] {
+				So(res.Action, ShouldEqual, Invalid)
+			}
+			So(report.Results[3].Err.Error(), ShouldEqual, "email: is not an email address")
+			So(report.Results[4].Err.Error(), ShouldEqual, "name: is required to create a record")
+			So(report.Results[5].Err.Error(), ShouldEqual, "email: is the same as on line 4")
+			var errs FieldErrors
+			So(errors.As(report.Results[6].Err, &errs), ShouldBeTrue)
+			So(errs, ShouldHaveLength, 2)
+			So(errs[0].Field, ShouldEqual, "currency")
+			So(errs[1].Field, ShouldEqual, "country")
+
+			So(report.String(), ShouldEqual, "1 to be created, 1 to be updated, 1 unchanged, 4 failed")
+			customer, _, _ := imp.Client.Customers.Get(businessID, jane.ID)
+			So(*customer.City, ShouldEqual, "Toronto")
+			customers, _, _ := imp.Client.Customers.List(businessID, nil)
+			So(customers, ShouldHaveLength, 2)
+		})
+
+		Convey("Rows should be created and updated", func() {
+			report, err := imp.Customers(ctx, strings.NewReader(customersCSV))
+			So(err, ShouldBeNil)
+			So(report.Failed(), ShouldEqual, 4)
+			So(report.Results[0].Err, ShouldBeNil)
+			So(report.Results[2].Err, ShouldBeNil)
+
+			customer, _, _ := imp.Client.Customers.Get(businessID, jane.ID)
+			So(*customer.City, ShouldEqual, "Ottawa")
+			So(*customer.Name, ShouldEqual, "Jane Doe")
+
+			customers, _, _ := imp.Client.Customers.List(businessID, nil)
+			So(customers, ShouldHaveLength, 3)
+			ann := customers[2]
+			So(report.Results[2].ID, ShouldEqual, fmt.Sprint(ann.ID))
+			So(*ann.Email, ShouldEqual, "ann@example.com")
+			So(*ann.Country.CountryCode, ShouldEqual, "US")
+			So(*ann.ShippingDetails.Address.City, ShouldEqual, "Boston")
+
+			Convey("and importing the file again should change nothing", func() {
+				report, err := imp.Customers(ctx, strings.NewReader(customersCSV))
+				So(err, ShouldBeNil)
+				So(report.Count(Unchanged), ShouldEqual, 3)
+			})
+		})
+
+		Convey("Rows should be matched on an id column first", func() {
+			file := fmt.Sprintf("id,email\n%d,jane@example.org\n999,jim@example.org\n", jane.ID)
+			report, err := imp.Customers(ctx, strings.NewReader(file))
+			So(err, ShouldBeNil)
+			So(report.Results[0].Action, ShouldEqual, Update)
+			So(report.Results[0].Changes[0].Old, ShouldEqual, "Jane@Example.com")
+			So(report.Results[1].Err.Error(), ShouldEqual, "id: no record has ID 999")
+		})
+
+		Convey("Codes and provinces should be checked against the reference data", func() {
+			imp.DryRun = true
+			file := "name,currency,country,province\nA,ZZZ,,\nB,,ZZ,\nC,,CA,texas\nD,,CA,Ontario\nE,,CA,ON\nF,JPY,US,new-mexico\n"
+			report, err := imp.Customers(ctx, strings.NewReader(file))
+			So(err, ShouldBeNil)
+			So(report.Results[0].Err.Error(), ShouldEqual, "currency: is not an ISO 4217 currency code")
+			So(report.Results[1].Err.Error(), ShouldEqual, "country: is not an ISO 3166-1 country code")
+			So(report.Results[2].Err.Error(), ShouldEqual, "province: is not a province of CA")
+			So(report.Results[3].Err.Error(), ShouldEqual, `province: is not the slug of a province; use "ontario"`)
+			So(report.Results[4].Err.Error(), ShouldEqual, `province: is not the slug of a province; use "ontario"`)
+			So(report.Results[5].Err, ShouldBeNil)
+			So(report.Results[5].Action, ShouldEqual, Create)
+		})
+
+		Convey("Errors from the API should be reported on their row", func() {
+			srv.Fail(wavetest.Failure{Method: "POST", Status: http.StatusBadRequest, Body: `{"error": {"message": "Invalid data", "fields": {"name": ["Too long."]}}}`})
+			report, err := imp.Customers(ctx, strings.NewReader("name\nNew\n"))
+			So(err, ShouldBeNil)
+			So(report.Results[0].Action, ShouldEqual, Create)
+			So(errors.Is(report.Results[0].Err, wave.ErrValidation), ShouldBeTrue)
+		})
+
+		Convey("Problems with the file as a whole should fail the import", func() {
+			_, err := imp.Customers(ctx, strings.NewReader("name,colour\nJane,red\n"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, `importer: column "colour": there is no field "colour"`)
+
+			_, err = imp.Customers(ctx, strings.NewReader("name,url\n"))
+			So(err.Error(), ShouldEqual, `importer: column "url": there is no field "url"`)
+
+			_, err = imp.Customers(ctx, strings.NewReader(""))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestProducts(t *testing.T) {
+	Convey("Importing products", t, func() {
+		imp := setUp()
+		defer tearDown()
+		ctx := context.Background()
+
+		account := srv.AddAccount(businessID, &wave.Account{
+			Name:        wave.String("Sales"),
+			AccountType: wave.String("income"),
+		})
+		srv.AddProduct(businessID, &wave.Product{Name: wave.String("Widget"), Price: wave.Amount("10")})
+
+		var file bytes.Buffer
+		fmt.Fprintf(&file, "name,price,is_sold,income_account\n")
+		fmt.Fprintf(&file, "Widget,10.00,,\n")
+		fmt.Fprintf(&file, "widget,12.5,true,%d\n", *account.ID)
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(&file, "Gadget %d,%d.99,yes,\n", i, i)
+		}
+		fmt.Fprintf(&file, "Refund,-1,,\n")
+
+		imp.Key = "name"
+		imp.Columns = map[string]string{"income_account": "income_account.id"}
+		imp.Concurrency = 3
+		report, err := imp.Products(ctx, bytes.NewReader(file.Bytes()))
+		So(err, ShouldBeNil)
+
+		Convey("Amounts should be compared by value", func() {
+			So(report.Results[0].Action, ShouldEqual, Unchanged)
+		})
+
+		Convey("Keys should be matched ignoring case", func() {
+			So(report.Results[1].Action, ShouldEqual, Invalid)
+			So(report.Results[1].Err.Error(), ShouldEqual, "name: is the same as on line 2")
+		})
+
+		Convey("Cells should be parsed into the type of their field", func() {
+			So(report.Results[2].Err, ShouldNotBeNil)
+			So(report.Results[2].Err.Error(), ShouldEqual, `is_sold: "yes" is not true or false`)
+			So(report.Results[len(report.Results)-1].Err.Error(), ShouldEqual, "price: is negative")
+		})
+
+		Convey("Rows should be sent concurrently", func() {
+			file.Reset()
+			fmt.Fprintf(&file, "name,price,is_sold,income_account\n")
+			for i := 0; i < 20; i++ {
+				fmt.Fprintf(&file, "Gadget %d,%d.99,true,%d\n", i, i, *account.ID)
+			}
+			report, err := imp.Products(ctx, &file)
+			So(err, ShouldBeNil)
+			So(report.Failed(), ShouldEqual, 0)
+			So(report.Count(Create), ShouldEqual, 20)
+
+			products, err := wave.ListAll(ctx, imp.Client.Products.ListIter(businessID, nil), 0)
+			So(err, ShouldBeNil)
+			So(products, ShouldHaveLength, 21)
+			for _, p := range products[1:] {
+				So(*p.IsSold, ShouldBeTrue)
+				So(*p.IncomeAccount.ID, ShouldEqual, *account.ID)
+			}
+		})
+	})
+}
+
+func TestReport(t *testing.T) {
+	Convey("A report should be written as CSV", t, func() {
+		report := &Report{DryRun: true, Results: []*Result{
+			{Line: 2, Key: "a@example.com", Action: Update, ID: "1", Changes: []Change{{"city", "A", "B"}, {"name", "", "C"}}},
+			{Line: 3, Key: "b@example.com", Action: Unchanged, ID: "2"},
+			{Line: 4, Action: Invalid, Err: FieldErrors{{"name", "is required"}}},
+		}}
+		var buf bytes.Buffer
+		So(report.WriteCSV(&buf), ShouldBeNil)
+		records, err := csv.NewReader(&buf).ReadAll()
+		So(err, ShouldBeNil)
+		So(records, ShouldResemble, [][]string{
+			{"line", "key", "action", "id", "status", "changes", "error"},
+			{"2", "a@example.com", "update", "1", "planned", `city: "A" -> "B"; name: "" -> "C"`, ""},
+			{"3", "b@example.com", "unchanged", "2", "ok", "", ""},
+			{"4", "", "invalid", "", "failed", "", "name: is required"},
+		})
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+var customers = &kind[wave.Customer]{
+	key:      "email",
+	required: []string{"name"},
+	validate: validateCustomer,
+	list: func(ctx context.Context, imp *Importer) ([]wave.Customer, error) {
+		return wave.ListAll(ctx, imp.Client.Customers.ListIter(imp.BusinessID, nil), 0)
+	},
+	id: func(c *wave.Customer) uint64 { return c.ID },
+	create: func(ctx context.Context, imp *Importer, c *wave.Customer) (*wave.Customer, error) {
+		c, _, err := imp.Client.Customers.CreateContext(ctx, imp.BusinessID, c)
+		return c, err
+	},
+	update: func(ctx context.Context, imp *Importer, id uint64, c *wave.Customer) (*wave.Customer, error) {
+		c, _, err := imp.Client.Customers.UpdateContext(ctx, imp.BusinessID, id, c)
+		return c, err
+	},
+}
+
+var products = &kind[wave.Product]{
+	key:      "name",
+	required: []string{"name"},
+	validate: validateProduct,
+	list: func(ctx context.Context, imp *Importer) ([]wave.Product, error) {
+		return wave.ListAll(ctx, imp.Client.Products.ListIter(imp.BusinessID, nil), 0)
+	},
+	id: func(p *wave.Product) uint64 {
+		if p.ID == nil {
+			return 0
+		}
+		return *p.ID
+	},
+	create: func(ctx context.Context, imp *Importer, p *wave.Product) (*wave.Product, error) {
+		p, _, err := imp.Client.Products.CreateContext(ctx, imp.BusinessID, p)
+		return p, err
+	},
+	update: func(ctx context.Context, imp *Importer, id uint64, p *wave.Product) (*wave.Product, error) {
+		p, _, err := imp.Client.Products.UpdateContext(ctx, imp.BusinessID, id, p)
+		return p, err
+	},
+}
+
+func validateCustomer(c *wave.Customer) FieldErrors {
+	var errs FieldErrors
+	if c.Email != nil {
+		if addr, err := mail.ParseAddress(*c.Email); err != nil || addr.Address != *c.Email {
+			errs = append(errs, &FieldError{"email", "is not an email address"})
+		}
+	}
+	if c.Currency != nil && c.Currency.Code != nil {
+		errs = append(errs, validateCurrency("currency", *c.Currency.Code)...)
+	}
+	errs = append(errs, validateAddress("", c.Address)...)
+	if c.ShippingDetails != nil {
+		errs = append(errs, validateAddress("shipping_details.address.", c.ShippingDetails.Address)...)
+	}
+	return errs
+}
+
+// validateCurrency checks a currency code against the bundled reference data.
+func validateCurrency(field, code string) FieldErrors {
+	if errs := validateCode(field, code, 3); errs != nil {
+		return errs
+	}
+	if wave.BundledReference().Currency(code) == nil {
+		return FieldErrors{{field, "is not an ISO 4217 currency code"}}
+	}
+	return nil
+}
+
+// validateAddress checks the country of an address against the bundled
+// reference data, and its province against the provinces of the country.
+func validateAddress(prefix string, a *wave.Address) FieldErrors {
+	if a == nil || a.Country == nil || a.Country.CountryCode == nil {
+		return nil
+	}
+	code := *a.Country.CountryCode
+	if errs := validateCode(prefix+"country", code, 2); errs != nil {
+		return errs
+	}
+	country := wave.BundledReference().Country(code)
+	if country == nil {
+		return FieldErrors{{prefix + "country", "is not an ISO 3166-1 country code"}}
+	}
+	if a.Province == nil || a.Province.Slug == nil || len(country.Provinces) == 0 {
+		return nil
+	}
+	slug := *a.Province.Slug
+	switch p := country.Province(slug); {
+	case p == nil:
+		return FieldErrors{{prefix + "province", fmt.Sprintf("is not a province of %v", code)}}
+	case *p.Slug != slug:
+		return FieldErrors{{prefix + "province", fmt.Sprintf("is not the slug of a province; use %q", *p.Slug)}}
+	}
+	return nil
+}
+
+// validateCode checks the format of an ISO currency or country code, which is
+// made of n capital letters.
+func validateCode(field, code string, n int) FieldErrors {
+	valid := len(code) == n
+	for _, r := range code {
+		valid = valid && r >= 'A' && r <= 'Z'
+	}
+	if !valid {
+		return FieldErrors{{field, fmt.Sprintf("is not a code of %d capital letters", n)}}
+	}
+	return nil
+}
+
+func validateProduct(p *wave.Product) FieldErrors {
+	if p.Price != nil && p.Price.Sign() < 0 {
+		return FieldErrors{{"price", "is negative"}}
+	}
+	return nil
+}
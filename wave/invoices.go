@@ -0,0 +1,382 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// InvoicesService handles communication with the invoice related methods of the Wave API.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html
+type InvoicesService struct {
+	client *Client
+}
+
+// Invoice statuses, in the order an invoice normally moves through them.
+// Draft invoices are approved (saved), then sent, possibly viewed by the
+// customer, and finally paid, either partially or in full.
+const (
+	InvoiceStatusDraft   = "draft"
+	InvoiceStatusSaved   = "saved"
+	InvoiceStatusSent    = "sent"
+	InvoiceStatusViewed  = "viewed"
+	InvoiceStatusPartial = "partial"
+	InvoiceStatusPaid    = "paid"
+	InvoiceStatusOverdue = "overdue"
+)
+
+// Tax represents a sales tax applied to an invoice item.
+type Tax struct {
+	ID           *uint64  `json:"id,omitempty"`
+	URL          *string  `json:"url,omitempty"`
+	Name         *string  `json:"name,omitempty"`
+	Abbreviation *string  `json:"abbreviation,omitempty"`
+	Rate         *float64 `json:"rate,omitempty"`
+	Amount       *Money   `json:"amount,omitempty"`
+}
+
+func (t Tax) String() string {
+	return fmt.Sprintf("%v (%v%%)", deref(t.Abbreviation), deref(t.Rate))
+}
+
+// InvoiceItem represents a line item on an Invoice.
+type InvoiceItem struct {
+	ID          *uint64  `json:"id,omitempty"`
+	Product     *Product `json:"product,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	Price       *Money   `json:"price,omitempty"`
+	Amount      *Money   `json:"amount,omitempty"`
+	Taxes       []Tax    `json:"taxes,omitempty"`
+}
+
+// Invoice represents a bill sent to a Customer for products sold.
+type Invoice struct {
+	ID            uint64        `json:"id,omitempty"`
+	URL           *string       `json:"url,omitempty"`
+	InvoiceNumber *string       `json:"invoice_number,omitempty"`
+	PONumber      *string       `json:"po_number,omitempty"`
+	Status        *string       `json:"status,omitempty"`
+	Title         *string       `json:"title,omitempty"`
+	Subhead       *string       `json:"subhead,omitempty"`
+	Customer      *Customer     `json:"customer,omitempty"`
+	Currency      *Currency     `json:"currency,omitempty"`
+	InvoiceDate   *Date         `json:"invoice_date,omitempty"`
+	DueDate       *Date         `json:"due_date,omitempty"`
+	Items         []InvoiceItem `json:"items,omitempty"`
+	Subtotal      *Money        `json:"subtotal,omitempty"`
+	TaxTotal      *Money        `json:"tax_total,omitempty"`
+	Total         *Money        `json:"total,omitempty"`
+	AmountPaid    *Money        `json:"amount_paid,omitempty"`
+	AmountDue     *Money        `json:"amount_due,omitempty"`
+	Memo          *string       `json:"memo,omitempty"`
+	Footer        *string       `json:"footer,omitempty"`
+	DateCreated   *DateTime     `json:"date_created,omitempty"`
+	DateModified  *DateTime     `json:"date_modified,omitempty"`
+}
+
+func (i Invoice) String() string {
+	return fmt.Sprintf("Invoice %v (status=%v)", deref(i.InvoiceNumber), deref(i.Status))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The amounts of the invoice and its items take the invoice's currency.
+func (i *Invoice) UnmarshalJSON(data []byte) error {
+	type invoice Invoice
+	if err := json.Unmarshal(data, (*invoice)(i)); err != nil {
+		return err
+	}
+	withCurrency(i.Currency, i.Subtotal, i.TaxTotal, i.Total, i.AmountPaid, i.AmountDue)
+	for j := range i.Items {
+		withCurrency(i.Currency, i.Items[j].Price, i.Items[j].Amount)
+	}
+	return nil
+}
+
+// InvoicePayment represents a payment recorded against an Invoice.
+type InvoicePayment struct {
+	ID            *uint64   `json:"id,omitempty"`
+	URL           *string   `json:"url,omitempty"`
+	Amount        *Money    `json:"amount,omitempty"`
+	PaymentDate   *Date     `json:"payment_date,omitempty"`
+	PaymentMethod *string   `json:"payment_method,omitempty"`
+	Account       *Account  `json:"payment_account,omitempty"`
+	Memo          *string   `json:"memo,omitempty"`
+	DateCreated   *DateTime `json:"date_created,omitempty"`
+	DateModified  *DateTime `json:"date_modified,omitempty"`
+}
+
+// InvoiceSendOptions specifies the email sent with an invoice by Send.
+type InvoiceSendOptions struct {
+	To       []string `json:"to,omitempty"`
+	Subject  *string  `json:"subject,omitempty"`
+	Message  *string  `json:"message,omitempty"`
+	CCMyself *bool    `json:"cc_myself,omitempty"`
+}
+
+// InvoiceListOptions specifies the optional parameters to LIST endpoint.
+type InvoiceListOptions struct {
+	// Status only lists invoices with the given status, such as InvoiceStatusPaid.
+	Status string `url:"status,omitempty"`
+	// CustomerID only lists invoices for the given customer.
+	CustomerID uint64 `url:"customer_id,omitempty"`
+	// InvoiceDateStart and InvoiceDateEnd only list invoices dated within the
+	// range, inclusive.
+	InvoiceDateStart *Date `url:"invoice_date_start,omitempty"`
+	InvoiceDateEnd   *Date `url:"invoice_date_end,omitempty"`
+
+	PageOptions
+}
+
+// List all invoices for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#get--businesses-{business_id}-invoices-
+func (service *InvoicesService) List(businessID string, opts *InvoiceListOptions) ([]Invoice, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *InvoicesService) ListContext(ctx context.Context, businessID string, opts *InvoiceListOptions) ([]Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.List")
+	url := fmt.Sprintf("businesses/%v/invoices/", businessID)
+	url, err := addOptions(url, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	invoices := new([]Invoice)
+	resp, err := service.client.Do(req, invoices)
+	if err != nil {
+		return nil, resp, err
+	}
+	return *invoices, resp, nil
+}
+
+// ListIter returns an Iterator over every invoice for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *InvoicesService) ListIter(businessID string, opts *InvoiceListOptions) *Iterator[Invoice] {
+	o := new(InvoiceListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Invoice, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
+// Get an existing invoice for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#get--businesses-{business_id}-invoices-{invoice_id}-
+func (service *InvoicesService) Get(businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	return service.GetContext(context.Background(), businessID, invoiceID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *InvoicesService) GetContext(ctx context.Context, businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Get")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// Create a new draft invoice for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#post--businesses-{business_id}-invoices-
+func (service *InvoicesService) Create(businessID string, invoice *Invoice) (*Invoice, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, invoice)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *InvoicesService) CreateContext(ctx context.Context, businessID string, invoice *Invoice) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Create")
+	url := fmt.Sprintf("businesses/%v/invoices/", businessID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// Replace an existing invoice. You cannot create an invoice using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#put--businesses-{business_id}-invoices-{invoice_id}-
+func (service *InvoicesService) Replace(businessID string, invoiceID uint64, invoice *Invoice) (*Invoice, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, invoiceID, invoice)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *InvoicesService) ReplaceContext(ctx context.Context, businessID string, invoiceID uint64, invoice *Invoice) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Replace")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// Update an existing invoice. You cannot create an invoice using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#patch--businesses-{business_id}-invoices-{invoice_id}-
+func (service *InvoicesService) Update(businessID string, invoiceID uint64, invoice *Invoice) (*Invoice, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, invoiceID, invoice)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *InvoicesService) UpdateContext(ctx context.Context, businessID string, invoiceID uint64, invoice *Invoice) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Update")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// Delete an existing invoice.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#delete--businesses-{business_id}-invoices-{invoice_id}-
+func (service *InvoicesService) Delete(businessID string, invoiceID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, invoiceID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *InvoicesService) DeleteContext(ctx context.Context, businessID string, invoiceID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Invoices.Delete")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return service.client.Do(req, nil)
+}
+
+// Approve a draft invoice, moving it to the saved status so that it can be sent.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#post--businesses-{business_id}-invoices-{invoice_id}-approve-
+func (service *InvoicesService) Approve(businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	return service.ApproveContext(context.Background(), businessID, invoiceID)
+}
+
+// ApproveContext is like Approve but carries ctx through to the HTTP request.
+func (service *InvoicesService) ApproveContext(ctx context.Context, businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Approve")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/approve/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// Send an approved invoice to its customer by email.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#post--businesses-{business_id}-invoices-{invoice_id}-send-
+func (service *InvoicesService) Send(businessID string, invoiceID uint64, opts *InvoiceSendOptions) (*Invoice, *Response, error) {
+	return service.SendContext(context.Background(), businessID, invoiceID, opts)
+}
+
+// SendContext is like Send but carries ctx through to the HTTP request.
+func (service *InvoicesService) SendContext(ctx context.Context, businessID string, invoiceID uint64, opts *InvoiceSendOptions) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.Send")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/send/", businessID, invoiceID)
+	var body interface{}
+	if opts != nil {
+		body = opts
+	}
+	req, err := service.client.NewRequestContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// MarkSent marks an approved invoice as sent, for invoices delivered outside of Wave.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#post--businesses-{business_id}-invoices-{invoice_id}-mark-sent-
+func (service *InvoicesService) MarkSent(businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	return service.MarkSentContext(context.Background(), businessID, invoiceID)
+}
+
+// MarkSentContext is like MarkSent but carries ctx through to the HTTP request.
+func (service *InvoicesService) MarkSentContext(ctx context.Context, businessID string, invoiceID uint64) (*Invoice, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.MarkSent")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/mark-sent/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := new(Invoice)
+	resp, err := service.client.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+	return i, resp, nil
+}
+
+// RecordPayment records a payment against an invoice.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/invoices.html#post--businesses-{business_id}-invoices-{invoice_id}-payments-
+func (service *InvoicesService) RecordPayment(businessID string, invoiceID uint64, payment *InvoicePayment) (*InvoicePayment, *Response, error) {
+	return service.RecordPaymentContext(context.Background(), businessID, invoiceID, payment)
+}
+
+// RecordPaymentContext is like RecordPayment but carries ctx through to the HTTP request.
+func (service *InvoicesService) RecordPaymentContext(ctx context.Context, businessID string, invoiceID uint64, payment *InvoicePayment) (*InvoicePayment, *Response, error) {
+	ctx = withOperation(ctx, "Invoices.RecordPayment")
+	url := fmt.Sprintf("businesses/%v/invoices/%v/payments/", businessID, invoiceID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := new(InvoicePayment)
+	resp, err := service.client.Do(req, p)
+	if err != nil {
+		return nil, resp, err
+	}
+	return p, resp, nil
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	expectedInvoiceJSON = `{
+		"id": 1,
+		"url": "url",
+		"invoice_number": "42",
+		"po_number": "PO-1",
+		"status": "draft",
+		"title": "Invoice",
+		"subhead": "For services rendered",
+		"customer": {
+			"id": 1,
+			"name": "Mike Ehrmantraut",
+			"email": "mike@hitmenforhire.com"
+		},
+		"currency": {
+			"url": "url",
+			"code": "USD",
+			"symbol": "$",
+			"name": "U.S. dollar"
+		},
+		"invoice_date": "2013-12-05",
+		"due_date": "2014-01-04",
+		"items": [
+			{
+				"id": 1,
+				"product": {
+					"id": 3,
+					"name": "Product"
+				},
+				"description": "Product Description",
+				"quantity": 2,
+				"price": 13.37,
+				"amount": 26.74,
+				"taxes": [
+					{
+						"id": 1,
+						"name": "Harmonized Sales Tax",
+						"abbreviation": "HST",
+						"rate": 13,
+						"amount": 3.48
+					}
+				]
+			}
+		],
+		"subtotal": 26.74,
+		"tax_total": 3.48,
+		"total": 30.22,
+		"amount_paid": 0,
+		"amount_due": 30.22,
+		"memo": "Memo",
+		"footer": "Footer",
+		"date_created": "2013-12-05T10:31:01+00:00",
+		"date_modified": "2013-12-05T13:37:59+00:00"
+	}`
+	expectedInvoicesJSON = "[" + expectedInvoiceJSON + "]"
+
+	expectedInvoicePaymentJSON = `{
+		"id": 7,
+		"url": "url",
+		"amount": 30.22,
+		"payment_date": "2013-12-20",
+		"payment_method": "cheque",
+		"payment_account": {
+			"id": 1,
+			"name": "Chequing"
+		},
+		"memo": "Paid in full",
+		"date_created": "2013-12-20T10:31:01+00:00",
+		"date_modified": "2013-12-20T10:31:01+00:00"
+	}`
+)
+
+func TestInvoicesService(t *testing.T) {
+	expectedInvoiceStruct := new(Invoice)
+	json.Unmarshal([]byte(expectedInvoiceJSON), expectedInvoiceStruct)
+
+	expectedInvoicePaymentStruct := new(InvoicePayment)
+	json.Unmarshal([]byte(expectedInvoicePaymentJSON), expectedInvoicePaymentStruct)
+
+	Convey("Testing JSON unmarshalling of an Invoice", t, func() {
+		i := expectedInvoiceStruct
+		So(*i.Status, ShouldEqual, InvoiceStatusDraft)
+		So(time.Time(*i.DueDate).Format("2006-01-02"), ShouldEqual, "2014-01-04")
+		So(len(i.Items), ShouldEqual, 1)
+		So(*i.Items[0].Product.ID, ShouldEqual, 3)
+		So(*i.Items[0].Quantity, ShouldEqual, 2)
+		So(*i.Items[0].Taxes[0].Abbreviation, ShouldEqual, "HST")
+		So(i.Total.Decimal(), ShouldEqual, "30.22")
+	})
+
+	Convey("LIST all Invoices for a business", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedInvoicesJSON)
+		})
+
+		invoices, _, err := client.Invoices.List("1", nil)
+		i := []Invoice{*expectedInvoiceStruct}
+		So(err, ShouldEqual, nil)
+		So(invoices, ShouldResemble, i)
+	})
+
+	Convey("LIST all Invoices for a business with filters", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.URL.RawQuery, ShouldEqual, "customer_id=5&invoice_date_end=2013-12-31&invoice_date_start=2013-12-01&page=2&status=paid")
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedInvoicesJSON)
+		})
+
+		start := Date(time.Date(2013, time.December, 1, 0, 0, 0, 0, time.UTC))
+		end := Date(time.Date(2013, time.December, 31, 0, 0, 0, 0, time.UTC))
+		opts := &InvoiceListOptions{
+			Status:           InvoiceStatusPaid,
+			CustomerID:       5,
+			InvoiceDateStart: &start,
+			InvoiceDateEnd:   &end,
+			PageOptions:      PageOptions{Page: 2},
+		}
+		_, _, err := client.Invoices.List("1", opts)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("LIST all Invoices for a business invalid ID", t, func() {
+		_, resp, err := client.Invoices.List("%", nil)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("GET a specific Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedInvoiceJSON)
+		})
+
+		invoice, _, err := client.Invoices.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(invoice, ShouldResemble, expectedInvoiceStruct)
+	})
+
+	Convey("GET a specific Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Get("%", 1)
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("CREATE an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"customer":{"id":1},"items":[{"product":{"id":3},"quantity":2}]}`+"\n")
+			fmt.Fprint(w, expectedInvoiceJSON)
+		})
+
+		i := &Invoice{
+			Customer: &Customer{ID: 1},
+			Items: []InvoiceItem{
+				{Product: &Product{ID: Uint64(3)}, Quantity: Float64(2)},
+			},
+		}
+		invoice, _, err := client.Invoices.Create("1", i)
+		So(err, ShouldBeNil)
+		So(invoice, ShouldResemble, expectedInvoiceStruct)
+	})
+
+	Convey("CREATE an Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Create("%", &Invoice{})
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("REPLACE an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PUT")
+			fmt.Fprint(w, expectedInvoiceJSON)
+		})
+
+		invoice, _, err := client.Invoices.Replace("1", 1, &Invoice{})
+		So(err, ShouldBeNil)
+		So(invoice, ShouldResemble, expectedInvoiceStruct)
+	})
+
+	Convey("REPLACE an Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Replace("%", 1, &Invoice{})
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("UPDATE an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PATCH")
+			fmt.Fprint(w, expectedInvoiceJSON)
+		})
+
+		invoice, _, err := client.Invoices.Update("1", 1, &Invoice{})
+		So(err, ShouldEqual, nil)
+		So(invoice, ShouldResemble, expectedInvoiceStruct)
+	})
+
+	Convey("UPDATE an Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Update("%", 1, &Invoice{})
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("DELETE a specific Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "DELETE")
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		_, err := client.Invoices.Delete("1", 1)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("DELETE a specific Invoice with an invalid ID", t, func() {
+		resp, err := client.Invoices.Delete("%", 1)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("APPROVE an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/approve/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			fmt.Fprint(w, `{"id": 1, "status": "saved"}`)
+		})
+
+		invoice, _, err := client.Invoices.Approve("1", 1)
+		So(err, ShouldBeNil)
+		So(*invoice.Status, ShouldEqual, InvoiceStatusSaved)
+	})
+
+	Convey("APPROVE an Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Approve("%", 1)
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("SEND an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/send/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"to":["mike@hitmenforhire.com"],"cc_myself":true}`+"\n")
+			fmt.Fprint(w, `{"id": 1, "status": "sent"}`)
+		})
+
+		opts := &InvoiceSendOptions{To: []string{"mike@hitmenforhire.com"}, CCMyself: Bool(true)}
+		invoice, _, err := client.Invoices.Send("1", 1, opts)
+		So(err, ShouldBeNil)
+		So(*invoice.Status, ShouldEqual, InvoiceStatusSent)
+	})
+
+	Convey("SEND an Invoice without options", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/send/", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldBeBlank)
+			fmt.Fprint(w, `{"id": 1, "status": "sent"}`)
+		})
+
+		_, _, err := client.Invoices.Send("1", 1, nil)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("SEND an Invoice with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.Send("%", 1, nil)
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("MARK an Invoice as sent", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/mark-sent/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			fmt.Fprint(w, `{"id": 1, "status": "sent"}`)
+		})
+
+		invoice, _, err := client.Invoices.MarkSent("1", 1)
+		So(err, ShouldBeNil)
+		So(*invoice.Status, ShouldEqual, InvoiceStatusSent)
+	})
+
+	Convey("MARK an Invoice as sent with an invalid ID", t, func() {
+		invoice, resp, err := client.Invoices.MarkSent("%", 1)
+		checkInvalidURLError(invoice, resp, err)
+	})
+
+	Convey("RECORD a payment on an Invoice", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/invoices/1/payments/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"amount":30.22,"payment_date":"2013-12-20","payment_account":{"id":1}}`+"\n")
+			fmt.Fprint(w, expectedInvoicePaymentJSON)
+		})
+
+		date := Date(time.Date(2013, time.December, 20, 0, 0, 0, 0, time.UTC))
+		p := &InvoicePayment{Amount: Amount("30.22"), PaymentDate: &date, Account: &Account{ID: Int(1)}}
+		payment, _, err := client.Invoices.RecordPayment("1", 1, p)
+		So(err, ShouldBeNil)
+		So(payment, ShouldResemble, expectedInvoicePaymentStruct)
+	})
+
+	Convey("RECORD a payment on an Invoice with an invalid ID", t, func() {
+		payment, resp, err := client.Invoices.RecordPayment("%", 1, &InvoicePayment{})
+		checkInvalidURLError(payment, resp, err)
+	})
+
+	Convey("String method on Invoice", t, func() {
+		i := new(Invoice)
+		i.InvoiceNumber = String("42")
+		i.Status = String(InvoiceStatusPaid)
+		So(i.String(), ShouldEqual, "Invoice 42 (status=paid)")
+	})
+
+	Convey("String method on Tax", t, func() {
+		tax := new(Tax)
+		tax.Abbreviation = String("HST")
+		tax.Rate = Float64(13)
+		So(tax.String(), ShouldEqual, "HST (13%)")
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import "context"
+
+// pageFunc fetches a single page of a LIST endpoint.
+type pageFunc[T any] func(ctx context.Context, page PageOptions) ([]T, *Response, error)
+
+// Iterator walks every item returned by a paginated LIST endpoint. Pages are
+// fetched lazily, only once the items of the previous page have been
+// consumed, and iteration stops after the last page reported by the API.
+//
+// An Iterator is used like a bufio.Scanner:
+//
+//	it := client.Customers.ListIter(businessID, nil)
+//	for it.Next(ctx) {
+//		customer := it.Item()
+//		// Stop early by breaking out of the loop.
+//	}
+//	if err := it.Err(); err != nil {
+//		// Handle the error from the page that failed.
+//	}
+type Iterator[T any] struct {
+	fetch pageFunc[T]
+	page  PageOptions
+
+	items []T
+	index int
+	item  T
+
+	resp *Response
+	err  error
+	done bool
+}
+
+func newIterator[T any](page PageOptions, fetch pageFunc[T]) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, page: page}
+}
+
+// Next advances the iterator to the next item, fetching the next page if the
+// current one has been exhausted. It returns false when there are no more
+// items or when fetching a page failed; use Err to tell the two apart.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	for it.index >= len(it.items) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.nextPage(ctx)
+	}
+	it.item = it.items[it.index]
+	it.index++
+	return true
+}
+
+func (it *Iterator[T]) nextPage(ctx context.Context) {
+	items, resp, err := it.fetch(ctx, it.page)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.items, it.index, it.resp = items, 0, resp
+	if resp.NextPage == 0 || len(items) == 0 {
+		it.done = true
+		return
+	}
+	it.page.Page = resp.NextPage
+}
+
+// Item returns the item the iterator is currently positioned at.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Response returns the API response for the most recently fetched page.
+func (it *Iterator[T]) Response() *Response {
+	return it.resp
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// ListAll drains it and returns every item, stopping once max items have been
+// collected. A max of zero or less collects every item. If a page fails to
+// load, the items collected so far are returned along with the error.
+func ListAll[T any](ctx context.Context, it *Iterator[T], max int) ([]T, error) {
+	var all []T
+	for (max <= 0 || len(all) < max) && it.Next(ctx) {
+		all = append(all, it.Item())
+	}
+	return all, it.Err()
+}
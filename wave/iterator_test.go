@@ -0,0 +1,142 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// servePages registers a handler on path which serves pages of JSON objects
+// with sequential names, setting the Link and X-Total-Count headers the way
+// the Wave API does. It returns a pointer to the list of pages requested.
+func servePages(path string, pageCount, pageSize int) *[]int {
+	requested := new([]int)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		*requested = append(*requested, page)
+
+		if page < pageCount {
+			w.Header().Set("Link", fmt.Sprintf(`<%v%v?page=%d>; rel="next", <%v%v?page=%d>; rel="last"`,
+				server.URL, path, page+1, server.URL, path, pageCount))
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(pageCount*pageSize))
+
+		fmt.Fprint(w, "[")
+		for i := 0; i < pageSize; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"name": "%d"}`, (page-1)*pageSize+i+1)
+		}
+		fmt.Fprint(w, "]")
+	})
+	return requested
+}
+
+func TestIterator(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Iterating over every Customer", t, func() {
+		setUp()
+		defer tearDown()
+		requested := servePages("/businesses/1/customers/", 3, 2)
+
+		var names []string
+		it := client.Customers.ListIter("1", nil)
+		for it.Next(ctx) {
+			names = append(names, *it.Item().Name)
+		}
+		So(it.Err(), ShouldBeNil)
+		So(names, ShouldResemble, []string{"1", "2", "3", "4", "5", "6"})
+		So(*requested, ShouldResemble, []int{1, 2, 3})
+		So(it.Response().TotalCount, ShouldEqual, 6)
+	})
+
+	Convey("Iterating over Products should keep the other options", t, func() {
+		setUp()
+		defer tearDown()
+		var queries []string
+		mux.HandleFunc("/businesses/1/products/", func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%v/businesses/1/products/?page=2>; rel="next"`, server.URL))
+			}
+			fmt.Fprint(w, `[{"id": 1}]`)
+		})
+
+		it := client.Products.ListIter("1", &ProductListOptions{EmbedAccounts: true, PageOptions: PageOptions{PageSize: 1}})
+		products, err := ListAll(ctx, it, 0)
+		So(err, ShouldBeNil)
+		So(len(products), ShouldEqual, 2)
+		So(queries, ShouldResemble, []string{
+			"embed_accounts=true&page_size=1",
+			"embed_accounts=true&page=2&page_size=1",
+		})
+	})
+
+	Convey("Pages should only be fetched as they are needed", t, func() {
+		setUp()
+		defer tearDown()
+		requested := servePages("/businesses/", 5, 2)
+
+		it := client.Businesses.ListIter(nil)
+		for i := 0; i < 3; i++ {
+			So(it.Next(ctx), ShouldBeTrue)
+		}
+		So(*requested, ShouldResemble, []int{1, 2})
+	})
+
+	Convey("An error should stop the iteration", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "2" {
+				http.Error(w, `{"error": {"message": "Boom"}}`, http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%v/businesses/1/customers/?page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id": 1}, {"id": 2}]`)
+		})
+
+		it := client.Customers.ListIter("1", nil)
+		customers, err := ListAll(ctx, it, 0)
+		So(len(customers), ShouldEqual, 2)
+		So(err, ShouldNotBeNil)
+		So(err.(*ErrorResponse).Err.Message, ShouldEqual, "Boom")
+		So(it.Next(ctx), ShouldBeFalse)
+	})
+
+	Convey("ListAll should stop at the maximum number of items", t, func() {
+		setUp()
+		defer tearDown()
+		requested := servePages("/businesses/1/customers/", 10, 2)
+
+		customers, err := ListAll(ctx, client.Customers.ListIter("1", nil), 3)
+		So(err, ShouldBeNil)
+		So(len(customers), ShouldEqual, 3)
+		So(*requested, ShouldResemble, []int{1, 2})
+	})
+
+	Convey("A cancelled context should stop the iteration", t, func() {
+		setUp()
+		defer tearDown()
+		servePages("/businesses/1/customers/", 2, 2)
+
+		it := client.Customers.ListIter("1", nil)
+		So(it.Next(canceledContext()), ShouldBeFalse)
+		So(it.Err(), ShouldEqual, context.Canceled)
+	})
+}
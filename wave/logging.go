@@ -0,0 +1,297 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secrets and sensitive fields in logged requests.
+const Redacted = "REDACTED"
+
+// redactedHeaders are the headers whose values are always redacted.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// secretFields are the query parameters and JSON keys of tokens and
+// credentials, whose values are always redacted.
+var secretFields = []string{"access_token", "refresh_token", "client_secret"}
+
+// sensitiveTypes are the types with fields marked with a `wave:"sensitive"`
+// tag, such as the email of a Customer.
+var sensitiveTypes = []reflect.Type{
+	reflect.TypeOf(Customer{}),
+	reflect.TypeOf(User{}),
+	reflect.TypeOf(Vendor{}),
+}
+
+// redactedFields are the JSON names of the secret and sensitive fields.
+var redactedFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	for _, name := range secretFields {
+		fields[name] = true
+	}
+	seen := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Tag.Get("wave") == "sensitive" {
+				fields[strings.Split(f.Tag.Get("json"), ",")[0]] = true
+			}
+			walk(f.Type)
+		}
+	}
+	for _, t := range sensitiveTypes {
+		walk(t)
+	}
+	return fields
+}()
+
+// LogEntry describes a request sent by a client and its outcome. Secrets and
+// sensitive fields are redacted from its URL, headers and bodies.
+type LogEntry struct {
+	Method string
+	URL    string
+
+	// Status is the status code of the response, or 0 if the request failed
+	// with a transport error.
+	Status int
+
+	// Latency is how long the request took, including any retries and the
+	// decoding of the response.
+	Latency time.Duration
+
+	// TotalCount is the X-Total-Count header of a list response, or -1 if the
+	// response has none.
+	TotalCount int
+
+	// The headers and bodies are only set if LogOptions.Bodies is set.
+	RequestHeader  http.Header
+	RequestBody    string
+	ResponseHeader http.Header
+	ResponseBody   string
+
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+func (e *LogEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v", e.Method, e.URL)
+	if e.Status != 0 {
+		fmt.Fprintf(&b, " %d", e.Status)
+	}
+	fmt.Fprintf(&b, " %v", e.Latency.Round(time.Millisecond))
+	if e.TotalCount >= 0 {
+		fmt.Fprintf(&b, " total=%d", e.TotalCount)
+	}
+	if e.Err != nil && e.Status == 0 {
+		fmt.Fprintf(&b, " error=%q", e.Err.Error())
+	}
+	if e.RequestBody != "" {
+		fmt.Fprintf(&b, "\n> %v", e.RequestBody)
+	}
+	if e.ResponseBody != "" {
+		fmt.Fprintf(&b, "\n< %v", e.ResponseBody)
+	}
+	return b.String()
+}
+
+// Logger receives an entry for every request a client sends through the
+// Middleware returned by Logging.
+type Logger interface {
+	Log(entry *LogEntry)
+}
+
+// LoggerFunc adapts a func to a Logger.
+type LoggerFunc func(entry *LogEntry)
+
+// Log calls f(entry).
+func (f LoggerFunc) Log(entry *LogEntry) {
+	f(entry)
+}
+
+// NewLogger returns a Logger which prints entries to l, or to the standard
+// logger if l is nil.
+func NewLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return LoggerFunc(func(entry *LogEntry) {
+		l.Print(entry)
+	})
+}
+
+// LogOptions specifies what Logging logs.
+type LogOptions struct {
+	// Bodies logs the headers and bodies of requests and responses.
+	Bodies bool
+
+	// Redact lists the JSON names of further fields to redact, on top of
+	// tokens, credentials and the fields marked as sensitive, such as the
+	// email and phone numbers of a Customer.
+	Redact []string
+}
+
+// Logging returns a Middleware which logs every request to logger, with
+// tokens, credentials, Authorization headers and sensitive fields redacted.
+func Logging(logger Logger, opts *LogOptions) Middleware {
+	if opts == nil {
+		opts = new(LogOptions)
+	}
+	r := redactor{fields: redactedFields}
+	if len(opts.Redact) > 0 {
+		r.fields = make(map[string]bool)
+		for name := range redactedFields {
+			r.fields[name] = true
+		}
+		for _, name := range opts.Redact {
+			r.fields[name] = true
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(req *http.Request, v interface{}) (*Response, error) {
+			entry := &LogEntry{Method: req.Method, URL: r.url(req.URL), TotalCount: -1}
+			if opts.Bodies {
+				entry.RequestHeader = r.header(req.Header)
+				if body, err := readBody(req); err == nil {
+					entry.RequestBody = r.body(body)
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(req, v)
+			entry.Latency = time.Since(start)
+			entry.Err = err
+			if resp != nil && resp.Response != nil {
+				entry.Status = resp.StatusCode
+				if n, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+					entry.TotalCount = n
+				}
+				if opts.Bodies {
+					entry.ResponseHeader = r.header(resp.Header)
+					if resp.Body != nil {
+						data, _ := io.ReadAll(resp.Body)
+						resp.Body = io.NopCloser(bytes.NewReader(data))
+						entry.ResponseBody = r.body(data)
+					}
+				}
+			}
+			logger.Log(entry)
+			return resp, err
+		}
+	}
+}
+
+// readBody returns the body of req, putting it back so it can still be sent.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, err
+}
+
+// redactor redacts the values of fields in URLs and bodies.
+type redactor struct {
+	fields map[string]bool // the JSON names and query parameters to redact
+}
+
+func (r redactor) header(header http.Header) http.Header {
+	h := header.Clone()
+	for _, key := range redactedHeaders {
+		if _, ok := h[key]; ok {
+			h[key] = []string{Redacted}
+		}
+	}
+	return h
+}
+
+func (r redactor) url(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	if r.values(q) {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// values redacts v, reporting whether anything was redacted.
+func (r redactor) values(v url.Values) bool {
+	redacted := false
+	for key := range v {
+		if r.fields[key] {
+			v[key] = []string{Redacted}
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// body redacts a JSON body. Other bodies are replaced by their size, since
+// they cannot be redacted.
+func (r redactor) body(data []byte) string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return ""
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if dec.Decode(&v) == nil {
+		r.json(v)
+		if redacted, err := json.Marshal(v); err == nil {
+			return string(redacted)
+		}
+	}
+	return fmt.Sprintf("(%d bytes)", len(data))
+}
+
+func (r redactor) json(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.fields[key] && value != nil {
+				v[key] = Redacted
+			} else {
+				r.json(value)
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			r.json(value)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLogging(t *testing.T) {
+	Convey("Logging should log every request", t, func() {
+		setUp()
+		defer tearDown()
+		var entries []*LogEntry
+		logger := LoggerFunc(func(entry *LogEntry) {
+			entries = append(entries, entry)
+		})
+
+		Convey("with its status, latency and total count", func() {
+			mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+				So(r.URL.Query().Get("access_token"), ShouldEqual, "secret")
+				w.Header().Set("X-Total-Count", "42")
+				fmt.Fprint(w, `[{"id": 1, "email": "jane@example.com"}]`)
+			})
+			client.Use(SetHeader("Authorization", "Bearer secret"), Logging(logger, nil))
+			req, _ := client.NewRequest("GET", "businesses/1/customers/?access_token=secret&page=2", nil)
+			var customers []Customer
+			_, err := client.Do(req, &customers)
+			So(err, ShouldBeNil)
+			So(*customers[0].Email, ShouldEqual, "jane@example.com")
+
+			So(entries, ShouldHaveLength, 1)
+			entry := entries[0]
+			So(entry.Method, ShouldEqual, "GET")
+			So(entry.URL, ShouldEqual, server.URL+"/businesses/1/customers/?access_token=REDACTED&page=2")
+			So(entry.Status, ShouldEqual, http.StatusOK)
+			So(entry.TotalCount, ShouldEqual, 42)
+			So(entry.Latency, ShouldBeGreaterThan, 0)
+			So(entry.RequestHeader, ShouldBeNil)
+			So(entry.ResponseBody, ShouldBeEmpty)
+		})
+
+		Convey("with its bodies and headers, redacted, if asked to", func() {
+			mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				So(string(body), ShouldContainSubstring, "jane@example.com")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 1, "name": "Jane", "email": "jane@example.com", "shipping_details": {"phone_number": "555-0100"}, "currency": {"code": "CAD"}}`)
+			})
+			client.Use(SetHeader("Authorization", "Bearer secret"), Logging(logger, &LogOptions{Bodies: true, Redact: []string{"name"}}))
+			customer, _, err := client.Customers.Create("1", &Customer{Name: String("Jane"), Email: String("jane@example.com"), MobileNumber: String("555-0199")})
+			So(err, ShouldBeNil)
+			So(*customer.Email, ShouldEqual, "jane@example.com")
+
+			entry := entries[0]
+			So(entry.Status, ShouldEqual, http.StatusCreated)
+			So(entry.TotalCount, ShouldEqual, -1)
+			So(entry.RequestHeader.Get("Authorization"), ShouldEqual, Redacted)
+			So(entry.RequestHeader.Get("Content-Type"), ShouldEqual, "application/json")
+			So(entry.RequestBody, ShouldEqual, `{"email":"REDACTED","mobile_number":"REDACTED","name":"REDACTED"}`)
+			var body map[string]interface{}
+			So(json.Unmarshal([]byte(entry.ResponseBody), &body), ShouldBeNil)
+			So(body["email"], ShouldEqual, Redacted)
+			So(body["shipping_details"].(map[string]interface{})["phone_number"], ShouldEqual, Redacted)
+			So(body["currency"].(map[string]interface{})["code"], ShouldEqual, "CAD")
+		})
+
+		Convey("including API errors", func() {
+			mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error": {"message": "Invalid token"}, "access_token": "secret"}`)
+			})
+			client.Use(Logging(logger, &LogOptions{Bodies: true}))
+			_, _, err := client.Users.Get()
+			So(err, ShouldNotBeNil)
+			So(entries[0].Status, ShouldEqual, http.StatusUnauthorized)
+			So(entries[0].Err, ShouldEqual, err)
+			So(entries[0].ResponseBody, ShouldEqual, `{"access_token":"REDACTED","error":{"message":"Invalid token"}}`)
+		})
+
+		Convey("including transport errors", func() {
+			client.Use(Logging(logger, nil))
+			server.Close()
+			_, _, err := client.Users.Get()
+			So(err, ShouldNotBeNil)
+			So(entries[0].Status, ShouldEqual, 0)
+			So(entries[0].Err, ShouldEqual, err)
+		})
+	})
+
+	Convey("NewLogger should print entries to a log.Logger", t, func() {
+		var buf bytes.Buffer
+		entry := &LogEntry{Method: "GET", URL: "https://api.waveapps.com/user/", Status: 200, TotalCount: -1, ResponseBody: `{"id":"1"}`}
+		NewLogger(log.New(&buf, "", 0)).Log(entry)
+		So(buf.String(), ShouldEqual, "GET https://api.waveapps.com/user/ 200 0s\n< {\"id\":\"1\"}\n")
+	})
+
+	Convey("Fields marked as sensitive should be redacted", t, func() {
+		for _, name := range []string{"access_token", "email", "phone_number", "mobile_number", "fax_number", "toll_free_number", "date_of_birth"} {
+			So(redactedFields[name], ShouldBeTrue)
+		}
+		So(redactedFields["name"], ShouldBeFalse)
+	})
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorClass is the kind of error a request failed with, as recorded by
+// Instrument.
+type ErrorClass string
+
+// The classes of errors, one per sentinel error, and the classes of requests
+// which got no response.
+const (
+	ClassUnauthorized ErrorClass = "unauthorized"
+	ClassForbidden    ErrorClass = "forbidden"
+	ClassNotFound     ErrorClass = "not_found"
+	ClassValidation   ErrorClass = "validation"
+	ClassRateLimited  ErrorClass = "rate_limited"
+	ClassServer       ErrorClass = "server"
+	ClassClient       ErrorClass = "client"    // any other error response
+	ClassCanceled     ErrorClass = "canceled"  // the context was cancelled
+	ClassTimeout      ErrorClass = "timeout"   // the context's deadline expired
+	ClassTransport    ErrorClass = "transport" // any other error
+)
+
+// classes are the error classes of the sentinel errors.
+var classes = []struct {
+	err   error
+	class ErrorClass
+}{
+	{ErrUnauthorized, ClassUnauthorized},
+	{ErrForbidden, ClassForbidden},
+	{ErrNotFound, ClassNotFound},
+	{ErrValidation, ClassValidation},
+	{ErrRateLimited, ClassRateLimited},
+	{ErrServer, ClassServer},
+	{context.Canceled, ClassCanceled},
+	{context.DeadlineExceeded, ClassTimeout},
+}
+
+// Classify returns the class of err, or "" if it is nil.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	for _, c := range classes {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	var eresp *ErrorResponse
+	if errors.As(err, &eresp) {
+		return ClassClient
+	}
+	return ClassTransport
+}
+
+// Observation is a request sent by a client, as recorded by Instrument.
+type Observation struct {
+	// Operation is the service method which sent the request, such as
+	// "Customers.List", or "" for a request not sent by a service.
+	Operation string
+
+	// Status is the status code of the response, or 0 if there was none.
+	Status int
+
+	// Class is the class of the error of the request, or "" if it succeeded.
+	Class ErrorClass
+
+	// Duration is how long the request took, including any retries.
+	Duration time.Duration
+}
+
+// Collector records the requests observed by Instrument. It must be safe for
+// concurrent use.
+type Collector interface {
+	Observe(o Observation)
+}
+
+// Instrument returns a Middleware which records every request in collector.
+func Instrument(collector Collector) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request, v interface{}) (*Response, error) {
+			start := time.Now()
+			resp, err := next(req, v)
+			o := Observation{
+				Operation: Operation(req),
+				Class:     Classify(err),
+				Duration:  time.Since(start),
+			}
+			if resp != nil && resp.Response != nil {
+				o.Status = resp.StatusCode
+			}
+			collector.Observe(o)
+			return resp, err
+		}
+	}
+}
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+// buckets of Metrics.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics is a Collector which keeps request counts, error counts and latency
+// histograms per operation, and serves them over HTTP in the Prometheus text
+// format:
+//
+//	metrics := wave.NewMetrics(nil)
+//	client.Use(wave.Instrument(metrics))
+//	http.Handle("/metrics", metrics)
+type Metrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	requests   map[[2]string]uint64 // by operation and status code
+	errors     map[[2]string]uint64 // by operation and error class
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative, with +Inf last
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics returns an empty Metrics with the given latency histogram
+// buckets, in seconds, or DefaultBuckets if buckets is nil.
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:    buckets,
+		requests:   make(map[[2]string]uint64),
+		errors:     make(map[[2]string]uint64),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// Observe implements the Collector interface.
+func (m *Metrics) Observe(o Observation) {
+	op := o.Operation
+	if op == "" {
+		op = "other"
+	}
+	code := "none"
+	if o.Status != 0 {
+		code = strconv.Itoa(o.Status)
+	}
+	seconds := o.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{op, code}]++
+	if o.Class != "" {
+		m.errors[[2]string{op, string(o.Class)}]++
+	}
+	h := m.histograms[op]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.histograms[op] = h
+	}
+	i := sort.SearchFloat64s(m.buckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP gowave_requests_total Requests sent to the Wave API, by operation and status code.\n")
+	b.WriteString("# TYPE gowave_requests_total counter\n")
+	for _, key := range sortedKeys(m.requests) {
+		fmt.Fprintf(&b, "gowave_requests_total{operation=%v,code=%v} %d\n", label(key[0]), label(key[1]), m.requests[key])
+	}
+
+	b.WriteString("# HELP gowave_request_errors_total Requests to the Wave API which failed, by operation and class of error.\n")
+	b.WriteString("# TYPE gowave_request_errors_total counter\n")
+	for _, key := range sortedKeys(m.errors) {
+		fmt.Fprintf(&b, "gowave_request_errors_total{operation=%v,class=%v} %d\n", label(key[0]), label(key[1]), m.errors[key])
+	}
+
+	b.WriteString("# HELP gowave_request_duration_seconds Latency of requests to the Wave API, by operation.\n")
+	b.WriteString("# TYPE gowave_request_duration_seconds histogram\n")
+	ops := make([]string, 0, len(m.histograms))
+	for op := range m.histograms {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		h := m.histograms[op]
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(m.buckets) {
+				le = strconv.FormatFloat(m.buckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(&b, "gowave_request_duration_seconds_bucket{operation=%v,le=%v} %d\n", label(op), label(le), cumulative)
+		}
+		fmt.Fprintf(&b, "gowave_request_duration_seconds_sum{operation=%v} %v\n", label(op), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "gowave_request_duration_seconds_count{operation=%v} %d\n", label(op), h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// label quotes a label value, escaping it as the Prometheus text format
+// requires.
+func label(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+func sortedKeys(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
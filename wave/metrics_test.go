@@ -0,0 +1,135 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOperation(t *testing.T) {
+	Convey("Requests should record the service method which created them", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+		var ops []string
+		client.Use(func(next Handler) Handler {
+			return func(req *http.Request, v interface{}) (*Response, error) {
+				ops = append(ops, Operation(req))
+				return next(req, v)
+			}
+		})
+
+		client.Customers.List("1", nil)
+		client.Customers.GetContext(context.Background(), "1", 1)
+		client.Bills.RecordPayment("1", 1, &BillPayment{})
+		ListAll(context.Background(), client.Products.ListIter("1", nil), 0)
+		req, _ := client.NewRequest("GET", "user/", nil)
+		client.Do(req, nil)
+
+		So(ops, ShouldResemble, []string{"Customers.List", "Customers.Get", "Bills.RecordPayment", "Products.List", ""})
+	})
+}
+
+func TestClassify(t *testing.T) {
+	Convey("Errors should be classified by their kind", t, func() {
+		response := func(code int) error {
+			return &ErrorResponse{Response: &http.Response{StatusCode: code}}
+		}
+		So(Classify(nil), ShouldEqual, ErrorClass(""))
+		So(Classify(response(401)), ShouldEqual, ClassUnauthorized)
+		So(Classify(response(403)), ShouldEqual, ClassForbidden)
+		So(Classify(response(404)), ShouldEqual, ClassNotFound)
+		So(Classify(response(422)), ShouldEqual, ClassValidation)
+		So(Classify(response(429)), ShouldEqual, ClassRateLimited)
+		So(Classify(response(503)), ShouldEqual, ClassServer)
+		So(Classify(response(409)), ShouldEqual, ClassClient)
+		So(Classify(context.Canceled), ShouldEqual, ClassCanceled)
+		So(Classify(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)), ShouldEqual, ClassTimeout)
+		So(Classify(errors.New("connection refused")), ShouldEqual, ClassTransport)
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	Convey("Metrics should be written in the Prometheus text format", t, func() {
+		metrics := NewMetrics([]float64{1, 0.1})
+		metrics.Observe(Observation{Operation: "Customers.List", Status: 200, Duration: 50 * time.Millisecond})
+		metrics.Observe(Observation{Operation: "Customers.List", Status: 200, Duration: 100 * time.Millisecond})
+		metrics.Observe(Observation{Operation: "Customers.List", Status: 503, Class: ClassServer, Duration: 2 * time.Second})
+		metrics.Observe(Observation{Class: ClassTransport, Duration: 500 * time.Millisecond})
+
+		rec := httptest.NewRecorder()
+		metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		So(rec.Header().Get("Content-Type"), ShouldStartWith, "text/plain; version=0.0.4")
+		So(rec.Body.String(), ShouldEqual, strings.Join([]string{
+			`# HELP gowave_requests_total Requests sent to the Wave API, by operation and status code.`,
+			`# TYPE gowave_requests_total counter`,
+			`gowave_requests_total{operation="Customers.List",code="200"} 2`,
+			`gowave_requests_total{operation="Customers.List",code="503"} 1`,
+			`gowave_requests_total{operation="other",code="none"} 1`,
+			`# HELP gowave_request_errors_total Requests to the Wave API which failed, by operation and class of error.`,
+			`# TYPE gowave_request_errors_total counter`,
+			`gowave_request_errors_total{operation="Customers.List",class="server"} 1`,
+			`gowave_request_errors_total{operation="other",class="transport"} 1`,
+			`# HELP gowave_request_duration_seconds Latency of requests to the Wave API, by operation.`,
+			`# TYPE gowave_request_duration_seconds histogram`,
+			`gowave_request_duration_seconds_bucket{operation="Customers.List",le="0.1"} 2`,
+			`gowave_request_duration_seconds_bucket{operation="Customers.List",le="1"} 2`,
+			`gowave_request_duration_seconds_bucket{operation="Customers.List",le="+Inf"} 3`,
+			`gowave_request_duration_seconds_sum{operation="Customers.List"} 2.15`,
+			`gowave_request_duration_seconds_count{operation="Customers.List"} 3`,
+			`gowave_request_duration_seconds_bucket{operation="other",le="0.1"} 0`,
+			`gowave_request_duration_seconds_bucket{operation="other",le="1"} 1`,
+			`gowave_request_duration_seconds_bucket{operation="other",le="+Inf"} 1`,
+			`gowave_request_duration_seconds_sum{operation="other"} 0.5`,
+			`gowave_request_duration_seconds_count{operation="other"} 1`,
+			``,
+		}, "\n"))
+	})
+
+	Convey("Label values should be escaped", t, func() {
+		So(label("a\"b\\c\nd"), ShouldEqual, `"a\"b\\c\nd"`)
+	})
+
+	Convey("Instrument should record every request of a client", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/businesses/1/customers/1/", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id": 1}`)
+		})
+		mux.HandleFunc("/businesses/1/customers/2/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		metrics := NewMetrics(nil)
+		client.Use(Instrument(metrics))
+
+		client.Customers.Get("1", 1)
+		client.Customers.Get("1", 2)
+
+		srv := httptest.NewServer(metrics)
+		defer srv.Close()
+		resp, err := http.Get(srv.URL)
+		So(err, ShouldBeNil)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		So(string(body), ShouldContainSubstring, `gowave_requests_total{operation="Customers.Get",code="200"} 1`)
+		So(string(body), ShouldContainSubstring, `gowave_requests_total{operation="Customers.Get",code="404"} 1`)
+		So(string(body), ShouldContainSubstring, `gowave_request_errors_total{operation="Customers.Get",class="not_found"} 1`)
+		So(string(body), ShouldContainSubstring, `gowave_request_duration_seconds_count{operation="Customers.Get"} 2`)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import "net/http"
+
+// Handler sends an API request and decodes the response into v, as Do does.
+type Handler func(req *http.Request, v interface{}) (*Response, error)
+
+// Middleware wraps the Handler which sends a request, and is run by Do for
+// every request the client sends. Before calling next it can change the
+// request, such as to add a header or a query parameter; after it, it can
+// inspect the Response and v, which then holds the decoded response, or
+// change the error. It can also return without calling next, to answer the
+// request itself.
+//
+// A Middleware which replaces the body of a request should also set its
+// GetBody, so that the request can be retried.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client's Middleware.
+func (c *Client) Use(middleware ...Middleware) {
+	c.Middleware = append(c.Middleware, middleware...)
+}
+
+// SetHeader returns a Middleware which sets the header key to value on every
+// request, such as a tenant header.
+func SetHeader(key, value string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request, v interface{}) (*Response, error) {
+			req.Header.Set(key, value)
+			return next(req, v)
+		}
+	}
+}
+
+// handler returns the client's Middleware wrapped around h, the first
+// Middleware outermost.
+func (c *Client) handler(h Handler) Handler {
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		h = c.Middleware[i](h)
+	}
+	return h
+}
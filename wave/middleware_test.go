@@ -0,0 +1,120 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMiddleware(t *testing.T) {
+	Convey("Middleware should run around every request, the first outermost", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/businesses/1/customers/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Header.Get("X-Tenant"), ShouldEqual, "acme")
+			So(r.URL.Query().Get("audit"), ShouldEqual, "1")
+			fmt.Fprint(w, `{"id": 1, "name": "Jane"}`)
+		})
+
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next Handler) Handler {
+				return func(req *http.Request, v interface{}) (*Response, error) {
+					calls = append(calls, name+" before")
+					resp, err := next(req, v)
+					calls = append(calls, name+" after")
+					return resp, err
+				}
+			}
+		}
+		client.Use(trace("outer"), SetHeader("X-Tenant", "acme"), trace("inner"))
+		client.Use(func(next Handler) Handler {
+			return func(req *http.Request, v interface{}) (*Response, error) {
+				q := req.URL.Query()
+				q.Set("audit", "1")
+				req.URL.RawQuery = q.Encode()
+				resp, err := next(req, v)
+				// The response has been decoded into v.
+				So(*v.(*Customer).Name, ShouldEqual, "Jane")
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				return resp, err
+			}
+		})
+
+		customer, _, err := client.Customers.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(customer.ID, ShouldEqual, 1)
+		So(calls, ShouldResemble, []string{"outer before", "inner before", "inner after", "outer after"})
+	})
+
+	Convey("Middleware should be able to answer a request itself", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the request should not be sent")
+		})
+		client.Use(func(next Handler) Handler {
+			return func(req *http.Request, v interface{}) (*Response, error) {
+				v.(*User).FirstName = String("Cached")
+				return &Response{Response: &http.Response{StatusCode: http.StatusOK, Request: req}}, nil
+			}
+		})
+
+		user, resp, err := client.Users.Get()
+		So(err, ShouldBeNil)
+		So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		So(*user.FirstName, ShouldEqual, "Cached")
+	})
+
+	Convey("Middleware should see API errors and be able to replace them", t, func() {
+		setUp()
+		defer tearDown()
+		mux.HandleFunc("/businesses/1/customers/1/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		errAudit := errors.New("audit: customer not found")
+		client.Use(func(next Handler) Handler {
+			return func(req *http.Request, v interface{}) (*Response, error) {
+				resp, err := next(req, v)
+				if errors.Is(err, ErrNotFound) {
+					return resp, fmt.Errorf("%w: %v", errAudit, err)
+				}
+				return resp, err
+			}
+		})
+
+		_, resp, err := client.Customers.Get("1", 1)
+		So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		So(errors.Is(err, errAudit), ShouldBeTrue)
+		So(errors.Is(err, ErrNotFound), ShouldBeFalse)
+	})
+
+	Convey("Middleware should run once per call, around any retries", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+		calls := failFirst("/businesses/1/customers/1/", 2, http.StatusServiceUnavailable, `{"id": 1}`)
+		runs := 0
+		client.Use(func(next Handler) Handler {
+			return func(req *http.Request, v interface{}) (*Response, error) {
+				runs++
+				return next(req, v)
+			}
+		})
+
+		_, _, err := client.Customers.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(*calls, ShouldEqual, 3)
+		So(runs, ShouldEqual, 1)
+	})
+}
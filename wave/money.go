@@ -0,0 +1,412 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact decimal amount of a currency. The currency is identified
+// by its ISO 4217 code, as found in Currency.Code, and may be empty when it is
+// not known. Amounts decoded as part of an Invoice or a Bill take the currency
+// of the invoice or bill; other amounts decoded from a JSON response, such as
+// product prices and payments, have none until it is set with WithCurrency.
+//
+// Money is a value type: the zero value is an amount of 0 with no currency,
+// and methods return new values rather than modifying their receiver.
+// Arithmetic rounds its result to the minor units of the currency (cents for
+// USD, none for JPY, and two places without a currency), rounding halves away
+// from zero. Arithmetic whose rounded result does not fit in 64 bits of minor
+// units returns ErrAmountOutOfRange.
+//
+// Money marshals to and from a bare JSON number, keeping the digits exactly as
+// they were sent: 13.370 decodes and encodes back to 13.370.
+type Money struct {
+	// coef is the amount scaled by 10^scale.
+	coef     int64
+	scale    int32
+	currency string
+}
+
+// ErrAmountOutOfRange is returned by Money arithmetic whose result, rounded to
+// the minor units of its currency, does not fit in 64 bits. The range is
+// symmetric, so that every amount can be negated: math.MinInt64 is out of it.
+var ErrAmountOutOfRange = errors.New("wave: Money amount out of range")
+
+// MixedCurrencyError is returned by Money arithmetic when the operands are
+// amounts of different currencies.
+type MixedCurrencyError struct {
+	A, B string
+}
+
+func (e *MixedCurrencyError) Error() string {
+	return fmt.Sprintf("cannot combine amounts in %v and %v", e.A, e.B)
+}
+
+// ParseMoney returns the amount of currency represented by the decimal string
+// amount, such as "13.37", "-0.5" or "1e3".
+func ParseMoney(amount, currency string) (Money, error) {
+	coef, scale, err := parseDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{coef: coef, scale: scale, currency: currency}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics if amount cannot be parsed.
+func MustParseMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMoney returns an amount of currency given in its minor units, so that
+// NewMoney(1337, "USD") is 13.37 USD and NewMoney(1337, "JPY") is 1337 JPY.
+// It panics if minor is math.MinInt64, which is out of range.
+func NewMoney(minor int64, currency string) Money {
+	if minor == math.MinInt64 {
+		panic(ErrAmountOutOfRange)
+	}
+	return Money{coef: minor, scale: int32(minorUnits(currency)), currency: currency}
+}
+
+// MoneyFromFloat converts a float64 amount, as previously used for monetary
+// fields, to Money. It uses the shortest decimal that represents f, so
+// MoneyFromFloat(13.37, "USD") is exactly 13.37 USD.
+func MoneyFromFloat(f float64, currency string) Money {
+	return MustParseMoney(strconv.FormatFloat(f, 'f', -1, 64), currency)
+}
+
+// Currency returns the ISO 4217 code of the amount's currency, or "" if it is
+// not known.
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// WithCurrency returns the same amount in the currency with the given code.
+// It does not convert between currencies.
+func (m Money) WithCurrency(code string) Money {
+	m.currency = code
+	return m
+}
+
+// Decimal returns the amount as a decimal string without the currency, such
+// as "-13.37".
+func (m Money) Decimal() string {
+	digits := strconv.FormatInt(m.coef, 10)
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	if m.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(m.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(m.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.currency
+}
+
+// Float64 returns the nearest float64 to the amount. It is meant for display
+// and interoperability only; use Money for arithmetic.
+func (m Money) Float64() float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// MinorUnits returns the amount rounded to the minor units of its currency,
+// such as cents. It returns ErrAmountOutOfRange if they do not fit in 64 bits.
+func (m Money) MinorUnits() (int64, error) {
+	r, err := m.Round()
+	return r.coef, err
+}
+
+// Sign returns -1, 0 or +1 depending on whether the amount is negative, zero
+// or positive.
+func (m Money) Sign() int {
+	switch {
+	case m.coef < 0:
+		return -1
+	case m.coef > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.coef == 0
+}
+
+// Cmp compares the amounts of m and o, ignoring their currencies, and returns
+// -1, 0 or +1 if m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) int {
+	scale := largerScale(m.scale, o.scale)
+	return rescale(m.big(), m.scale, scale).Cmp(rescale(o.big(), o.scale, scale))
+}
+
+// Equal reports whether m and o are the same amount of the same currency.
+// Trailing zeros do not matter: 13.370 USD equals 13.37 USD.
+func (m Money) Equal(o Money) bool {
+	return m.currency == o.currency && m.Cmp(o) == 0
+}
+
+// Round returns the amount rounded to the minor units of its currency. It
+// returns ErrAmountOutOfRange if the amount does not fit in 64 bits of minor
+// units, which can only happen to amounts with fewer decimal places than
+// their currency, such as 1e18 decoded from JSON.
+func (m Money) Round() (Money, error) {
+	return round(m.big(), m.scale, m.currency)
+}
+
+// Neg returns the amount with its sign flipped.
+func (m Money) Neg() Money {
+	return Money{coef: -m.coef, scale: m.scale, currency: m.currency}
+}
+
+// Add returns m+o rounded to the minor units of their currency. An amount
+// without a currency takes on the currency of the other operand; amounts in
+// two different currencies return a *MixedCurrencyError.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := combineCurrencies(m.currency, o.currency)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := largerScale(m.scale, o.scale)
+	sum := new(big.Int).Add(rescale(m.big(), m.scale, scale), rescale(o.big(), o.scale, scale))
+	return round(sum, scale, currency)
+}
+
+// Sub returns m-o rounded to the minor units of their currency, following the
+// same currency rules as Add.
+func (m Money) Sub(o Money) (Money, error) {
+	currency, err := combineCurrencies(m.currency, o.currency)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := largerScale(m.scale, o.scale)
+	diff := new(big.Int).Sub(rescale(m.big(), m.scale, scale), rescale(o.big(), o.scale, scale))
+	return round(diff, scale, currency)
+}
+
+// Mul returns the amount multiplied by factor, such as an item quantity or a
+// tax rate, rounded to the minor units of its currency. The factor is taken
+// as the shortest decimal that represents it, so Mul(0.13) multiplies by
+// exactly 0.13. The product is rounded before it is checked against the range
+// of Money, so only results which are themselves too large fail.
+func (m Money) Mul(factor float64) (Money, error) {
+	f, err := ParseMoney(strconv.FormatFloat(factor, 'f', -1, 64), "")
+	if err != nil {
+		return Money{}, fmt.Errorf("wave: cannot multiply by %v: %w", factor, err)
+	}
+	product := new(big.Int).Mul(m.big(), f.big())
+	return round(product, m.scale+f.scale, m.currency)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The amount is encoded as a bare JSON number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The amount may be a JSON number or a string holding one. The currency of m
+// is left as it is.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	coef, scale, err := parseDecimal(s)
+	if err != nil {
+		return err
+	}
+	m.coef, m.scale = coef, scale
+	return nil
+}
+
+// sum is an exact total of amounts, whatever their number of decimal places,
+// which is only rounded to the minor units of its currency at the end.
+type sum struct {
+	coef     *big.Int
+	scale    int32
+	currency string
+}
+
+func newSum(currency string) *sum {
+	return &sum{coef: new(big.Int), currency: currency}
+}
+
+// add adds m to the total, following the same currency rules as Money.Add.
+func (s *sum) add(m Money) error {
+	currency, err := combineCurrencies(s.currency, m.currency)
+	if err != nil {
+		return err
+	}
+	scale := largerScale(s.scale, m.scale)
+	s.coef = new(big.Int).Add(rescale(s.coef, s.scale, scale), rescale(m.big(), m.scale, scale))
+	s.scale, s.currency = scale, currency
+	return nil
+}
+
+// round returns the total rounded to the minor units of its currency.
+func (s *sum) round() (Money, error) {
+	return round(s.coef, s.scale, s.currency)
+}
+
+// withCurrency sets the currency of the given amounts, which may be nil, to
+// that of c if it is known. Resources which have a Currency use it to give
+// their amounts the currency when they are decoded.
+func withCurrency(c *Currency, amounts ...*Money) {
+	if c == nil || c.Code == nil {
+		return
+	}
+	for _, m := range amounts {
+		if m != nil {
+			m.currency = *c.Code
+		}
+	}
+}
+
+// minorUnits returns the number of digits after the decimal point in amounts
+// of the currency with the given ISO 4217 code, as given by the bundled
+// reference data. Unknown codes, the empty code and currencies without minor
+// units use 2.
+func minorUnits(code string) int {
+	if c := BundledReference().Currency(code); c != nil && c.MinorUnits != nil {
+		return *c.MinorUnits
+	}
+	return 2
+}
+
+func combineCurrencies(a, b string) (string, error) {
+	switch {
+	case a == "":
+		return b, nil
+	case b == "" || a == b:
+		return a, nil
+	}
+	return "", &MixedCurrencyError{A: a, B: b}
+}
+
+// maxScale is the largest number of decimal places a parsed amount may have.
+// An int64 coefficient holds at most 19 digits, so anything finer is noise.
+const maxScale = 19
+
+// parseDecimal parses a decimal string into a coefficient and the number of
+// digits after the decimal point. Exponents are folded into the coefficient.
+func parseDecimal(s string) (coef int64, scale int32, err error) {
+	invalid := fmt.Errorf("invalid amount %q", s)
+
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return 0, 0, invalid
+		}
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, frac := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		whole, frac = mantissa[:i], mantissa[i+1:]
+	}
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, 0, invalid
+	}
+
+	c, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return 0, 0, invalid
+	}
+	outOfRange := fmt.Errorf("amount %q is out of range", s)
+	exp = int64(len(frac)) - exp
+	if exp < 0 {
+		if exp < -maxScale {
+			return 0, 0, outOfRange
+		}
+		c.Mul(c, pow10(int32(-exp)))
+		exp = 0
+	}
+	if !inRange(c) || exp > maxScale {
+		return 0, 0, outOfRange
+	}
+	return c.Int64(), int32(exp), nil
+}
+
+func (m Money) big() *big.Int {
+	return big.NewInt(m.coef)
+}
+
+// round rounds coef, scaled by 10^scale, to the minor units of currency while
+// it is still a big.Int, and only then checks that it fits in a Money.
+func round(coef *big.Int, scale int32, currency string) (Money, error) {
+	places := int32(minorUnits(currency))
+	coef = rescale(coef, scale, places)
+	if !inRange(coef) {
+		return Money{}, ErrAmountOutOfRange
+	}
+	return Money{coef: coef.Int64(), scale: places, currency: currency}, nil
+}
+
+// inRange reports whether coef fits in a Money: an int64 other than
+// math.MinInt64, so that it can be negated.
+func inRange(coef *big.Int) bool {
+	return coef.IsInt64() && coef.Int64() != math.MinInt64
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func largerScale(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// rescale changes the scale of coef from one number of decimal places to
+// another, rounding halves away from zero when places are dropped.
+func rescale(coef *big.Int, from, to int32) *big.Int {
+	switch {
+	case to > from:
+		return new(big.Int).Mul(coef, pow10(to-from))
+	case to < from:
+		d := pow10(from - to)
+		q, r := new(big.Int).QuoRem(coef, d, new(big.Int))
+		if r.Abs(r).Lsh(r, 1).Cmp(d) >= 0 {
+			q.Add(q, big.NewInt(int64(coef.Sign())))
+		}
+		return q
+	}
+	return coef
+}
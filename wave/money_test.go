@@ -0,0 +1,240 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMoney(t *testing.T) {
+	Convey("Parsing Money", t, func() {
+		Convey("Should keep the digits of a decimal amount", func() {
+			for _, s := range []string{"0", "13.37", "13.370", "-0.5", "0.001", "-1234567.89"} {
+				m, err := ParseMoney(s, "USD")
+				So(err, ShouldBeNil)
+				So(m.Decimal(), ShouldEqual, s)
+				So(m.Currency(), ShouldEqual, "USD")
+			}
+		})
+
+		Convey("Should fold exponents into the amount", func() {
+			So(MustParseMoney("1e3", "").Decimal(), ShouldEqual, "1000")
+			So(MustParseMoney("1.5E-2", "").Decimal(), ShouldEqual, "0.015")
+			So(MustParseMoney("+2", "").Decimal(), ShouldEqual, "2")
+		})
+
+		Convey("Should reject anything else", func() {
+			for _, s := range []string{"", "-", ".", "1.2.3", "12a", "1e", "NaN", "$5", "1 000"} {
+				_, err := ParseMoney(s, "")
+				So(err, ShouldNotBeNil)
+			}
+			So(func() { MustParseMoney("abc", "") }, ShouldPanic)
+		})
+
+		Convey("Should reject amounts which do not fit", func() {
+			_, err := ParseMoney("99999999999999999999", "")
+			So(err, ShouldNotBeNil)
+			_, err = ParseMoney("1e100", "")
+			So(err, ShouldNotBeNil)
+			_, err = ParseMoney("1e-100", "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Constructing Money", t, func() {
+		So(NewMoney(1337, "USD").String(), ShouldEqual, "13.37 USD")
+		So(NewMoney(1337, "JPY").String(), ShouldEqual, "1337 JPY")
+		So(NewMoney(1337, "KWD").String(), ShouldEqual, "1.337 KWD")
+		So(NewMoney(-5, "").String(), ShouldEqual, "-0.05")
+
+		So(MoneyFromFloat(13.37, "USD").Decimal(), ShouldEqual, "13.37")
+		So(MoneyFromFloat(0.1, "").Decimal(), ShouldEqual, "0.1")
+		So(MoneyFromFloat(-42, "").Decimal(), ShouldEqual, "-42")
+
+		So(MustParseMoney("1", "").WithCurrency("CAD").String(), ShouldEqual, "1 CAD")
+		So(MustParseMoney("-13.37", "").Float64(), ShouldEqual, -13.37)
+	})
+
+	Convey("Rounding Money to minor units", t, func() {
+		rounded := func(amount, currency string) string {
+			r, err := MustParseMoney(amount, currency).Round()
+			So(err, ShouldBeNil)
+			return r.Decimal()
+		}
+		So(rounded("13.375", "USD"), ShouldEqual, "13.38")
+		So(rounded("13.374", "USD"), ShouldEqual, "13.37")
+		So(rounded("-13.375", "USD"), ShouldEqual, "-13.38")
+		So(rounded("13.4", "USD"), ShouldEqual, "13.40")
+		So(rounded("1337.5", "JPY"), ShouldEqual, "1338")
+		So(rounded("1.2345", "BHD"), ShouldEqual, "1.235")
+		So(rounded("0.005", ""), ShouldEqual, "0.01")
+
+		minor, err := MustParseMoney("13.375", "USD").MinorUnits()
+		So(err, ShouldBeNil)
+		So(minor, ShouldEqual, 1338)
+
+		Convey("Should return an error rather than overflow", func() {
+			p := new(Product)
+			So(json.Unmarshal([]byte(`{"price": 1e18}`), p), ShouldBeNil)
+			_, err := p.Price.MinorUnits()
+			So(err, ShouldEqual, ErrAmountOutOfRange)
+			_, err = p.Price.Round()
+			So(err, ShouldEqual, ErrAmountOutOfRange)
+			r, err := p.Price.WithCurrency("JPY").Round()
+			So(err, ShouldBeNil)
+			So(r.Decimal(), ShouldEqual, "1000000000000000000")
+		})
+	})
+
+	Convey("Comparing Money", t, func() {
+		a := MustParseMoney("13.370", "USD")
+		b := MustParseMoney("13.37", "USD")
+		So(a.Cmp(b), ShouldEqual, 0)
+		So(a.Equal(b), ShouldBeTrue)
+		So(a.Equal(b.WithCurrency("CAD")), ShouldBeFalse)
+		So(a.Cmp(MustParseMoney("13.38", "")), ShouldEqual, -1)
+		So(a.Cmp(MustParseMoney("-20", "")), ShouldEqual, 1)
+
+		So(a.Sign(), ShouldEqual, 1)
+		So(a.Neg().Sign(), ShouldEqual, -1)
+		So(MustParseMoney("-9223372036854775807", "").Neg().Decimal(), ShouldEqual, "9223372036854775807")
+		_, err := ParseMoney("-9223372036854775808", "")
+		So(err, ShouldNotBeNil)
+		So(func() { NewMoney(math.MinInt64, "USD") }, ShouldPanicWith, ErrAmountOutOfRange)
+		So(Money{}.Sign(), ShouldEqual, 0)
+		So(Money{}.IsZero(), ShouldBeTrue)
+		So(MustParseMoney("0.000", "").IsZero(), ShouldBeTrue)
+	})
+
+	Convey("Adding and subtracting Money", t, func() {
+		Convey("Should be exact", func() {
+			sum, err := MustParseMoney("0.1", "USD").Add(MustParseMoney("0.2", "USD"))
+			So(err, ShouldBeNil)
+			So(sum.String(), ShouldEqual, "0.30 USD")
+
+			diff, err := MustParseMoney("10", "USD").Sub(MustParseMoney("0.01", "USD"))
+			So(err, ShouldBeNil)
+			So(diff.String(), ShouldEqual, "9.99 USD")
+		})
+
+		Convey("Should round to the minor units of the currency", func() {
+			sum, _ := MustParseMoney("0.004", "USD").Add(MustParseMoney("0.004", "USD"))
+			So(sum.Decimal(), ShouldEqual, "0.01")
+
+			sum, _ = MustParseMoney("100.4", "JPY").Add(MustParseMoney("0.4", "JPY"))
+			So(sum.Decimal(), ShouldEqual, "101")
+		})
+
+		Convey("Should take the currency of the other amount when one is missing", func() {
+			sum, err := MustParseMoney("1", "").Add(MustParseMoney("2", "EUR"))
+			So(err, ShouldBeNil)
+			So(sum.Currency(), ShouldEqual, "EUR")
+		})
+
+		Convey("Should refuse to mix currencies", func() {
+			_, err := MustParseMoney("1", "USD").Add(MustParseMoney("1", "CAD"))
+			e, ok := err.(*MixedCurrencyError)
+			So(ok, ShouldBeTrue)
+			So(e.A, ShouldEqual, "USD")
+			So(e.B, ShouldEqual, "CAD")
+			So(err.Error(), ShouldEqual, "cannot combine amounts in USD and CAD")
+		})
+	})
+
+	Convey("Multiplying Money", t, func() {
+		mul := func(amount, currency string, factor float64) string {
+			product, err := MustParseMoney(amount, currency).Mul(factor)
+			So(err, ShouldBeNil)
+			return product.String()
+		}
+		So(mul("13.37", "USD", 2), ShouldEqual, "26.74 USD")
+		So(mul("26.74", "USD", 0.13), ShouldEqual, "3.48 USD")
+		So(mul("19.99", "USD", 1.5), ShouldEqual, "29.99 USD")
+		So(mul("1000", "JPY", 0.08), ShouldEqual, "80 JPY")
+
+		Convey("Should round products which only overflow at full precision", func() {
+			So(mul("1000.00", "USD", 1.0/3), ShouldEqual, "333.33 USD")
+			So(mul("100000.00", "USD", 0.0725/12), ShouldEqual, "604.17 USD")
+		})
+
+		Convey("Should return an error for results out of range", func() {
+			_, err := MustParseMoney("9000000000000000", "").Mul(10000)
+			So(err, ShouldEqual, ErrAmountOutOfRange)
+			_, err = MustParseMoney("1", "USD").Mul(math.NaN())
+			So(err, ShouldNotBeNil)
+			_, err = MustParseMoney("90000000000000000", "USD").Add(MustParseMoney("90000000000000000", "USD"))
+			So(err, ShouldEqual, ErrAmountOutOfRange)
+		})
+	})
+
+	Convey("Money JSON", t, func() {
+		Convey("Should round-trip the digits exactly", func() {
+			for _, s := range []string{"0", "13.37", "13.370", "-0.5", "1234567.891"} {
+				m := new(Money)
+				So(json.Unmarshal([]byte(s), m), ShouldBeNil)
+				b, err := json.Marshal(m)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, s)
+			}
+		})
+
+		Convey("Should accept amounts sent as strings", func() {
+			m := new(Money)
+			So(json.Unmarshal([]byte(`"13.37"`), m), ShouldBeNil)
+			So(m.Decimal(), ShouldEqual, "13.37")
+		})
+
+		Convey("Should keep the currency of the value decoded into", func() {
+			m := MustParseMoney("1", "JPY")
+			So(json.Unmarshal([]byte(`500`), &m), ShouldBeNil)
+			So(m.String(), ShouldEqual, "500 JPY")
+		})
+
+		Convey("Should take the currency of the invoice or bill they are in", func() {
+			invoice := new(Invoice)
+			So(json.Unmarshal([]byte(`{
+				"currency": {"code": "JPY"},
+				"items": [{"price": 1337.5, "amount": 1337.5}],
+				"total": 1337.5
+			}`), invoice), ShouldBeNil)
+			So(invoice.Total.String(), ShouldEqual, "1337.5 JPY")
+			minor, err := invoice.Total.MinorUnits()
+			So(err, ShouldBeNil)
+			So(minor, ShouldEqual, 1338)
+			So(invoice.Items[0].Price.Currency(), ShouldEqual, "JPY")
+
+			bill := new(Bill)
+			So(json.Unmarshal([]byte(`{"currency": {"code": "KWD"}, "items": [{"amount": 1.2345}], "amount_due": 1.2345}`), bill), ShouldBeNil)
+			r, err := bill.AmountDue.Round()
+			So(err, ShouldBeNil)
+			So(r.String(), ShouldEqual, "1.235 KWD")
+			So(bill.Items[0].Amount.Currency(), ShouldEqual, "KWD")
+		})
+
+		Convey("Should leave nil pointers for null and missing amounts", func() {
+			p := new(Product)
+			So(json.Unmarshal([]byte(`{"price": null}`), p), ShouldBeNil)
+			So(p.Price, ShouldBeNil)
+		})
+
+		Convey("Should reject values which are not numbers", func() {
+			m := new(Money)
+			So(json.Unmarshal([]byte(`"abc"`), m), ShouldNotBeNil)
+			So(json.Unmarshal([]byte(`true`), m), ShouldNotBeNil)
+		})
+
+		Convey("Should encode as a bare number in a resource", func() {
+			b, err := json.Marshal(&Product{Price: Amount("42.340")})
+			So(err, ShouldBeNil)
+			So(string(b), ShouldEqual, `{"price":42.340}`)
+		})
+	})
+}
@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // ProductsService handles communication with the product related methods of the Wave API.
 //
@@ -20,7 +23,7 @@ type Product struct {
 	ID             *uint64   `json:"id,omitempty"`
 	URL            *string   `json:"url,omitempty"`
 	Name           *string   `json:"name,omitempty"`
-	Price          *float64  `json:"price,omitempty"`
+	Price          *Money    `json:"price,omitempty"`
 	Description    *string   `json:"description,omitempty"`
 	IsSold         *bool     `json:"is_sold,omitempty"`
 	IsBought       *bool     `json:"is_bought,omitempty"`
@@ -31,7 +34,7 @@ type Product struct {
 }
 
 func (p Product) String() string {
-	return *p.Name
+	return deref(p.Name)
 }
 
 // ProductListOptions specifies the optional parameters to LIST endpoint.
@@ -54,12 +57,18 @@ type ProductGetOptions struct {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/products.html#get--businesses-{business_id}-products-
 func (service *ProductsService) List(businessID string, opts *ProductListOptions) ([]Product, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *ProductsService) ListContext(ctx context.Context, businessID string, opts *ProductListOptions) ([]Product, *Response, error) {
+	ctx = withOperation(ctx, "Products.List")
 	url := fmt.Sprintf("businesses/%v/products/", businessID)
 	url, err := addOptions(url, opts)
 	if err != nil {
 		return nil, nil, err
 	}
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -71,16 +80,35 @@ func (service *ProductsService) List(businessID string, opts *ProductListOptions
 	return *products, resp, nil
 }
 
+// ListIter returns an Iterator over every product for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *ProductsService) ListIter(businessID string, opts *ProductListOptions) *Iterator[Product] {
+	o := new(ProductListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Product, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
 // Get an existing product for a given business.
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/products.html#get--businesses-{business_id}-products-{product_id}-
 func (service *ProductsService) Get(businessID string, productID uint64, opts *ProductGetOptions) (*Product, *Response, error) {
+	return service.GetContext(context.Background(), businessID, productID, opts)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *ProductsService) GetContext(ctx context.Context, businessID string, productID uint64, opts *ProductGetOptions) (*Product, *Response, error) {
+	ctx = withOperation(ctx, "Products.Get")
 	url := fmt.Sprintf("businesses/%v/products/%v/", businessID, productID)
 	url, err := addOptions(url, opts)
 	if err != nil {
 		return nil, nil, err
 	}
-	req, err := service.client.NewRequest("GET", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -96,8 +124,14 @@ func (service *ProductsService) Get(businessID string, productID uint64, opts *P
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/products.html#post--businesses-{business_id}-products-
 func (service *ProductsService) Create(businessID string, product *Product) (*Product, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, product)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *ProductsService) CreateContext(ctx context.Context, businessID string, product *Product) (*Product, *Response, error) {
+	ctx = withOperation(ctx, "Products.Create")
 	url := fmt.Sprintf("businesses/%v/products/", businessID)
-	req, err := service.client.NewRequest("POST", url, product)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, product)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -113,8 +147,14 @@ func (service *ProductsService) Create(businessID string, product *Product) (*Pr
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/products.html#put--businesses-{business_id}-products-{product_id}-
 func (service *ProductsService) Replace(businessID string, productID uint64, product *Product) (*Product, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, productID, product)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *ProductsService) ReplaceContext(ctx context.Context, businessID string, productID uint64, product *Product) (*Product, *Response, error) {
+	ctx = withOperation(ctx, "Products.Replace")
 	url := fmt.Sprintf("businesses/%v/products/%v/", businessID, productID)
-	req, err := service.client.NewRequest("PUT", url, product)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, product)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -130,8 +170,14 @@ func (service *ProductsService) Replace(businessID string, productID uint64, pro
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/products.html#patch--businesses-{business_id}-products-{product_id}-
 func (service *ProductsService) Update(businessID string, productID uint64, product *Product) (*Product, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, productID, product)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *ProductsService) UpdateContext(ctx context.Context, businessID string, productID uint64, product *Product) (*Product, *Response, error) {
+	ctx = withOperation(ctx, "Products.Update")
 	url := fmt.Sprintf("businesses/%v/products/%v/", businessID, productID)
-	req, err := service.client.NewRequest("PATCH", url, product)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, product)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -147,8 +193,14 @@ func (service *ProductsService) Update(businessID string, productID uint64, prod
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/accounts.html#delete--businesses-{business_id}-accounts-{account_id}-
 func (service *ProductsService) Delete(businessID string, productID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, productID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *ProductsService) DeleteContext(ctx context.Context, businessID string, productID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Products.Delete")
 	url := fmt.Sprintf("businesses/%v/products/%v/", businessID, productID)
-	req, err := service.client.NewRequest("DELETE", url, nil)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +205,11 @@ func TestProductsService(t *testing.T) {
 		checkInvalidURLError(nil, resp, err)
 	})
 
+	Convey("CREATE a Product with a cancelled context", t, func() {
+		product, resp, err := client.Products.CreateContext(canceledContext(), "1", &Product{})
+		checkCanceledError(product, resp, err)
+	})
+
 	Convey("String method on Product", t, func() {
 		Convey("Name should return 'Name'", func() {
 			p := new(Product)
@@ -0,0 +1,156 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerRateLimit     = "X-RateLimit-Limit"
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+)
+
+// Rate represents the API quota reported in the rate limit headers of a
+// response. Fields are left at their zero value when the matching header is
+// not present.
+type Rate struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+
+	// Reset is when the current window ends and the quota is replenished.
+	Reset time.Time
+}
+
+// parseRate reads the rate limit headers from h, reporting whether the
+// remaining quota was present. X-RateLimit-Reset may be given either as a
+// Unix timestamp or as a number of seconds from now.
+func parseRate(h http.Header) (rate Rate, ok bool) {
+	if v := h.Get(headerRateLimit); v != "" {
+		rate.Limit, _ = strconv.Atoi(v)
+	}
+	if v := h.Get(headerRateRemaining); v != "" {
+		rate.Remaining, _ = strconv.Atoi(v)
+		ok = true
+	}
+	if v := h.Get(headerRateReset); v != "" {
+		if reset, err := strconv.ParseInt(v, 10, 64); err == nil {
+			// Anything before 2001 cannot be a timestamp for a future reset.
+			if reset < 1e9 {
+				rate.Reset = time.Now().Add(time.Duration(reset) * time.Second)
+			} else {
+				rate.Reset = time.Unix(reset, 0)
+			}
+		}
+	}
+	return rate, ok
+}
+
+func (r *Response) populateRateValues() {
+	r.Rate, _ = parseRate(r.Response.Header)
+}
+
+// RateLimiter is a token bucket which limits how often requests are sent. It
+// is safe for concurrent use, and a single RateLimiter can be shared between
+// several clients so that they draw from the same quota.
+//
+// A RateLimiter also tracks the quota reported by the API: once a response
+// reports that no requests remain, requests are held back until the
+// reported reset time.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// pausedUntil is the reset time reported by a response which exhausted
+	// the API quota.
+	pausedUntil time.Time
+}
+
+// NewRateLimiter returns a RateLimiter which allows perSecond requests per
+// second on average, with bursts of up to burst requests.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent, or until ctx is done, in which
+// case the context's error is returned.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token from the bucket and returns how long the caller has
+// to wait before the token becomes available.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 && l.rate > 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	return wait
+}
+
+// cancel returns a reserved token to the bucket.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// Observe records the quota reported by a response. Clients call it for
+// every response which carries rate limit headers.
+func (l *RateLimiter) Observe(rate Rate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate.Remaining <= 0 && rate.Reset.After(l.pausedUntil) {
+		l.pausedUntil = rate.Reset
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPopulateRateValues(t *testing.T) {
+	Convey("populateRateValues should set the quota from the rate limit headers", t, func() {
+		Convey("When the reset is a Unix timestamp", func() {
+			r := &http.Response{
+				Header: http.Header{
+					"X-Ratelimit-Limit":     {"100"},
+					"X-Ratelimit-Remaining": {"42"},
+					"X-Ratelimit-Reset":     {"1700000000"},
+				},
+			}
+			resp := newResponse(r)
+			So(resp.Rate.Limit, ShouldEqual, 100)
+			So(resp.Rate.Remaining, ShouldEqual, 42)
+			So(resp.Rate.Reset.Equal(time.Unix(1700000000, 0)), ShouldBeTrue)
+		})
+
+		Convey("When the reset is a number of seconds", func() {
+			r := &http.Response{
+				Header: http.Header{
+					"X-Ratelimit-Reset": {"60"},
+				},
+			}
+			resp := newResponse(r)
+			So(time.Until(resp.Rate.Reset), ShouldBeBetween, 59*time.Second, 61*time.Second)
+		})
+
+		Convey("When there are no rate limit headers", func() {
+			resp := newResponse(&http.Response{Header: http.Header{}})
+			So(resp.Rate, ShouldResemble, Rate{})
+		})
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	Convey("A RateLimiter should allow a burst and then throttle", t, func() {
+		l := NewRateLimiter(50, 2)
+		ctx := context.Background()
+
+		start := time.Now()
+		So(l.Wait(ctx), ShouldBeNil)
+		So(l.Wait(ctx), ShouldBeNil)
+		So(time.Since(start), ShouldBeLessThan, 10*time.Millisecond)
+
+		So(l.Wait(ctx), ShouldBeNil)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 15*time.Millisecond)
+	})
+
+	Convey("A RateLimiter should be safe to share between goroutines", t, func() {
+		l := NewRateLimiter(1000, 5)
+		ctx := context.Background()
+
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < 25; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.Wait(ctx)
+			}()
+		}
+		wg.Wait()
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 19*time.Millisecond)
+	})
+
+	Convey("Waiting on a RateLimiter should stop when the context is done", t, func() {
+		l := NewRateLimiter(0.001, 1)
+		So(l.Wait(context.Background()), ShouldBeNil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		So(l.Wait(ctx) == context.DeadlineExceeded, ShouldBeTrue)
+	})
+
+	Convey("An exhausted quota should pause the RateLimiter until the reset", t, func() {
+		l := NewRateLimiter(1000, 10)
+		l.Observe(Rate{Limit: 100, Remaining: 0, Reset: time.Now().Add(20 * time.Millisecond)})
+
+		start := time.Now()
+		So(l.Wait(context.Background()), ShouldBeNil)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 15*time.Millisecond)
+	})
+
+	Convey("The client should wait on its RateLimiter and track the quota", t, func() {
+		setUp()
+		defer tearDown()
+		client.RateLimiter = NewRateLimiter(1000, 10)
+
+		calls := 0
+		mux.HandleFunc("/currencies", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-RateLimit-Limit", "2")
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(2-calls))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(2*time.Second).Unix(), 10))
+			fmt.Fprint(w, `[]`)
+		})
+
+		_, resp, err := client.Currencies.List()
+		So(err, ShouldBeNil)
+		So(resp.Rate.Limit, ShouldEqual, 2)
+		So(resp.Rate.Remaining, ShouldEqual, 1)
+
+		_, resp, err = client.Currencies.List()
+		So(err, ShouldBeNil)
+		So(resp.Rate.Remaining, ShouldEqual, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, _, err = client.Currencies.ListContext(ctx)
+		So(err == context.DeadlineExceeded, ShouldBeTrue)
+		So(calls, ShouldEqual, 2)
+	})
+}
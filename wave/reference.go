@@ -0,0 +1,234 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+//go:generate go run gen_reference.go -iso-codes /usr/share/iso-codes/json -version 4.15.0
+
+//go:embed data/reference.json
+var referenceJSON []byte
+
+// ReferenceData holds currencies and countries, with their provinces, so that
+// they can be looked up without asking the API.
+type ReferenceData struct {
+	// Version identifies the data. The bundled data has the version of the
+	// iso-codes release it was generated from, such as "4.15.0", and data
+	// refreshed from the API has "+api" added to it.
+	Version    string
+	Currencies []Currency
+	Countries  []Country
+
+	currencies map[string]int // indexes in Currencies, by code
+	countries  map[string]int // indexes in Countries, by code
+}
+
+// NewReferenceData returns reference data holding the given currencies and
+// countries.
+func NewReferenceData(version string, currencies []Currency, countries []Country) *ReferenceData {
+	d := &ReferenceData{Version: version, Currencies: currencies, Countries: countries}
+	d.index()
+	return d
+}
+
+func (d *ReferenceData) index() {
+	d.currencies = make(map[string]int, len(d.Currencies))
+	for i, c := range d.Currencies {
+		if c.Code != nil {
+			d.currencies[strings.ToUpper(*c.Code)] = i
+		}
+	}
+	d.countries = make(map[string]int, len(d.Countries))
+	for i, c := range d.Countries {
+		if c.CountryCode != nil {
+			d.countries[strings.ToUpper(*c.CountryCode)] = i
+		}
+	}
+}
+
+// referenceFile is the format of data/reference.json, which holds the fields
+// of currencies and provinces that are not sent to the API.
+type referenceFile struct {
+	Version    string `json:"version"`
+	Currencies []struct {
+		Currency
+		MinorUnits *int `json:"minor_units"`
+	} `json:"currencies"`
+	Countries []struct {
+		Country
+		Provinces []struct {
+			Province
+			Code *string `json:"code"`
+		} `json:"provinces"`
+	} `json:"countries"`
+}
+
+var bundled struct {
+	once sync.Once
+	data *ReferenceData
+}
+
+// BundledReference returns the reference data bundled with the package: the
+// ISO 4217 currencies, with their minor units and symbols, and the ISO 3166-1
+// countries, with their top-level ISO 3166-2 subdivisions as provinces. It is
+// shared, so it must not be modified.
+func BundledReference() *ReferenceData {
+	bundled.once.Do(func() {
+		var f referenceFile
+		if err := json.Unmarshal(referenceJSON, &f); err != nil {
+			panic("wave: bundled reference data: " + err.Error())
+		}
+		currencies := make([]Currency, len(f.Currencies))
+		for i, c := range f.Currencies {
+			currencies[i] = c.Currency
+			currencies[i].MinorUnits = c.MinorUnits
+		}
+		countries := make([]Country, len(f.Countries))
+		for i, c := range f.Countries {
+			countries[i] = c.Country
+			countries[i].Provinces = nil
+			for _, p := range c.Provinces {
+				p.Province.Code = p.Code
+				countries[i].Provinces = append(countries[i].Provinces, p.Province)
+			}
+		}
+		bundled.data = NewReferenceData(f.Version, currencies, countries)
+	})
+	return bundled.data
+}
+
+// Currency returns the currency with the given ISO 4217 code, in any case, or
+// nil if there is none.
+func (d *ReferenceData) Currency(code string) *Currency {
+	if i, ok := d.currencies[strings.ToUpper(code)]; ok {
+		return &d.Currencies[i]
+	}
+	return nil
+}
+
+// Country returns the country with the given ISO 3166-1 alpha-2 code, in any
+// case, or nil if there is none.
+func (d *ReferenceData) Country(code string) *Country {
+	if i, ok := d.countries[strings.ToUpper(code)]; ok {
+		return &d.Countries[i]
+	}
+	return nil
+}
+
+// Province returns the province of the country with the given slug, name or
+// ISO 3166-2 code, in any case, or nil if there is none. The code may leave
+// out the country, so "ON" and "CA-ON" are both Ontario.
+func (c Country) Province(key string) *Province {
+	for i, p := range c.Provinces {
+		for _, s := range []*string{p.Slug, p.Name, p.Code} {
+			if s != nil && strings.EqualFold(*s, key) {
+				return &c.Provinces[i]
+			}
+		}
+		if p.Code != nil && c.CountryCode != nil && strings.EqualFold(*p.Code, *c.CountryCode+"-"+key) {
+			return &c.Provinces[i]
+		}
+	}
+	return nil
+}
+
+// merge returns a copy of d updated with the currencies and countries listed
+// by the API. Their fields replace those of the ones in d, and any not in d
+// are added, once even if the API lists them more than once.
+func (d *ReferenceData) merge(currencies []Currency, countries []Country) *ReferenceData {
+	version := d.Version
+	if !strings.HasSuffix(version, "+api") {
+		version += "+api"
+	}
+	m := NewReferenceData(version, append([]Currency(nil), d.Currencies...), append([]Country(nil), d.Countries...))
+
+	for _, c := range currencies {
+		if c.Code == nil {
+			continue
+		}
+		known := m.Currency(*c.Code)
+		if known == nil {
+			m.currencies[strings.ToUpper(*c.Code)] = len(m.Currencies)
+			m.Currencies = append(m.Currencies, c)
+			continue
+		}
+		if c.MinorUnits == nil {
+			c.MinorUnits = known.MinorUnits
+		}
+		if c.Symbol == nil {
+			c.Symbol = known.Symbol
+		}
+		if c.Name == nil {
+			c.Name = known.Name
+		}
+		*known = c
+	}
+
+	for _, c := range countries {
+		if c.CountryCode == nil {
+			continue
+		}
+		known := m.Country(*c.CountryCode)
+		if known == nil {
+			m.countries[strings.ToUpper(*c.CountryCode)] = len(m.Countries)
+			m.Countries = append(m.Countries, c)
+			continue
+		}
+		if c.Name == nil {
+			c.Name = known.Name
+		}
+		if c.CurrencyCode == nil {
+			c.CurrencyCode = known.CurrencyCode
+		}
+		if len(c.Provinces) == 0 {
+			c.Provinces = known.Provinces
+		} else {
+			c.Provinces = append([]Province(nil), c.Provinces...)
+			for i, p := range c.Provinces {
+				if p.Code != nil || p.Slug == nil {
+					continue
+				}
+				if kp := known.Province(*p.Slug); kp != nil {
+					c.Provinces[i].Code = kp.Code
+				}
+			}
+		}
+		*known = c
+	}
+	return m
+}
+
+// reference returns the reference data of the client.
+func (c *Client) reference() *ReferenceData {
+	if c.Reference != nil {
+		return c.Reference
+	}
+	return BundledReference()
+}
+
+// RefreshReference replaces the client's Reference with its current reference
+// data updated with the currencies and countries listed by the API, such as
+// their URLs and the provinces Wave knows about. It must not be called while
+// other goroutines look up currencies or countries with the client.
+func (c *Client) RefreshReference(ctx context.Context) error {
+	currencies, _, err := c.Currencies.ListContext(ctx)
+	if err != nil {
+		return err
+	}
+	countries, _, err := c.Countries.ListContext(ctx)
+	if err != nil {
+		return err
+	}
+	c.Reference = c.reference().merge(currencies, countries)
+	return nil
+}
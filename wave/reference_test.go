@@ -0,0 +1,161 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBundledReference(t *testing.T) {
+	Convey("The bundled reference data should hold the ISO currencies and countries", t, func() {
+		data := BundledReference()
+		So(data.Version, ShouldEqual, "4.15.0")
+
+		cad := data.Currency("cad")
+		So(*cad.Code, ShouldEqual, "CAD")
+		So(*cad.Symbol, ShouldEqual, "$")
+		So(*cad.Name, ShouldEqual, "Canadian Dollar")
+		So(*cad.MinorUnits, ShouldEqual, 2)
+		So(*data.Currency("JPY").MinorUnits, ShouldEqual, 0)
+		So(*data.Currency("KWD").MinorUnits, ShouldEqual, 3)
+		So(data.Currency("XAU").MinorUnits, ShouldBeNil)
+		So(data.Currency("ZZZ"), ShouldBeNil)
+
+		ca := data.Country("ca")
+		So(*ca.Name, ShouldEqual, "Canada")
+		So(*ca.CurrencyCode, ShouldEqual, "CAD")
+		So(ca.Provinces, ShouldHaveLength, 13)
+		So(data.Country("ZZ"), ShouldBeNil)
+
+		Convey("with every country using a known currency and unique province slugs", func() {
+			for _, c := range data.Countries {
+				if c.CurrencyCode != nil {
+					So(data.Currency(*c.CurrencyCode), ShouldNotBeNil)
+				}
+				slugs := make(map[string]bool)
+				for _, p := range c.Provinces {
+					So(slugs[*p.Slug], ShouldBeFalse)
+					slugs[*p.Slug] = true
+				}
+			}
+		})
+	})
+
+	Convey("Provinces should be found by slug, name or code", t, func() {
+		ca := BundledReference().Country("CA")
+		for _, key := range []string{"ontario", "Ontario", "CA-ON", "on"} {
+			p := ca.Province(key)
+			So(p, ShouldNotBeNil)
+			So(*p.Slug, ShouldEqual, "ontario")
+			So(*p.Code, ShouldEqual, "CA-ON")
+		}
+		So(ca.Province("texas"), ShouldBeNil)
+		So(*BundledReference().Country("US").Province("new-mexico").Name, ShouldEqual, "New Mexico")
+	})
+
+	Convey("Minor units and province codes should not be sent to the API", t, func() {
+		data := BundledReference()
+		b, err := json.Marshal(data.Currency("CAD"))
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"code":"CAD","symbol":"$","name":"Canadian Dollar"}`)
+		b, err = json.Marshal(data.Country("CA").Province("ON"))
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"name":"Ontario","slug":"ontario"}`)
+	})
+
+	Convey("Money should use the minor units of the reference data", t, func() {
+		So(NewMoney(12345, "CLF").Decimal(), ShouldEqual, "1.2345")
+		So(NewMoney(12345, "JPY").Decimal(), ShouldEqual, "12345")
+		So(NewMoney(12345, "XAU").Decimal(), ShouldEqual, "123.45")
+	})
+}
+
+func TestRefreshReference(t *testing.T) {
+	Convey("Given a client", t, func() {
+		setUp()
+		defer tearDown()
+
+		Convey("Lookups should use the bundled data without asking the API", func() {
+			So(*client.Currencies.Lookup("EUR").Symbol, ShouldEqual, "€")
+			So(*client.Countries.Lookup("FR").CurrencyCode, ShouldEqual, "EUR")
+			So(client.Countries.Lookup("ZZ"), ShouldBeNil)
+		})
+
+		Convey("Refreshing should update the reference data from the API", func() {
+			mux.HandleFunc("/currencies/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"code": "CAD", "symbol": "CA$", "name": "Canadian Dollar", "url": "https://api.waveapps.com/currencies/CAD/"},
+					{"code": "XBT", "symbol": "₿", "name": "Bitcoin"}
+				]`)
+			})
+			mux.HandleFunc("/countries/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"country_code": "CA", "name": "Canada", "currency_code": "CAD", "provinces": [{"name": "Ontario", "slug": "ontario"}]},
+					{"country_code": "US", "name": "United States"}
+				]`)
+			})
+
+			err := client.RefreshReference(context.Background())
+			So(err, ShouldBeNil)
+			So(client.Reference.Version, ShouldEqual, "4.15.0+api")
+
+			cad := client.Currencies.Lookup("CAD")
+			So(*cad.Symbol, ShouldEqual, "CA$")
+			So(*cad.URL, ShouldEqual, "https://api.waveapps.com/currencies/CAD/")
+			So(*cad.MinorUnits, ShouldEqual, 2)
+			So(*client.Currencies.Lookup("XBT").Name, ShouldEqual, "Bitcoin")
+			So(client.Currencies.Lookup("JPY"), ShouldNotBeNil)
+
+			ca := client.Countries.Lookup("CA")
+			So(ca.Provinces, ShouldHaveLength, 1)
+			So(*ca.Provinces[0].Code, ShouldEqual, "CA-ON")
+			us := client.Countries.Lookup("US")
+			So(*us.Name, ShouldEqual, "United States")
+			So(*us.CurrencyCode, ShouldEqual, "USD")
+			So(us.Province("NM"), ShouldNotBeNil)
+
+			So(*BundledReference().Currency("CAD").Symbol, ShouldEqual, "$")
+			So(BundledReference().Country("CA").Provinces, ShouldHaveLength, 13)
+		})
+
+		Convey("Codes listed twice by the API should be added once", func() {
+			mux.HandleFunc("/currencies/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"code": "XBT", "name": "Bitcoin"}, {"code": "xbt", "symbol": "₿"}]`)
+			})
+			mux.HandleFunc("/countries/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"country_code": "XK", "name": "Kosovo"}, {"country_code": "XK", "currency_code": "EUR"}]`)
+			})
+
+			So(client.RefreshReference(context.Background()), ShouldBeNil)
+			data := client.Reference
+			So(data.Currencies, ShouldHaveLength, len(BundledReference().Currencies)+1)
+			So(data.Countries, ShouldHaveLength, len(BundledReference().Countries)+1)
+			xbt := data.Currency("XBT")
+			So(*xbt.Code, ShouldEqual, "xbt")
+			So(*xbt.Symbol, ShouldEqual, "₿")
+			So(*xbt.Name, ShouldEqual, "Bitcoin")
+			xk := data.Country("XK")
+			So(*xk.Name, ShouldEqual, "Kosovo")
+			So(*xk.CurrencyCode, ShouldEqual, "EUR")
+		})
+
+		Convey("A failed refresh should keep the reference data", func() {
+			mux.HandleFunc("/currencies/", func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"error": {"message": "Unauthorized"}}`, http.StatusUnauthorized)
+			})
+			err := client.RefreshReference(context.Background())
+			So(err, ShouldNotBeNil)
+			So(client.Reference, ShouldBeNil)
+		})
+	})
+}
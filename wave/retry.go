@@ -0,0 +1,224 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy configures how a Client retries requests which fail with a
+// transient error: a transport error, a 429 Too Many Requests, or a 500, 502,
+// 503 or 504 response.
+//
+// Only idempotent requests (GET, HEAD, OPTIONS, PUT and DELETE) are retried
+// unless RetryPOST is set. PATCH requests are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including
+	// the first attempt. Values less than 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the base wait before the first retry. The wait doubles on
+	// every further retry, up to MaxBackoff, and half of it is randomized.
+	// MaxBackoff also caps the wait asked for by a Retry-After header. They
+	// default to 500ms and 30s respectively.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RetryPOST allows POST requests to be retried. A POST which times out
+	// may have been processed by the API, so retrying it can create
+	// duplicate resources.
+	RetryPOST bool
+
+	// OnRetry, if set, is called before every retry.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	// Request is the request that failed.
+	Request *http.Request
+
+	// Response is the API response of the failed attempt, or nil if the
+	// attempt failed with a transport error.
+	Response *Response
+
+	// Err is the transport error of the failed attempt, if any.
+	Err error
+
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+
+	// Wait is how long the client will wait before the next attempt.
+	Wait time.Duration
+}
+
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+	case "POST":
+		if !p.RetryPOST {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns how long to wait after the given failed attempt. A
+// Retry-After header on resp takes precedence over the exponential backoff,
+// but is still capped at MaxBackoff.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = defaultMinBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > max {
+				wait = max
+			}
+			return wait
+		}
+	}
+
+	wait := min
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// send sends req using the underlying http.Client, waiting on the client's
+// RateLimiter and retrying it according to the client's RetryPolicy.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if resp != nil && c.RateLimiter != nil {
+			if rate, ok := parseRate(resp.Header); ok {
+				c.RateLimiter.Observe(rate)
+			}
+		}
+
+		p := c.RetryPolicy
+		if p == nil || attempt >= p.MaxAttempts || !p.retryable(req, resp, err) {
+			return resp, err
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			// The body has been consumed and cannot be sent again.
+			return resp, err
+		}
+
+		wait := p.backoff(attempt, resp)
+		if p.OnRetry != nil {
+			event := RetryEvent{Request: req, Err: err, Attempt: attempt, Wait: wait}
+			if resp != nil {
+				event.Response = newResponse(resp)
+			}
+			p.OnRetry(event)
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewind returns a copy of req whose body can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// sleep waits for d, returning early with the context's error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
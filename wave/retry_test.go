@@ -0,0 +1,206 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// failFirst registers a handler on path which responds with status to the
+// first n requests and with body afterwards. It returns a pointer to the
+// number of requests received.
+func failFirst(path string, n int, status int, body string) *int {
+	calls := new(int)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= n {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, body)
+	})
+	return calls
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := func() *RetryPolicy {
+		return &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	}
+
+	Convey("A GET failing with a 502 should be retried", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		calls := failFirst("/businesses/1/customers/1/", 2, http.StatusBadGateway, `{"id": 1}`)
+
+		var events []RetryEvent
+		client.RetryPolicy.OnRetry = func(e RetryEvent) {
+			events = append(events, e)
+		}
+
+		customer, _, err := client.Customers.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(customer.ID, ShouldEqual, 1)
+		So(*calls, ShouldEqual, 3)
+		So(len(events), ShouldEqual, 2)
+		So(events[0].Attempt, ShouldEqual, 1)
+		So(events[1].Attempt, ShouldEqual, 2)
+		So(events[0].Response.StatusCode, ShouldEqual, http.StatusBadGateway)
+		So(events[0].Err, ShouldBeNil)
+	})
+
+	Convey("A request should give up after MaxAttempts", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		calls := failFirst("/businesses/1/customers/1/", 5, http.StatusServiceUnavailable, `{}`)
+
+		_, resp, err := client.Customers.Get("1", 1)
+		So(err, ShouldNotBeNil)
+		So(resp.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+		So(*calls, ShouldEqual, 3)
+	})
+
+	Convey("A client error should not be retried", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		calls := failFirst("/businesses/1/customers/1/", 1, http.StatusNotFound, `{}`)
+
+		_, _, err := client.Customers.Get("1", 1)
+		So(err, ShouldNotBeNil)
+		So(*calls, ShouldEqual, 1)
+	})
+
+	Convey("A POST should not be retried by default", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		calls := failFirst("/businesses/1/customers/", 1, http.StatusBadGateway, `{"id": 1}`)
+
+		_, _, err := client.Customers.Create("1", &Customer{})
+		So(err, ShouldNotBeNil)
+		So(*calls, ShouldEqual, 1)
+	})
+
+	Convey("A POST should be retried with its body when RetryPOST is set", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		client.RetryPolicy.RetryPOST = true
+
+		var bodies []string
+		mux.HandleFunc("/businesses/1/customers/", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			fmt.Fprint(w, `{"id": 1}`)
+		})
+
+		_, _, err := client.Customers.Create("1", &Customer{Name: String("Foo")})
+		So(err, ShouldBeNil)
+		So(bodies, ShouldResemble, []string{`{"name":"Foo"}` + "\n", `{"name":"Foo"}` + "\n"})
+	})
+
+	Convey("A PATCH should never be retried", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+		client.RetryPolicy.RetryPOST = true
+		calls := failFirst("/businesses/1/customers/1/", 1, http.StatusBadGateway, `{"id": 1}`)
+
+		_, _, err := client.Customers.Update("1", 1, &Customer{})
+		So(err, ShouldNotBeNil)
+		So(*calls, ShouldEqual, 1)
+	})
+
+	Convey("A Retry-After header should be honoured", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = policy()
+
+		var wait time.Duration
+		client.RetryPolicy.OnRetry = func(e RetryEvent) {
+			wait = e.Wait
+		}
+		calls := 0
+		mux.HandleFunc("/currencies/CAD", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			fmt.Fprint(w, `{"code": "CAD"}`)
+		})
+
+		_, _, err := client.Currencies.Get("CAD")
+		So(err, ShouldBeNil)
+		So(calls, ShouldEqual, 2)
+		So(wait, ShouldEqual, 0)
+	})
+
+	Convey("Cancelling the context should stop waiting between retries", t, func() {
+		setUp()
+		defer tearDown()
+		client.RetryPolicy = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour}
+		failFirst("/businesses/1/customers/1/", 3, http.StatusBadGateway, `{}`)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		client.RetryPolicy.OnRetry = func(RetryEvent) {
+			cancel()
+		}
+		_, resp, err := client.Customers.GetContext(ctx, "1", 1)
+		So(resp, ShouldBeNil)
+		So(err, ShouldEqual, context.Canceled)
+	})
+
+	Convey("Backoff should grow exponentially up to the maximum", t, func() {
+		p := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+		for i := 0; i < 10; i++ {
+			So(p.backoff(1, nil), ShouldBeBetweenOrEqual, 50*time.Millisecond, 100*time.Millisecond)
+			So(p.backoff(2, nil), ShouldBeBetweenOrEqual, 100*time.Millisecond, 200*time.Millisecond)
+			So(p.backoff(5, nil), ShouldBeBetweenOrEqual, 150*time.Millisecond, 300*time.Millisecond)
+		}
+	})
+
+	Convey("A Retry-After header should be capped at the maximum backoff", t, func() {
+		resp := &http.Response{Header: http.Header{"Retry-After": {"3600"}}}
+		p := &RetryPolicy{MaxBackoff: 5 * time.Second}
+		So(p.backoff(1, resp), ShouldEqual, 5*time.Second)
+		So((&RetryPolicy{}).backoff(1, resp), ShouldEqual, 30*time.Second)
+
+		resp.Header.Set("Retry-After", "2")
+		So(p.backoff(1, resp), ShouldEqual, 2*time.Second)
+	})
+
+	Convey("Parsing a Retry-After header", t, func() {
+		wait, ok := parseRetryAfter("120")
+		So(ok, ShouldBeTrue)
+		So(wait, ShouldEqual, 2*time.Minute)
+
+		wait, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		So(ok, ShouldBeTrue)
+		So(wait, ShouldBeBetween, 59*time.Minute, time.Hour)
+
+		_, ok = parseRetryAfter("")
+		So(ok, ShouldBeFalse)
+
+		_, ok = parseRetryAfter("soon")
+		So(ok, ShouldBeFalse)
+	})
+}
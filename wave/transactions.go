@@ -0,0 +1,258 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TransactionsService handles communication with the transaction (journal entry) related methods of the Wave API.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/transactions.html
+type TransactionsService struct {
+	client *Client
+}
+
+// TransactionLine represents a single debit or credit against an Account.
+// A line should set either Debit or Credit, not both.
+type TransactionLine struct {
+	ID          *uint64  `json:"id,omitempty"`
+	Account     *Account `json:"account,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Debit       *Money   `json:"debit,omitempty"`
+	Credit      *Money   `json:"credit,omitempty"`
+}
+
+// Transaction represents a double-entry journal entry. The debits and credits
+// of its lines must balance, and every account must use the same currency.
+type Transaction struct {
+	ID           uint64            `json:"id,omitempty"`
+	URL          *string           `json:"url,omitempty"`
+	Date         *Date             `json:"date,omitempty"`
+	Description  *string           `json:"description,omitempty"`
+	Lines        []TransactionLine `json:"lines,omitempty"`
+	DateCreated  *DateTime         `json:"date_created,omitempty"`
+	DateModified *DateTime         `json:"date_modified,omitempty"`
+}
+
+func (t Transaction) String() string {
+	return fmt.Sprintf("%v (%d lines)", deref(t.Description), len(t.Lines))
+}
+
+// UnbalancedTransactionError is returned when the debits of a transaction do
+// not equal its credits.
+type UnbalancedTransactionError struct {
+	Debits  Money
+	Credits Money
+}
+
+func (e *UnbalancedTransactionError) Error() string {
+	return fmt.Sprintf("transaction is unbalanced: debits %v != credits %v", e.Debits, e.Credits)
+}
+
+// ErrEmptyTransaction is returned when a transaction has no lines.
+var ErrEmptyTransaction = errors.New("wave: transaction has no lines")
+
+// DoubleSidedLineError is returned when a line of a transaction sets both its
+// Debit and its Credit.
+type DoubleSidedLineError struct {
+	// Line is the index of the line.
+	Line int
+}
+
+func (e *DoubleSidedLineError) Error() string {
+	return fmt.Sprintf("transaction line %d: sets both a debit and a credit", e.Line)
+}
+
+// CurrencyMismatchError is returned when the accounts of a transaction do not
+// all use the same currency.
+type CurrencyMismatchError struct {
+	// Line is the index of the first line whose account currency disagrees.
+	Line     int
+	Expected string
+	Currency string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("transaction line %d: account currency %v does not match %v", e.Line, e.Currency, e.Expected)
+}
+
+// Validate checks that a transaction has lines, each setting Debit or Credit
+// but not both, that its debits equal its credits, and that every line whose
+// Account has a Currency agrees on its code. Amounts are totalled exactly and
+// the totals compared in the minor units of that currency.
+//
+// It returns ErrEmptyTransaction, a *DoubleSidedLineError, an
+// *UnbalancedTransactionError or a *CurrencyMismatchError, or a
+// *MixedCurrencyError if the amounts themselves carry different currencies.
+func (t *Transaction) Validate() error {
+	if len(t.Lines) == 0 {
+		return ErrEmptyTransaction
+	}
+	var currency string
+	for i, line := range t.Lines {
+		if line.Debit != nil && line.Credit != nil {
+			return &DoubleSidedLineError{Line: i}
+		}
+		if a := line.Account; a != nil && a.Currency != nil && a.Currency.Code != nil {
+			if currency == "" {
+				currency = *a.Currency.Code
+			} else if *a.Currency.Code != currency {
+				return &CurrencyMismatchError{Line: i, Expected: currency, Currency: *a.Currency.Code}
+			}
+		}
+	}
+
+	debits, credits := newSum(currency), newSum(currency)
+	for _, line := range t.Lines {
+		if line.Debit != nil {
+			if err := debits.add(*line.Debit); err != nil {
+				return err
+			}
+		}
+		if line.Credit != nil {
+			if err := credits.add(*line.Credit); err != nil {
+				return err
+			}
+		}
+	}
+
+	d, err := debits.round()
+	if err != nil {
+		return err
+	}
+	c, err := credits.round()
+	if err != nil {
+		return err
+	}
+	if d.Cmp(c) != 0 {
+		return &UnbalancedTransactionError{Debits: d, Credits: c}
+	}
+	return nil
+}
+
+// TransactionListOptions specifies the optional parameters to LIST endpoint.
+type TransactionListOptions struct {
+	// AccountID only lists transactions with a line against the given account.
+	AccountID int `url:"account_id,omitempty"`
+	// DateStart and DateEnd only list transactions dated within the range,
+	// inclusive.
+	DateStart *Date `url:"date_start,omitempty"`
+	DateEnd   *Date `url:"date_end,omitempty"`
+
+	PageOptions
+}
+
+// List all transactions for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/transactions.html#get--businesses-{business_id}-transactions-
+func (service *TransactionsService) List(businessID string, opts *TransactionListOptions) ([]Transaction, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *TransactionsService) ListContext(ctx context.Context, businessID string, opts *TransactionListOptions) ([]Transaction, *Response, error) {
+	ctx = withOperation(ctx, "Transactions.List")
+	url := fmt.Sprintf("businesses/%v/transactions/", businessID)
+	url, err := addOptions(url, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	transactions := new([]Transaction)
+	resp, err := service.client.Do(req, transactions)
+	if err != nil {
+		return nil, resp, err
+	}
+	return *transactions, resp, nil
+}
+
+// ListIter returns an Iterator over every transaction for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *TransactionsService) ListIter(businessID string, opts *TransactionListOptions) *Iterator[Transaction] {
+	o := new(TransactionListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Transaction, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
+// Get an existing transaction for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/transactions.html#get--businesses-{business_id}-transactions-{transaction_id}-
+func (service *TransactionsService) Get(businessID string, transactionID uint64) (*Transaction, *Response, error) {
+	return service.GetContext(context.Background(), businessID, transactionID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *TransactionsService) GetContext(ctx context.Context, businessID string, transactionID uint64) (*Transaction, *Response, error) {
+	ctx = withOperation(ctx, "Transactions.Get")
+	url := fmt.Sprintf("businesses/%v/transactions/%v/", businessID, transactionID)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction := new(Transaction)
+	resp, err := service.client.Do(req, transaction)
+	if err != nil {
+		return nil, resp, err
+	}
+	return transaction, resp, nil
+}
+
+// Create a new transaction for a given business. The transaction is checked
+// with Validate first, and is not sent if it is unbalanced or mixes currencies.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/transactions.html#post--businesses-{business_id}-transactions-
+func (service *TransactionsService) Create(businessID string, transaction *Transaction) (*Transaction, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, transaction)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *TransactionsService) CreateContext(ctx context.Context, businessID string, transaction *Transaction) (*Transaction, *Response, error) {
+	ctx = withOperation(ctx, "Transactions.Create")
+	if err := transaction.Validate(); err != nil {
+		return nil, nil, err
+	}
+	url := fmt.Sprintf("businesses/%v/transactions/", businessID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := new(Transaction)
+	resp, err := service.client.Do(req, t)
+	if err != nil {
+		return nil, resp, err
+	}
+	return t, resp, nil
+}
+
+// Delete an existing transaction.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/transactions.html#delete--businesses-{business_id}-transactions-{transaction_id}-
+func (service *TransactionsService) Delete(businessID string, transactionID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, transactionID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *TransactionsService) DeleteContext(ctx context.Context, businessID string, transactionID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Transactions.Delete")
+	url := fmt.Sprintf("businesses/%v/transactions/%v/", businessID, transactionID)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return service.client.Do(req, nil)
+}
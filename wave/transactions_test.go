@@ -0,0 +1,303 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	expectedTransactionJSON = `{
+		"id": 1,
+		"url": "url",
+		"date": "2013-12-05",
+		"description": "Owner investment",
+		"lines": [
+			{
+				"id": 1,
+				"account": {
+					"id": 1,
+					"name": "Chequing",
+					"currency": {"code": "USD"}
+				},
+				"debit": 1000
+			},
+			{
+				"id": 2,
+				"account": {
+					"id": 2,
+					"name": "Owner Investment",
+					"currency": {"code": "USD"}
+				},
+				"credit": 1000
+			}
+		],
+		"date_created": "2013-12-05T10:31:01+00:00",
+		"date_modified": "2013-12-05T13:37:59+00:00"
+	}`
+	expectedTransactionsJSON = "[" + expectedTransactionJSON + "]"
+)
+
+func usdAccount(id int) *Account {
+	return &Account{ID: Int(id), Currency: &Currency{Code: String("USD")}}
+}
+
+func TestTransactionsService(t *testing.T) {
+	expectedTransactionStruct := new(Transaction)
+	json.Unmarshal([]byte(expectedTransactionJSON), expectedTransactionStruct)
+
+	Convey("Testing JSON unmarshalling of a Transaction", t, func() {
+		tr := expectedTransactionStruct
+		So(time.Time(*tr.Date).Format("2006-01-02"), ShouldEqual, "2013-12-05")
+		So(len(tr.Lines), ShouldEqual, 2)
+		So(tr.Lines[0].Debit.Decimal(), ShouldEqual, "1000")
+		So(tr.Lines[0].Credit, ShouldBeNil)
+		So(tr.Lines[1].Credit.Decimal(), ShouldEqual, "1000")
+		So(tr.Validate(), ShouldBeNil)
+	})
+
+	Convey("Validating a Transaction", t, func() {
+		Convey("Should total amounts exactly", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("0.1")},
+				{Account: usdAccount(2), Debit: Amount("0.2")},
+				{Account: usdAccount(3), Credit: Amount("0.3")},
+			}}
+			So(tr.Validate(), ShouldBeNil)
+		})
+
+		Convey("Should only round the totals", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("0.005")},
+				{Account: usdAccount(2), Debit: Amount("0.005")},
+				{Account: usdAccount(3), Credit: Amount("0.01")},
+			}}
+			So(tr.Validate(), ShouldBeNil)
+		})
+
+		Convey("Should reject a transaction without lines", func() {
+			So((&Transaction{}).Validate(), ShouldEqual, ErrEmptyTransaction)
+		})
+
+		Convey("Should reject a line with both a debit and a credit", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("10")},
+				{Account: usdAccount(2), Debit: Amount("5"), Credit: Amount("15")},
+			}}
+			err := tr.Validate()
+			e, ok := err.(*DoubleSidedLineError)
+			So(ok, ShouldBeTrue)
+			So(e.Line, ShouldEqual, 1)
+			So(err.Error(), ShouldEqual, "transaction line 1: sets both a debit and a credit")
+		})
+
+		Convey("Should compare amounts in the minor units of the currency", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("10.004")},
+				{Account: usdAccount(2), Credit: Amount("10")},
+			}}
+			So(tr.Validate(), ShouldBeNil)
+
+			jpy := &Account{ID: Int(3), Currency: &Currency{Code: String("JPY")}}
+			tr = &Transaction{Lines: []TransactionLine{
+				{Account: jpy, Debit: Amount("100.4")},
+				{Account: jpy, Credit: Amount("100")},
+			}}
+			So(tr.Validate(), ShouldBeNil)
+		})
+
+		Convey("Should reject amounts in a different currency", func() {
+			cad := MustParseMoney("10", "CAD")
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("10")},
+				{Account: usdAccount(2), Credit: &cad},
+			}}
+			_, ok := tr.Validate().(*MixedCurrencyError)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Should accept accounts without a known currency", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: &Account{ID: Int(1)}, Debit: Amount("5")},
+				{Account: usdAccount(2), Credit: Amount("5")},
+			}}
+			So(tr.Validate(), ShouldBeNil)
+		})
+
+		Convey("Should reject unbalanced lines", func() {
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("10")},
+				{Account: usdAccount(2), Credit: Amount("9.99")},
+			}}
+			err := tr.Validate()
+			e, ok := err.(*UnbalancedTransactionError)
+			So(ok, ShouldBeTrue)
+			So(e.Debits, ShouldResemble, MustParseMoney("10.00", "USD"))
+			So(e.Credits, ShouldResemble, MustParseMoney("9.99", "USD"))
+			So(err.Error(), ShouldEqual, "transaction is unbalanced: debits 10.00 USD != credits 9.99 USD")
+		})
+
+		Convey("Should reject accounts in different currencies", func() {
+			cad := &Account{ID: Int(2), Currency: &Currency{Code: String("CAD")}}
+			tr := &Transaction{Lines: []TransactionLine{
+				{Account: usdAccount(1), Debit: Amount("10")},
+				{Account: cad, Credit: Amount("10")},
+			}}
+			err := tr.Validate()
+			e, ok := err.(*CurrencyMismatchError)
+			So(ok, ShouldBeTrue)
+			So(e.Line, ShouldEqual, 1)
+			So(e.Expected, ShouldEqual, "USD")
+			So(e.Currency, ShouldEqual, "CAD")
+			So(err.Error(), ShouldEqual, "transaction line 1: account currency CAD does not match USD")
+		})
+	})
+
+	Convey("LIST all Transactions for a business", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/transactions/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedTransactionsJSON)
+		})
+
+		transactions, _, err := client.Transactions.List("1", nil)
+		So(err, ShouldEqual, nil)
+		So(transactions, ShouldResemble, []Transaction{*expectedTransactionStruct})
+	})
+
+	Convey("LIST all Transactions for a business with filters", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/transactions/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.URL.RawQuery, ShouldEqual, "account_id=3&date_end=2013-12-31&date_start=2013-12-01&page=2")
+			fmt.Fprint(w, expectedTransactionsJSON)
+		})
+
+		start := Date(time.Date(2013, time.December, 1, 0, 0, 0, 0, time.UTC))
+		end := Date(time.Date(2013, time.December, 31, 0, 0, 0, 0, time.UTC))
+		opts := &TransactionListOptions{
+			AccountID:   3,
+			DateStart:   &start,
+			DateEnd:     &end,
+			PageOptions: PageOptions{Page: 2},
+		}
+		_, _, err := client.Transactions.List("1", opts)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("LIST all Transactions for a business invalid ID", t, func() {
+		_, resp, err := client.Transactions.List("%", nil)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("GET a specific Transaction", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/transactions/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedTransactionJSON)
+		})
+
+		transaction, _, err := client.Transactions.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(transaction, ShouldResemble, expectedTransactionStruct)
+	})
+
+	Convey("GET a specific Transaction with an invalid ID", t, func() {
+		transaction, resp, err := client.Transactions.Get("%", 1)
+		checkInvalidURLError(transaction, resp, err)
+	})
+
+	Convey("CREATE a Transaction", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/transactions/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			body, _ := ioutil.ReadAll(r.Body)
+			So(string(body), ShouldEqual, `{"date":"2013-12-05","lines":[{"account":{"id":1},"debit":1000},{"account":{"id":2},"credit":1000}]}`+"\n")
+			fmt.Fprint(w, expectedTransactionJSON)
+		})
+
+		date := Date(time.Date(2013, time.December, 5, 0, 0, 0, 0, time.UTC))
+		tr := &Transaction{
+			Date: &date,
+			Lines: []TransactionLine{
+				{Account: &Account{ID: Int(1)}, Debit: Amount("1000")},
+				{Account: &Account{ID: Int(2)}, Credit: Amount("1000")},
+			},
+		}
+		transaction, _, err := client.Transactions.Create("1", tr)
+		So(err, ShouldBeNil)
+		So(transaction, ShouldResemble, expectedTransactionStruct)
+	})
+
+	Convey("CREATE an unbalanced Transaction should not send a request", t, func() {
+		setUp()
+		defer tearDown()
+
+		called := false
+		mux.HandleFunc("/businesses/1/transactions/", func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})
+
+		tr := &Transaction{Lines: []TransactionLine{
+			{Account: &Account{ID: Int(1)}, Debit: Amount("1000")},
+		}}
+		transaction, resp, err := client.Transactions.Create("1", tr)
+		So(transaction, ShouldBeNil)
+		So(resp, ShouldBeNil)
+		_, ok := err.(*UnbalancedTransactionError)
+		So(ok, ShouldBeTrue)
+		So(called, ShouldBeFalse)
+	})
+
+	Convey("CREATE a Transaction with an invalid ID", t, func() {
+		tr := &Transaction{Lines: []TransactionLine{
+			{Account: &Account{ID: Int(1)}, Debit: Amount("1000")},
+			{Account: &Account{ID: Int(2)}, Credit: Amount("1000")},
+		}}
+		transaction, resp, err := client.Transactions.Create("%", tr)
+		checkInvalidURLError(transaction, resp, err)
+	})
+
+	Convey("DELETE a specific Transaction", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/transactions/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "DELETE")
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		_, err := client.Transactions.Delete("1", 1)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("DELETE a specific Transaction with an invalid ID", t, func() {
+		resp, err := client.Transactions.Delete("%", 1)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("String method on Transaction", t, func() {
+		tr := new(Transaction)
+		tr.Description = String("Owner investment")
+		tr.Lines = make([]TransactionLine, 2)
+		So(tr.String(), ShouldEqual, "Owner investment (2 lines)")
+	})
+}
@@ -6,7 +6,10 @@
 
 package wave
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // UsersService handles communication with the user related methods of the Wave API.
 //
@@ -20,17 +23,17 @@ type User struct {
 	ID           *string   `json:"id,omitempty"`
 	URL          *string   `json:"url,omitempty"`
 	FirstName    *string   `json:"first_name,omitempty"`
-	LastName     *string   `json:"first_name,omitempty"`
+	LastName     *string   `json:"last_name,omitempty"`
 	DateCreated  *DateTime `json:"date_created,omitempty"`
 	DateModified *DateTime `json:"date_modified,omitempty"`
 	LastLogin    *DateTime `json:"last_login,omitempty"`
 	Emails       []struct {
-		Email      *string `json:"email,omitempty"`
+		Email      *string `json:"email,omitempty" wave:"sensitive"`
 		IsVerified *bool   `json:"is_verified,omitempty"`
 		IsDefault  *bool   `json:"is_default,omitempty"`
 	} `json:"emails,omitempty"`
 	Profile struct {
-		DateOfBirth *Date `json:"date_of_birth,omitempty"`
+		DateOfBirth *Date `json:"date_of_birth,omitempty" wave:"sensitive"`
 	} `json:"profile,omitempty"`
 	Businesses []struct {
 		ID  *string `json:"id,omitempty"`
@@ -64,7 +67,13 @@ func (u User) String() string {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/users.html#get--user-
 func (service *UsersService) Get() (*User, *Response, error) {
-	req, err := service.client.NewRequest("GET", "user/", nil)
+	return service.GetContext(context.Background())
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *UsersService) GetContext(ctx context.Context) (*User, *Response, error) {
+	ctx = withOperation(ctx, "Users.Get")
+	req, err := service.client.NewRequestContext(ctx, "GET", "user/", nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -80,7 +89,13 @@ func (service *UsersService) Get() (*User, *Response, error) {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/users.html#put--user-
 func (service *UsersService) Replace(user *User) (*User, *Response, error) {
-	req, err := service.client.NewRequest("PUT", "user/", user)
+	return service.ReplaceContext(context.Background(), user)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *UsersService) ReplaceContext(ctx context.Context, user *User) (*User, *Response, error) {
+	ctx = withOperation(ctx, "Users.Replace")
+	req, err := service.client.NewRequestContext(ctx, "PUT", "user/", user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -96,7 +111,13 @@ func (service *UsersService) Replace(user *User) (*User, *Response, error) {
 //
 // Wave API docs: http://docs.waveapps.com/endpoints/users.html#patch--user-
 func (service *UsersService) Update(user *User) (*User, *Response, error) {
-	req, err := service.client.NewRequest("PATCH", "user/", user)
+	return service.UpdateContext(context.Background(), user)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *UsersService) UpdateContext(ctx context.Context, user *User) (*User, *Response, error) {
+	ctx = withOperation(ctx, "Users.Update")
+	req, err := service.client.NewRequestContext(ctx, "PATCH", "user/", user)
 	if err != nil {
 		return nil, nil, err
 	}
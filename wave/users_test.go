@@ -62,6 +62,8 @@ func TestUsersService(t *testing.T) {
 		user, _, err := client.Users.Get()
 		So(err, ShouldBeNil)
 		So(user, ShouldResemble, expectedUserStruct)
+		So(*user.FirstName, ShouldEqual, "Jane")
+		So(*user.LastName, ShouldEqual, "Smith")
 	})
 
 	Convey("REPLACE a User", t, func() {
@@ -0,0 +1,228 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"context"
+	"fmt"
+)
+
+// VendorsService handles communication with the vendor related methods of the Wave API.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html
+type VendorsService struct {
+	client *Client
+}
+
+// Vendor represents a supplier associated with a bill or transaction.
+type Vendor struct {
+	ID              uint64           `json:"id,omitempty"`
+	URL             *string          `json:"url,omitempty"`
+	AccountNumber   *string          `json:"account_number,omitempty"`
+	Name            *string          `json:"name,omitempty"`
+	FirstName       *string          `json:"first_name,omitempty"`
+	LastName        *string          `json:"last_name,omitempty"`
+	Email           *string          `json:"email,omitempty" wave:"sensitive"`
+	FaxNumber       *string          `json:"fax_number,omitempty" wave:"sensitive"`
+	MobileNumber    *string          `json:"mobile_number,omitempty" wave:"sensitive"`
+	PhoneNumber     *string          `json:"phone_number,omitempty" wave:"sensitive"`
+	TollFreeNumber  *string          `json:"toll_free_number,omitempty" wave:"sensitive"`
+	Website         *string          `json:"website,omitempty"`
+	Currency        *Currency        `json:"currency,omitempty"`
+	ShippingDetails *ShippingDetails `json:"shipping_details,omitempty"`
+	DateCreated     *DateTime        `json:"date_created,omitempty"`
+	DateModified    *DateTime        `json:"date_modified,omitempty"`
+	*Address
+}
+
+// FullName returns the full name of a vendor.
+//
+// Given a first and last name, FullName will return 'First Last'.
+// Given either a first or last name, FullName will return whichever is non-empty.
+func (v Vendor) FullName() string {
+	if v.Name != nil {
+		return *v.Name
+	}
+
+	if v.FirstName == nil && v.LastName == nil {
+		return ""
+	}
+	if v.FirstName == nil {
+		return *v.LastName
+	}
+	if v.LastName == nil {
+		return *v.FirstName
+	}
+
+	return fmt.Sprintf("%v %v", *v.FirstName, *v.LastName)
+}
+
+func (v Vendor) String() string {
+	fullName := v.FullName()
+	if v.Email == nil {
+		return fullName
+	}
+	if fullName != "" {
+		return fmt.Sprintf("%v (%v)", fullName, *v.Email)
+	}
+	return *v.Email
+}
+
+// VendorListOptions specifies the optional parameters to LIST endpoint.
+type VendorListOptions struct {
+	PageOptions
+}
+
+// List all vendors for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#get--businesses-{business_id}-vendors-
+func (service *VendorsService) List(businessID string, opts *VendorListOptions) ([]Vendor, *Response, error) {
+	return service.ListContext(context.Background(), businessID, opts)
+}
+
+// ListContext is like List but carries ctx through to the HTTP request.
+func (service *VendorsService) ListContext(ctx context.Context, businessID string, opts *VendorListOptions) ([]Vendor, *Response, error) {
+	ctx = withOperation(ctx, "Vendors.List")
+	url := fmt.Sprintf("businesses/%v/vendors/", businessID)
+	url, err := addOptions(url, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	vendors := new([]Vendor)
+	resp, err := service.client.Do(req, vendors)
+	if err != nil {
+		return nil, resp, err
+	}
+	return *vendors, resp, nil
+}
+
+// ListIter returns an Iterator over every vendor for a given business.
+// Pages are fetched as the iterator advances, starting from the page in opts.
+func (service *VendorsService) ListIter(businessID string, opts *VendorListOptions) *Iterator[Vendor] {
+	o := new(VendorListOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	return newIterator(o.PageOptions, func(ctx context.Context, page PageOptions) ([]Vendor, *Response, error) {
+		o.PageOptions = page
+		return service.ListContext(ctx, businessID, o)
+	})
+}
+
+// Get an existing vendor for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#get--businesses-{business_id}-vendors-{vendor_id}-
+func (service *VendorsService) Get(businessID string, vendorID uint64) (*Vendor, *Response, error) {
+	return service.GetContext(context.Background(), businessID, vendorID)
+}
+
+// GetContext is like Get but carries ctx through to the HTTP request.
+func (service *VendorsService) GetContext(ctx context.Context, businessID string, vendorID uint64) (*Vendor, *Response, error) {
+	ctx = withOperation(ctx, "Vendors.Get")
+	url := fmt.Sprintf("businesses/%v/vendors/%v/", businessID, vendorID)
+	req, err := service.client.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	vendor := new(Vendor)
+	resp, err := service.client.Do(req, vendor)
+	if err != nil {
+		return nil, resp, err
+	}
+	return vendor, resp, nil
+}
+
+// Create a new vendor for a given business.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#post--businesses-{business_id}-vendors-
+func (service *VendorsService) Create(businessID string, vendor *Vendor) (*Vendor, *Response, error) {
+	return service.CreateContext(context.Background(), businessID, vendor)
+}
+
+// CreateContext is like Create but carries ctx through to the HTTP request.
+func (service *VendorsService) CreateContext(ctx context.Context, businessID string, vendor *Vendor) (*Vendor, *Response, error) {
+	ctx = withOperation(ctx, "Vendors.Create")
+	url := fmt.Sprintf("businesses/%v/vendors/", businessID)
+	req, err := service.client.NewRequestContext(ctx, "POST", url, vendor)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := new(Vendor)
+	resp, err := service.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// Replace an existing vendor. You cannot create a vendor using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#put--businesses-{business_id}-vendors-{vendor_id}-
+func (service *VendorsService) Replace(businessID string, vendorID uint64, vendor *Vendor) (*Vendor, *Response, error) {
+	return service.ReplaceContext(context.Background(), businessID, vendorID, vendor)
+}
+
+// ReplaceContext is like Replace but carries ctx through to the HTTP request.
+func (service *VendorsService) ReplaceContext(ctx context.Context, businessID string, vendorID uint64, vendor *Vendor) (*Vendor, *Response, error) {
+	ctx = withOperation(ctx, "Vendors.Replace")
+	url := fmt.Sprintf("businesses/%v/vendors/%v/", businessID, vendorID)
+	req, err := service.client.NewRequestContext(ctx, "PUT", url, vendor)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := new(Vendor)
+	resp, err := service.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// Update an existing vendor. You cannot create a vendor using this method.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#patch--businesses-{business_id}-vendors-{vendor_id}-
+func (service *VendorsService) Update(businessID string, vendorID uint64, vendor *Vendor) (*Vendor, *Response, error) {
+	return service.UpdateContext(context.Background(), businessID, vendorID, vendor)
+}
+
+// UpdateContext is like Update but carries ctx through to the HTTP request.
+func (service *VendorsService) UpdateContext(ctx context.Context, businessID string, vendorID uint64, vendor *Vendor) (*Vendor, *Response, error) {
+	ctx = withOperation(ctx, "Vendors.Update")
+	url := fmt.Sprintf("businesses/%v/vendors/%v/", businessID, vendorID)
+	req, err := service.client.NewRequestContext(ctx, "PATCH", url, vendor)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := new(Vendor)
+	resp, err := service.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// Delete an existing Vendor.
+//
+// Wave API docs: http://docs.waveapps.com/endpoints/vendors.html#delete--businesses-{business_id}-vendors-{vendor_id}-
+func (service *VendorsService) Delete(businessID string, vendorID uint64) (*Response, error) {
+	return service.DeleteContext(context.Background(), businessID, vendorID)
+}
+
+// DeleteContext is like Delete but carries ctx through to the HTTP request.
+func (service *VendorsService) DeleteContext(ctx context.Context, businessID string, vendorID uint64) (*Response, error) {
+	ctx = withOperation(ctx, "Vendors.Delete")
+	url := fmt.Sprintf("businesses/%v/vendors/%v/", businessID, vendorID)
+	req, err := service.client.NewRequestContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return service.client.Do(req, nil)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wave
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	expectedVendorJSON = `{
+    "id": 1,
+    "url": "https://api.waveapps.com/businesses/c2731e5c-5001-4fe3-87ae-477f9a48dfcc/vendors/1/",
+    "account_number": "12345",
+    "name": "Gustavo Fring",
+    "first_name": "Gustavo",
+    "last_name": "Fring",
+    "email": "gus@lospolloshermanos.com",
+    "fax_number": "555-578-9112",
+    "mobile_number": "555-553-1212",
+    "phone_number": "555-578-9111",
+    "toll_free_number": "1-800-555-5555",
+    "website": "http://www.lospolloshermanos.com/",
+    "currency": {
+        "url": "https://api.waveapps.com/currencies/USD/",
+        "code": "USD",
+        "symbol": "$",
+        "name": "U.S. dollar"
+    },
+    "address": {
+        "address1": "1100 Central Avenue Southeast",
+        "address2": "",
+        "city": "Albuquerque",
+        "province": {
+            "name": "New Mexico",
+            "slug": "new-mexico"
+        },
+        "country": {
+            "name": "United States",
+            "country_code": "US",
+            "currency_code": "USD",
+            "url": "https://api.waveapps.com/countries/US/"
+        },
+        "postal_code": "87106"
+    },
+    "shipping_details": {
+        "ship_to_contact": "Gustavo Fring",
+        "delivery_instructions": "Leave it in a black bag by the tree.",
+        "phone_number": "555-553-1212",
+        "address": {
+            "address1": "1100 Central Avenue Southeast",
+            "address2": "",
+            "city": "Albuquerque",
+            "province": {
+                "name": "New Mexico",
+                "slug": "new-mexico"
+            },
+            "country": {
+                "name": "United States",
+                "country_code": "US",
+                "currency_code": "USD",
+                "url": "https://api.waveapps.com/countries/US/"
+            },
+            "postal_code": "87106"
+        }
+    },
+    "date_created": "2013-12-05T10:31:01+00:00",
+    "date_modified": "2013-12-05T13:37:59+00:00"
+}`
+	expectedVendorsJSON = "[" + expectedVendorJSON + "]"
+)
+
+func TestVendorsService(t *testing.T) {
+	expectedVendorStruct := new(Vendor)
+	json.Unmarshal([]byte(expectedVendorJSON), expectedVendorStruct)
+
+	Convey("LIST all Vendors for a business", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedVendorsJSON)
+		})
+
+		vendors, _, err := client.Vendors.List("1", nil)
+		v := []Vendor{*expectedVendorStruct}
+		So(err, ShouldEqual, nil)
+		So(vendors, ShouldResemble, v)
+	})
+
+	Convey("LIST all Vendors for a business invalid ID", t, func() {
+		_, resp, err := client.Vendors.List("%", nil)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("GET a specific Vendor", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "GET")
+			fmt.Fprint(w, expectedVendorJSON)
+		})
+
+		vendor, _, err := client.Vendors.Get("1", 1)
+		So(err, ShouldBeNil)
+		So(vendor, ShouldResemble, expectedVendorStruct)
+	})
+
+	Convey("GET a specific Vendor with an invalid ID", t, func() {
+		vendor, resp, err := client.Vendors.Get("%", 1)
+		checkInvalidURLError(vendor, resp, err)
+	})
+
+	Convey("CREATE a Vendor", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "POST")
+			fmt.Fprint(w, expectedVendorJSON)
+		})
+
+		v := &Vendor{}
+		vendors, _, err := client.Vendors.Create("1", v)
+		So(err, ShouldBeNil)
+		So(vendors, ShouldResemble, expectedVendorStruct)
+	})
+
+	Convey("CREATE a Vendor with an invalid ID", t, func() {
+		vendor, resp, err := client.Vendors.Create("%", &Vendor{})
+		checkInvalidURLError(vendor, resp, err)
+	})
+
+	Convey("REPLACE a Vendor", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PUT")
+			fmt.Fprint(w, expectedVendorJSON)
+		})
+
+		v := &Vendor{}
+		vendor, _, err := client.Vendors.Replace("1", 1, v)
+		So(err, ShouldBeNil)
+		So(vendor, ShouldResemble, expectedVendorStruct)
+	})
+
+	Convey("REPLACE a Vendor with an invalid ID", t, func() {
+		vendor, resp, err := client.Vendors.Replace("%", 1, &Vendor{})
+		checkInvalidURLError(vendor, resp, err)
+	})
+
+	Convey("UPDATE a Vendor", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "PATCH")
+			fmt.Fprint(w, expectedVendorJSON)
+		})
+
+		v := &Vendor{}
+		vendor, _, err := client.Vendors.Update("1", 1, v)
+		So(err, ShouldEqual, nil)
+		So(vendor, ShouldResemble, expectedVendorStruct)
+	})
+
+	Convey("UPDATE a Vendor with an invalid ID", t, func() {
+		vendor, resp, err := client.Vendors.Update("%", 1, &Vendor{})
+		checkInvalidURLError(vendor, resp, err)
+	})
+
+	Convey("DELETE a specific Vendor", t, func() {
+		setUp()
+		defer tearDown()
+
+		mux.HandleFunc("/businesses/1/vendors/1/", func(w http.ResponseWriter, r *http.Request) {
+			So(r.Method, ShouldEqual, "DELETE")
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		_, err := client.Vendors.Delete("1", 1)
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("DELETE a specific Vendor with an invalid ID", t, func() {
+		resp, err := client.Vendors.Delete("%", 1)
+		checkInvalidURLError(nil, resp, err)
+	})
+
+	Convey("GET a specific Vendor with a cancelled context", t, func() {
+		vendor, resp, err := client.Vendors.GetContext(canceledContext(), "1", 1)
+		checkCanceledError(vendor, resp, err)
+	})
+
+	Convey("FullName method on Vendor", t, func() {
+		Convey("No FirstName and LastName should return ''", func() {
+			v := new(Vendor)
+			So(v.FullName(), ShouldBeBlank)
+		})
+		Convey("Only Name should return 'Name'", func() {
+			v := new(Vendor)
+			v.Name = String("Foo Bar")
+			So(v.FullName(), ShouldEqual, "Foo Bar")
+		})
+		Convey("No FirstName should return 'LastName'", func() {
+			v := new(Vendor)
+			v.LastName = String("Bar")
+			So(v.FullName(), ShouldEqual, "Bar")
+		})
+		Convey("No LastName should return 'FirstName'", func() {
+			v := new(Vendor)
+			v.FirstName = String("Foo")
+			So(v.FullName(), ShouldEqual, "Foo")
+		})
+		Convey("FirstName and LastName should return 'FirstName LastName'", func() {
+			v := new(Vendor)
+			v.FirstName = String("Foo")
+			v.LastName = String("Bar")
+			So(v.FullName(), ShouldEqual, "Foo Bar")
+		})
+	})
+
+	Convey("String method on Vendor", t, func() {
+		Convey("FirstName, LastName, Email should return 'FirstName LastName (Email)'", func() {
+			v := new(Vendor)
+			v.FirstName = String("Foo")
+			v.LastName = String("Bar")
+			v.Email = String("foo@example.com")
+			So(v.String(), ShouldEqual, "Foo Bar (foo@example.com)")
+		})
+
+		Convey("No Email should return 'FirstName LastName'", func() {
+			v := new(Vendor)
+			v.FirstName = String("Foo")
+			v.LastName = String("Bar")
+			So(v.String(), ShouldEqual, "Foo Bar")
+		})
+
+		Convey("No FirstName, LastName should return Email", func() {
+			v := new(Vendor)
+			v.Email = String("foo@example.com")
+			So(v.String(), ShouldEqual, "foo@example.com")
+		})
+
+		Convey("No FirstName, LastName, Email should return ''", func() {
+			v := new(Vendor)
+			So(v.String(), ShouldBeBlank)
+		})
+	})
+}
@@ -8,6 +8,7 @@ package wave
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -50,14 +51,39 @@ type Client struct {
 	// User agent used when communicating with the Wave API.
 	UserAgent string
 
+	// RetryPolicy controls how requests which fail with a transient error
+	// are retried. Requests are not retried if it is nil.
+	RetryPolicy *RetryPolicy
+
+	// RateLimiter, if set, is waited on before every request is sent,
+	// including retries.
+	RateLimiter *RateLimiter
+
+	// Reference is the reference data of currencies and countries used by
+	// CurrenciesService.Lookup and CountriesService.Lookup. The data bundled
+	// with the package is used if it is nil.
+	Reference *ReferenceData
+
+	// Cache, if set, caches the responses of GET requests, and is invalidated
+	// by the other requests the client sends.
+	Cache *Cache
+
+	// Middleware is run around every request sent by Do, the first outermost.
+	// It must not be changed while requests are being sent.
+	Middleware []Middleware
+
 	// Services used to communicate with different parts of the Wave API
-	Accounts   *AccountsService
-	Businesses *BusinessesService
-	Countries  *CountriesService
-	Currencies *CurrenciesService
-	Customers  *CustomersService
-	Products   *ProductsService
-	Users      *UsersService
+	Accounts     *AccountsService
+	Bills        *BillsService
+	Businesses   *BusinessesService
+	Countries    *CountriesService
+	Currencies   *CurrenciesService
+	Customers    *CustomersService
+	Invoices     *InvoicesService
+	Products     *ProductsService
+	Transactions *TransactionsService
+	Users        *UsersService
+	Vendors      *VendorsService
 }
 
 // PageOptions specifies the pagination options for methods that support pagination (mostly LIST and GET options)
@@ -88,27 +114,48 @@ func addOptions(s string, opt interface{}) (string, error) {
 	return u.String(), nil
 }
 
-// Response is a Wave API response. This embeds the standard http.Response and provides pagination information.
+// Response is a Wave API response. This embeds the standard http.Response and provides pagination
+// and rate limit information.
 type Response struct {
 	NextPage     int
 	PreviousPage int
 	FirstPage    int
 	LastPage     int
 	TotalCount   int
+	Rate         Rate
 	*http.Response
 }
 
 // ErrorResponse represents a single error returned from the API.
+//
+// Use errors.Is with ErrNotFound, ErrValidation and the other sentinel errors
+// to check what kind of error it is, and errors.As with *NotFoundError,
+// *ValidationError and the other typed errors to get at its details.
 type ErrorResponse struct {
 	Response *http.Response
 	Err      struct {
 		Message string `json:"message"`
 	} `json:"error"`
+
+	// Fields holds the messages for each rejected field of a validation
+	// error, keyed by the JSON name of the field. Nested fields are joined
+	// with dots, such as "address.city". It is nil for other errors.
+	Fields map[string][]string `json:"-"`
+
+	// Body is the raw response body, kept for debugging.
+	Body []byte `json:"-"`
 }
 
 func (resp *ErrorResponse) Error() string {
+	message := resp.Err.Message
+	if message == "" {
+		message = http.StatusText(resp.Response.StatusCode)
+	}
+	if len(resp.Fields) > 0 {
+		message = fmt.Sprintf("%v (%v)", message, resp.fieldSummary())
+	}
 	return fmt.Sprintf("%v %v: %d %+v",
-		resp.Response.Request.Method, resp.Response.Request.URL, resp.Response.StatusCode, resp.Err.Message)
+		resp.Response.Request.Method, resp.Response.Request.URL, resp.Response.StatusCode, message)
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -153,11 +200,19 @@ func (d Date) MarshalJSON() ([]byte, error) {
 	return []byte(trueTime.Format(`"2006-01-02"`)), nil
 }
 
+// EncodeValues implements the query.Encoder interface so that a Date can be
+// used in the optional parameters of an endpoint.
+// Date will be formatted as ISO-8601.
+func (d Date) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, time.Time(d).Format("2006-01-02"))
+	return nil
+}
+
 // NewClient returns a new Wave API client.
 // If a nil httpClient is provided, http.DefaultClient will be used.
 // To use API methods which require
 // authentication, provide an http.Client that will perform the authentication
-// for you (such as that provided by the goauth2 library).
+// for you (such as that provided by the wave/auth package).
 func NewClient(client *http.Client) *Client {
 	if client == nil {
 		client = http.DefaultClient
@@ -167,12 +222,16 @@ func NewClient(client *http.Client) *Client {
 
 	c := &Client{client: client, BaseURL: baseURL, UserAgent: userAgent}
 	c.Accounts = &AccountsService{client: c}
+	c.Bills = &BillsService{client: c}
 	c.Businesses = &BusinessesService{client: c}
 	c.Countries = &CountriesService{client: c}
 	c.Currencies = &CurrenciesService{client: c}
 	c.Customers = &CustomersService{client: c}
+	c.Invoices = &InvoicesService{client: c}
 	c.Products = &ProductsService{client: c}
+	c.Transactions = &TransactionsService{client: c}
 	c.Users = &UsersService{client: c}
+	c.Vendors = &VendorsService{client: c}
 
 	return c
 }
@@ -183,6 +242,13 @@ func NewClient(client *http.Client) *Client {
 // If specified, the value pointed to by body is JSON encoded and included as the
 // request body.
 func (c *Client) NewRequest(method string, urlStr string, body interface{}) (*http.Request, error) {
+	return c.NewRequestContext(context.Background(), method, urlStr, body)
+}
+
+// NewRequestContext is like NewRequest but attaches ctx to the request. Do
+// passes the context down to the underlying http.Client, so cancelling ctx
+// or letting its deadline expire aborts the request.
+func (c *Client) NewRequestContext(ctx context.Context, method string, urlStr string, body interface{}) (*http.Request, error) {
 	rel, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
@@ -197,7 +263,7 @@ func (c *Client) NewRequest(method string, urlStr string, body interface{}) (*ht
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -207,17 +273,59 @@ func (c *Client) NewRequest(method string, urlStr string, body interface{}) (*ht
 	return req, nil
 }
 
+// operationKey is the context key of the operation of a request.
+type operationKey struct{}
+
+// withOperation returns a copy of ctx recording op, such as "Customers.List",
+// as the operation of the requests created with it.
+func withOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// Operation returns the service and method which created req, such as
+// "Customers.List", or "" if req was not created by a service.
+func Operation(req *http.Request) string {
+	op, _ := req.Context().Value(operationKey{}).(string)
+	return op
+}
+
 func newResponse(resp *http.Response) *Response {
 	r := &Response{Response: resp}
 	r.populatePageValues()
+	r.populateRateValues()
 	return r
 }
 
 // Do sends an API request and returns the API response.
 // The API response is decoded and stored in the value pointed to by v, or returned
 // as an error if an API error has occured.
+//
+// If the request's context is cancelled or its deadline is exceeded, the
+// context's error (context.Canceled or context.DeadlineExceeded) is returned
+// rather than the transport error, so it can be told apart from API errors.
+//
+// Requests which fail with a transient error are retried according to the
+// client's RetryPolicy. The client's Middleware runs around all of it.
 func (c *Client) Do(request *http.Request, v interface{}) (*Response, error) {
-	resp, err := c.client.Do(request)
+	return c.handler(c.do)(request, v)
+}
+
+// do sends the request and decodes the response, as Do does without the
+// client's Middleware.
+func (c *Client) do(request *http.Request, v interface{}) (*Response, error) {
+	var resp *http.Response
+	var err error
+	if c.Cache != nil {
+		resp, err = c.Cache.send(c, request)
+	} else {
+		resp, err = c.send(request)
+	}
+	// The response to a change is still returned when the cache could not
+	// be invalidated after it, with the InvalidationError instead of nil.
+	var invalidation *InvalidationError
+	if errors.As(err, &invalidation) {
+		err = nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +347,9 @@ func (c *Client) Do(request *http.Request, v interface{}) (*Response, error) {
 	if v != nil {
 		err = json.Unmarshal(bufBytes, &v)
 	}
+	if err == nil && invalidation != nil {
+		err = invalidation
+	}
 
 	return response, err
 }
@@ -291,9 +402,10 @@ func (r *Response) populatePageValues() {
 
 // CheckResponse checks the API response for errors, and returns them if
 // present. A response is considered an error if it has a status code outside
-// the 200 range. API error responses are expected to have either no response
-// body, or a JSON response body that maps to ErrorResponse. Any other
-// response body will be silently ignored.
+// the 200 range, and is returned as an *ErrorResponse. The message and any
+// per-field validation messages are parsed from a JSON body; the raw body is
+// kept in ErrorResponse.Body whatever its format, and put back into
+// resp.Body so that it can be read again.
 func CheckResponse(resp *http.Response) error {
 	if code := resp.StatusCode; 200 <= code && code <= 299 {
 		return nil
@@ -301,7 +413,9 @@ func CheckResponse(resp *http.Response) error {
 	errorResponse := &ErrorResponse{Response: resp}
 	data, err := ioutil.ReadAll(resp.Body)
 	if err == nil && data != nil {
-		json.Unmarshal(data, errorResponse)
+		errorResponse.Body = data
+		errorResponse.parseErrorBody(data)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(data))
 	}
 	return errorResponse
 }
@@ -320,6 +434,13 @@ func Int(v int) *int {
 	return p
 }
 
+// Uint64 is a helper method that allocates a new uint64 value and returns a pointer to it.
+func Uint64(v uint64) *uint64 {
+	p := new(uint64)
+	*p = v
+	return p
+}
+
 // Float64 is a helper method that allocates a new float64 value and returns a pointer to it.
 func Float64(v float64) *float64 {
 	p := new(float64)
@@ -327,9 +448,27 @@ func Float64(v float64) *float64 {
 	return p
 }
 
+// Amount is a helper method that parses a decimal amount, such as "42.34", into
+// a new Money value without a currency and returns a pointer to it. It panics
+// if v is not a valid amount, so it is meant for literals.
+func Amount(v string) *Money {
+	p := new(Money)
+	*p = MustParseMoney(v, "")
+	return p
+}
+
 // String is a helper method that allocates a new string value and returns a pointer to it.
 func String(v string) *string {
 	p := new(string)
 	*p = v
 	return p
 }
+
+// deref returns the value p points to, or the zero value if p is nil, so that
+// the String methods of partly filled in resources do not panic.
+func deref[T any](p *T) (v T) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
@@ -8,21 +8,23 @@ package wave
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
-	"code.google.com/p/goauth2/oauth"
+	"github.com/NickPresta/gowave/wave/auth"
+	"github.com/NickPresta/gowave/wave/cassette"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -40,47 +42,6 @@ var (
 	server *httptest.Server
 )
 
-type CachedContent struct {
-	Response *http.Response
-	Error    error
-}
-
-type CachedResponseTransport struct {
-	Transport http.RoundTripper
-	cache     map[string]*CachedContent
-}
-
-func NewCachedResponseTransport() *CachedResponseTransport {
-	return &CachedResponseTransport{cache: make(map[string]*CachedContent)}
-}
-
-func (t *CachedResponseTransport) RoundTrip(r *http.Request) (*http.Response, error) {
-	u, err := url.Parse(r.URL.String())
-	if err != nil {
-		return nil, err
-	}
-	key := fmt.Sprintf("%v %v", r.Method, u.String())
-
-	cachedContent, inCache := t.cache[key]
-	if inCache {
-		log.Printf("Found '%v' in cache\n", key)
-		return cachedContent.Response, cachedContent.Error
-	}
-
-	log.Printf("Not in cache; making round trip for '%v'\n", key)
-	resp, err := t.Transport.RoundTrip(r)
-	if err != nil {
-		return nil, err
-	}
-
-	if code := resp.StatusCode; code >= 400 || code < 300 {
-		log.Printf("Storing in cache: '%v'\n", key)
-		t.cache[key] = &CachedContent{Response: resp, Error: err}
-	}
-
-	return resp, err
-}
-
 type data struct {
 	I int
 }
@@ -96,19 +57,35 @@ func setUp() {
 	client.BaseURL = url
 }
 
-func setUpIntegrations() {
-	if os.Getenv("WAVE_API_ACCESS_TOKEN") == "" {
-		panic("You must provide a WAVE_API_ACCESS_TOKEN environment variable for integration tests")
+// setUpIntegrations points integrationClient at the Wave API through a
+// cassette named after the test, in testdata/cassettes. By default the
+// recorded responses are replayed, without a network connection or a token,
+// and the test is skipped if there are none. With a WAVE_API_ACCESS_TOKEN the
+// real API is used and the cassette is recorded again. The returned func
+// saves the cassette, and must be called when the test finishes.
+func setUpIntegrations(t *testing.T) func() {
+	path := filepath.Join("testdata", "cassettes", t.Name()+".json")
+	token := os.Getenv("WAVE_API_ACCESS_TOKEN")
+	mode := cassette.ModeReplay
+	if token != "" {
+		mode = cassette.ModeRecord
+	}
+
+	recorder, err := cassette.New(path, mode)
+	if os.IsNotExist(err) {
+		t.Skipf("No cassette at %v; provide a WAVE_API_ACCESS_TOKEN environment variable to record one", path)
 	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Transport = auth.StaticClient(context.Background(), token).Transport
+	integrationClient = NewClient(recorder.Client())
 
-	transport := &oauth.Transport{
-		Config:    &oauth.Config{},
-		Transport: http.DefaultTransport,
-		Token:     &oauth.Token{AccessToken: os.Getenv("WAVE_API_ACCESS_TOKEN")},
+	return func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
 	}
-	cachedTransport := NewCachedResponseTransport()
-	cachedTransport.Transport = transport
-	integrationClient = NewClient(&http.Client{Transport: cachedTransport})
 }
 
 func tearDown() {
@@ -125,6 +102,19 @@ func checkInvalidURLError(v interface{}, resp *Response, err error) {
 	So(typeErr.Op, ShouldEqual, "parse")
 }
 
+func checkCanceledError(v interface{}, resp *Response, err error) {
+	So(v, ShouldBeNil)
+	So(resp, ShouldBeNil)
+	So(err, ShouldEqual, context.Canceled)
+}
+
+// canceledContext returns a context that has already been cancelled.
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
 func checkMarshalJSON(v interface{}, e string) {
 	want, err := json.Marshal(v)
 	So(err, ShouldBeNil)
@@ -296,6 +286,26 @@ func TestNewRequest(t *testing.T) {
 	})
 }
 
+func TestNewRequestContext(t *testing.T) {
+	Convey("Making a NewRequestContext should attach the context", t, func() {
+		c := NewClient(nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := c.NewRequestContext(ctx, "GET", "foo/", nil)
+		So(err, ShouldBeNil)
+		So(req.Context(), ShouldEqual, ctx)
+		So(req.URL.String(), ShouldEqual, defaultBaseURL+"foo/")
+	})
+
+	Convey("NewRequest should use a background context", t, func() {
+		c := NewClient(nil)
+		req, err := c.NewRequest("GET", "foo/", nil)
+		So(err, ShouldBeNil)
+		So(req.Context(), ShouldResemble, context.Background())
+	})
+}
+
 func TestDo(t *testing.T) {
 	Convey("Making a request with a good response", t, func() {
 		setUp()
@@ -325,6 +335,36 @@ func TestDo(t *testing.T) {
 		_, err := client.Do(&http.Request{}, nil)
 		So(err, ShouldNotBeNil)
 	})
+	Convey("Making a request with a cancelled context", t, func() {
+		setUp()
+		defer tearDown()
+		called := false
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})
+		req, _ := client.NewRequestContext(canceledContext(), "GET", "/", nil)
+		resp, err := client.Do(req, nil)
+		So(resp, ShouldBeNil)
+		So(err, ShouldEqual, context.Canceled)
+		So(called, ShouldBeFalse)
+	})
+	Convey("Making a request that exceeds its deadline", t, func() {
+		setUp()
+		defer tearDown()
+		done := make(chan struct{})
+		defer close(done)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, _ := client.NewRequestContext(ctx, "GET", "/", nil)
+		_, err := client.Do(req, nil)
+		So(err == context.DeadlineExceeded, ShouldBeTrue)
+	})
 }
 
 func TestCheckResponse(t *testing.T) {
@@ -552,6 +592,18 @@ func TestTypeHelpers(t *testing.T) {
 		So(reflect.Indirect(v).Int(), ShouldEqual, 42)
 	})
 
+	Convey("Uint64 should return a pointer to a uint64", t, func() {
+		v := reflect.ValueOf(Uint64(0))
+		So(v.Kind(), ShouldEqual, reflect.Ptr)
+		So(v.IsNil(), ShouldBeFalse)
+		So(reflect.Indirect(v).Uint(), ShouldEqual, 0)
+
+		v = reflect.ValueOf(Uint64(42))
+		So(v.Kind(), ShouldEqual, reflect.Ptr)
+		So(v.IsNil(), ShouldBeFalse)
+		So(reflect.Indirect(v).Uint(), ShouldEqual, 42)
+	})
+
 	Convey("Float64 should return a pointer to a float64", t, func() {
 		v := reflect.ValueOf(Float64(1.0))
 		So(v.Kind(), ShouldEqual, reflect.Ptr)
@@ -573,4 +625,13 @@ func TestTypeHelpers(t *testing.T) {
 		So(v.IsNil(), ShouldBeFalse)
 		So(reflect.Indirect(v).Float(), ShouldEqual, 42.0)
 	})
+
+	Convey("Amount should return a pointer to a Money", t, func() {
+		m := Amount("42.34")
+		So(m, ShouldNotBeNil)
+		So(m.Decimal(), ShouldEqual, "42.34")
+		So(m.Currency(), ShouldBeBlank)
+
+		So(func() { Amount("forty two") }, ShouldPanic)
+	})
 }
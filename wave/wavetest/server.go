@@ -0,0 +1,408 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package wavetest provides an in-memory fake of the Wave API for testing code
+which uses the wave package.
+
+A Server keeps businesses, accounts, customers, products, the user, currencies
+and countries in memory and serves them with the same endpoints, status codes,
+error bodies and Link-header pagination as the Wave API, so that code under
+test can create a customer and read it back without any canned responses:
+
+	srv := wavetest.NewServer()
+	defer srv.Close()
+
+	business := srv.AddBusiness(&wave.Business{
+		CompanyName:         wave.String("Acme"),
+		PrimaryCurrencyCode: wave.String("USD"),
+	})
+
+	client := srv.Client()
+	customer, _, err := client.Customers.Create(*business.ID, &wave.Customer{
+		Name: wave.String("Jane Doe"),
+	})
+
+Failures can be injected to exercise error handling, such as a rate limit on
+the next LIST of the business's customers:
+
+	srv.Fail(wavetest.Failure{
+		Method: "GET",
+		Path:   "/businesses/" + *business.ID + "/customers/",
+		Status: http.StatusTooManyRequests,
+		Header: http.Header{"Retry-After": {"1"}},
+	})
+*/
+package wavetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// DefaultPageSize is the number of items on each page of a paginated LIST
+// endpoint when the request does not give a page_size.
+const DefaultPageSize = 10
+
+// Server is a fake Wave API. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port with
+	// no trailing slash.
+	URL string
+
+	// AccessToken, if set, must be sent as a bearer token with every request.
+	// Requests without it are answered with 401 Unauthorized.
+	AccessToken string
+
+	server *httptest.Server
+
+	mu         sync.Mutex
+	nextID     uint64
+	businesses *collection
+	children   map[string]map[*resource]*collection
+	user       map[string]interface{}
+	currencies []wave.Currency
+	countries  []wave.Country
+	failures   []*Failure
+}
+
+// NewServer starts and returns a new Server, seeded with a user and some
+// common currencies and countries. The caller should call Close when finished,
+// to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		businesses: new(collection),
+		children:   make(map[string]map[*resource]*collection),
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	s.seed()
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a wave.Client which sends its requests to the server.
+func (s *Server) Client() *wave.Client {
+	client := wave.NewClient(s.server.Client())
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+	return client
+}
+
+// Failure describes an error response the server sends instead of handling a
+// matching request.
+type Failure struct {
+	// Method is the HTTP method of the requests to fail. Any method matches
+	// if it is empty.
+	Method string
+
+	// Path is a path.Match pattern for the paths of the requests to fail,
+	// such as "/businesses/*/customers/". Any path matches if it is empty.
+	Path string
+
+	// Status is the status code of the response.
+	Status int
+
+	// Header is added to the headers of the response.
+	Header http.Header
+
+	// Body is the body of the response. It defaults to an API error body
+	// with the status text as its message.
+	Body string
+
+	// Times is how many matching requests fail before the failure is
+	// removed. Zero fails the next matching request only, and a negative
+	// value fails every matching request until ClearFailures is called.
+	Times int
+}
+
+// Fail makes the server fail matching requests as described by f. Failures
+// are matched in the order they were added.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times == 0 {
+		f.Times = 1
+	}
+	s.failures = append(s.failures, &f)
+}
+
+// ClearFailures removes all failures added by Fail.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+}
+
+// failure returns the failure matching r, if any, and counts it as used.
+func (s *Server) failure(r *http.Request) *Failure {
+	for i, f := range s.failures {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if f.Path != "" {
+			if ok, _ := path.Match(f.Path, r.URL.Path); !ok {
+				continue
+			}
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i:i], s.failures[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface, so that the fake API can
+// also be mounted on another server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if f := s.failure(r); f != nil {
+		for key, values := range f.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(f.Status)
+		if f.Body != "" {
+			w.Write([]byte(f.Body))
+		} else {
+			writeJSON(w, errorBody(http.StatusText(f.Status), nil))
+		}
+		return
+	}
+	if s.AccessToken != "" && r.Header.Get("Authorization") != "Bearer "+s.AccessToken {
+		writeError(w, http.StatusUnauthorized, "Authentication credentials were not provided.", nil)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case segments[0] == "businesses" && len(segments) <= 4:
+		s.serveBusinesses(w, r, segments[1:])
+	case segments[0] == "user" && len(segments) == 1:
+		s.serveUser(w, r)
+	case segments[0] == "currencies" && len(segments) <= 2:
+		s.serveCurrencies(w, r, segments[1:])
+	case segments[0] == "countries" && len(segments) <= 2:
+		s.serveCountries(w, r, segments[1:])
+	default:
+		writeError(w, http.StatusNotFound, "Not found.", nil)
+	}
+}
+
+func (s *Server) serveBusinesses(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 {
+		s.serveCollection(w, r, businessResource, "", s.businesses)
+		return
+	}
+	business := s.businesses.find(segments[0])
+	if business == nil {
+		writeError(w, http.StatusNotFound, "Not found.", nil)
+		return
+	}
+	if len(segments) == 1 {
+		s.serveRecord(w, r, businessResource, "", s.businesses, business)
+		return
+	}
+
+	var res *resource
+	for _, child := range childResources {
+		if child.name == segments[1] {
+			res = child
+		}
+	}
+	if res == nil {
+		writeError(w, http.StatusNotFound, "Not found.", nil)
+		return
+	}
+	coll := s.children[business.id][res]
+	if len(segments) == 2 {
+		s.serveCollection(w, r, res, business.id, coll)
+		return
+	}
+	rec := coll.find(segments[2])
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "Not found.", nil)
+		return
+	}
+	s.serveRecord(w, r, res, business.id, coll, rec)
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, res *resource, businessID string, coll *collection) {
+	switch r.Method {
+	case "GET":
+		items := make([]interface{}, len(coll.records))
+		for i, rec := range coll.records {
+			items[i] = s.present(res, businessID, rec, r.URL.Query())
+		}
+		if res.paged {
+			var ok bool
+			if items, ok = s.paginate(w, r, items); !ok {
+				return
+			}
+		}
+		writeJSON(w, items)
+	case "POST":
+		data, ok := readObject(w, r)
+		if !ok {
+			return
+		}
+		rec, fields := s.create(res, businessID, coll, data)
+		if fields != nil {
+			writeError(w, http.StatusBadRequest, "Invalid data", fields)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, s.present(res, businessID, rec, r.URL.Query()))
+	default:
+		notAllowed(w, r, "GET, POST")
+	}
+}
+
+func (s *Server) serveRecord(w http.ResponseWriter, r *http.Request, res *resource, businessID string, coll *collection, rec *record) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, s.present(res, businessID, rec, r.URL.Query()))
+	case "PUT", "PATCH":
+		data, ok := readObject(w, r)
+		if !ok {
+			return
+		}
+		if fields := s.save(res, businessID, rec, data, r.Method == "PATCH"); fields != nil {
+			writeError(w, http.StatusBadRequest, "Invalid data", fields)
+			return
+		}
+		writeJSON(w, s.present(res, businessID, rec, r.URL.Query()))
+	case "DELETE":
+		if !res.deletable {
+			notAllowed(w, r, "GET, PUT, PATCH")
+			return
+		}
+		if canDelete, ok := rec.data["can_delete"].(bool); ok && !canDelete {
+			writeError(w, http.StatusBadRequest, "This "+res.singular+" cannot be deleted.", nil)
+			return
+		}
+		coll.remove(rec)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		if res.deletable {
+			notAllowed(w, r, "GET, PUT, PATCH, DELETE")
+		} else {
+			notAllowed(w, r, "GET, PUT, PATCH")
+		}
+	}
+}
+
+func (s *Server) serveUser(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "PUT", "PATCH":
+		data, ok := readObject(w, r)
+		if !ok {
+			return
+		}
+		s.saveUser(data, r.Method == "PATCH")
+	default:
+		notAllowed(w, r, "GET, PUT, PATCH")
+		return
+	}
+	user := copyObject(s.user)
+	list := make([]interface{}, len(s.businesses.records))
+	for i, rec := range s.businesses.records {
+		list[i] = map[string]interface{}{"id": rec.data["id"], "url": rec.data["url"]}
+	}
+	user["businesses"] = list
+	writeJSON(w, user)
+}
+
+func (s *Server) serveCurrencies(w http.ResponseWriter, r *http.Request, segments []string) {
+	if r.Method != "GET" {
+		notAllowed(w, r, "GET")
+		return
+	}
+	if len(segments) == 0 {
+		writeJSON(w, s.currencies)
+		return
+	}
+	if c := s.currency(segments[0]); c != nil {
+		writeJSON(w, c)
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not found.", nil)
+}
+
+func (s *Server) serveCountries(w http.ResponseWriter, r *http.Request, segments []string) {
+	if r.Method != "GET" {
+		notAllowed(w, r, "GET")
+		return
+	}
+	if len(segments) == 0 {
+		writeJSON(w, s.countries)
+		return
+	}
+	for _, c := range s.countries {
+		if c.CountryCode != nil && strings.EqualFold(*c.CountryCode, segments[0]) {
+			writeJSON(w, c)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not found.", nil)
+}
+
+// readObject decodes the JSON object in the body of r, responding with a 400
+// and returning false if it is not one.
+func readObject(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	var data map[string]interface{}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil || data == nil {
+		message := "Expected a JSON object."
+		if err != nil {
+			message = "JSON parse error - " + err.Error()
+		}
+		writeError(w, http.StatusBadRequest, message, nil)
+		return nil, false
+	}
+	return data, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorBody returns an error in the form the Wave API reports it.
+func errorBody(message string, fields map[string][]string) interface{} {
+	e := map[string]interface{}{"message": message}
+	if fields != nil {
+		e["fields"] = fields
+	}
+	return map[string]interface{}{"error": e}
+}
+
+func writeError(w http.ResponseWriter, status int, message string, fields map[string][]string) {
+	w.WriteHeader(status)
+	writeJSON(w, errorBody(message, fields))
+}
+
+func notAllowed(w http.ResponseWriter, r *http.Request, allow string) {
+	w.Header().Set("Allow", allow)
+	writeError(w, http.StatusMethodNotAllowed, `Method "`+r.Method+`" not allowed.`, nil)
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wavetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	// srv is the fake API being tested.
+	srv *Server
+
+	// client is the Wave client pointed at srv.
+	client *wave.Client
+
+	// businessID is the ID of a business added to srv.
+	businessID string
+)
+
+func setUp() {
+	srv = NewServer()
+	client = srv.Client()
+	business := srv.AddBusiness(&wave.Business{
+		CompanyName:         wave.String("Acme"),
+		PrimaryCurrencyCode: wave.String("USD"),
+	})
+	businessID = *business.ID
+}
+
+func tearDown() {
+	srv.Close()
+}
+
+func TestBusinesses(t *testing.T) {
+	Convey("Businesses should be created, read and updated", t, func() {
+		setUp()
+		defer tearDown()
+
+		created, resp, err := client.Businesses.Create(&wave.Business{CompanyName: wave.String("Globex")})
+		So(err, ShouldBeNil)
+		So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+		So(*created.ID, ShouldNotBeBlank)
+		So(*created.URL, ShouldEqual, srv.URL+"/businesses/"+*created.ID+"/")
+		So(created.DateCreated, ShouldNotBeNil)
+
+		business, _, err := client.Businesses.Get(*created.ID)
+		So(err, ShouldBeNil)
+		So(*business.CompanyName, ShouldEqual, "Globex")
+
+		updated, _, err := client.Businesses.Update(*created.ID, &wave.Business{Website: wave.String("globex.example.com")})
+		So(err, ShouldBeNil)
+		So(*updated.CompanyName, ShouldEqual, "Globex")
+		So(*updated.Website, ShouldEqual, "globex.example.com")
+
+		businesses, _, err := client.Businesses.List(nil)
+		So(err, ShouldBeNil)
+		So(len(businesses), ShouldEqual, 2)
+
+		_, _, err = client.Businesses.Get("missing")
+		So(errors.Is(err, wave.ErrNotFound), ShouldBeTrue)
+	})
+}
+
+func TestCustomers(t *testing.T) {
+	Convey("Customers should have real CRUD semantics", t, func() {
+		setUp()
+		defer tearDown()
+
+		created, _, err := client.Customers.Create(businessID, &wave.Customer{
+			Name:  wave.String("Jane Doe"),
+			Email: wave.String("jane@example.com"),
+		})
+		So(err, ShouldBeNil)
+		So(created.ID, ShouldNotEqual, 0)
+		So(*created.Currency.Code, ShouldEqual, "USD")
+
+		Convey("Reading it back should return what was created", func() {
+			customer, _, err := client.Customers.Get(businessID, created.ID)
+			So(err, ShouldBeNil)
+			So(customer, ShouldResemble, created)
+		})
+
+		Convey("Updating should only change the given fields", func() {
+			customer, _, err := client.Customers.Update(businessID, created.ID, &wave.Customer{Website: wave.String("example.com")})
+			So(err, ShouldBeNil)
+			So(*customer.Email, ShouldEqual, "jane@example.com")
+			So(*customer.Website, ShouldEqual, "example.com")
+		})
+
+		Convey("Replacing should drop the missing fields", func() {
+			customer, _, err := client.Customers.Replace(businessID, created.ID, &wave.Customer{Name: wave.String("Jane Smith")})
+			So(err, ShouldBeNil)
+			So(*customer.Name, ShouldEqual, "Jane Smith")
+			So(customer.Email, ShouldBeNil)
+			So(customer.ID, ShouldEqual, created.ID)
+			So(time.Time(*customer.DateCreated), ShouldResemble, time.Time(*created.DateCreated))
+		})
+
+		Convey("Deleting should remove it", func() {
+			resp, err := client.Customers.Delete(businessID, created.ID)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNoContent)
+
+			_, _, err = client.Customers.Get(businessID, created.ID)
+			So(errors.Is(err, wave.ErrNotFound), ShouldBeTrue)
+		})
+
+		Convey("Invalid customers should be rejected with field errors", func() {
+			_, _, err := client.Customers.Create(businessID, &wave.Customer{Email: wave.String("jane@example.com")})
+			var verr *wave.ValidationError
+			So(errors.As(err, &verr), ShouldBeTrue)
+			So(verr.Fields["name"], ShouldResemble, []string{"This field is required."})
+
+			_, _, err = client.Customers.Update(businessID, created.ID, &wave.Customer{Currency: &wave.Currency{Code: wave.String("XXX")}})
+			So(errors.As(err, &verr), ShouldBeTrue)
+			So(verr.Fields["currency"], ShouldNotBeEmpty)
+		})
+
+		Convey("Customers of a missing business should not be found", func() {
+			_, _, err := client.Customers.List("missing", nil)
+			So(errors.Is(err, wave.ErrNotFound), ShouldBeTrue)
+		})
+	})
+}
+
+func TestAccounts(t *testing.T) {
+	Convey("Accounts should fill in their defaults", t, func() {
+		setUp()
+		defer tearDown()
+
+		account, _, err := client.Accounts.Create(businessID, &wave.Account{
+			Name:        wave.String("Sales"),
+			AccountType: wave.String("income"),
+		})
+		So(err, ShouldBeNil)
+		So(*account.ID, ShouldBeGreaterThan, 0)
+		So(*account.Active, ShouldBeTrue)
+		So(*account.CanDelete, ShouldBeTrue)
+		So(*account.Currency.Code, ShouldEqual, "USD")
+
+		accounts, _, err := client.Accounts.List(businessID)
+		So(err, ShouldBeNil)
+		So(len(accounts), ShouldEqual, 1)
+	})
+
+	Convey("Accounts Wave created should not be deletable", t, func() {
+		setUp()
+		defer tearDown()
+
+		account := srv.AddAccount(businessID, &wave.Account{
+			Name:        wave.String("Cash on Hand"),
+			AccountType: wave.String("asset"),
+			CanDelete:   wave.Bool(false),
+		})
+
+		replaced, _, err := client.Accounts.Replace(businessID, uint64(*account.ID), &wave.Account{
+			Name:        wave.String("Petty Cash"),
+			AccountType: wave.String("asset"),
+			CanDelete:   wave.Bool(true),
+		})
+		So(err, ShouldBeNil)
+		So(*replaced.CanDelete, ShouldBeFalse)
+
+		_, err = client.Accounts.Delete(businessID, uint64(*account.ID))
+		So(errors.Is(err, wave.ErrValidation), ShouldBeTrue)
+	})
+}
+
+func TestProducts(t *testing.T) {
+	Convey("Products should refer to the accounts of the business", t, func() {
+		setUp()
+		defer tearDown()
+
+		income := srv.AddAccount(businessID, &wave.Account{Name: wave.String("Sales"), AccountType: wave.String("income")})
+		created, _, err := client.Products.Create(businessID, &wave.Product{
+			Name:          wave.String("Widget"),
+			Price:         wave.Amount("19.99"),
+			IncomeAccount: &wave.Account{ID: income.ID},
+		})
+		So(err, ShouldBeNil)
+		So(created.Price.String(), ShouldEqual, "19.99")
+
+		product, _, err := client.Products.Get(businessID, *created.ID, nil)
+		So(err, ShouldBeNil)
+		So(*product.IncomeAccount.URL, ShouldEqual, *income.URL)
+		So(product.IncomeAccount.Name, ShouldBeNil)
+
+		product, _, err = client.Products.Get(businessID, *created.ID, &wave.ProductGetOptions{EmbedAccounts: true})
+		So(err, ShouldBeNil)
+		So(*product.IncomeAccount.Name, ShouldEqual, "Sales")
+
+		_, _, err = client.Products.Create(businessID, &wave.Product{
+			Name:           wave.String("Gadget"),
+			ExpenseAccount: &wave.Account{ID: wave.Int(12345)},
+		})
+		var verr *wave.ValidationError
+		So(errors.As(err, &verr), ShouldBeTrue)
+		So(verr.Fields["expense_account"], ShouldNotBeEmpty)
+	})
+}
+
+func TestPagination(t *testing.T) {
+	Convey("Paginated LIST endpoints should link to the other pages", t, func() {
+		setUp()
+		defer tearDown()
+
+		for i := 1; i <= 25; i++ {
+			srv.AddCustomer(businessID, &wave.Customer{Name: wave.String(fmt.Sprint(i))})
+		}
+
+		customers, resp, err := client.Customers.List(businessID, &wave.CustomerListOptions{PageOptions: wave.PageOptions{Page: 2}})
+		So(err, ShouldBeNil)
+		So(len(customers), ShouldEqual, DefaultPageSize)
+		So(*customers[0].Name, ShouldEqual, "11")
+		So(resp.TotalCount, ShouldEqual, 25)
+		So(resp.NextPage, ShouldEqual, 3)
+		So(resp.PreviousPage, ShouldEqual, 1)
+		So(resp.FirstPage, ShouldEqual, 1)
+		So(resp.LastPage, ShouldEqual, 3)
+
+		Convey("The last page should not link to a next page", func() {
+			customers, resp, err := client.Customers.List(businessID, &wave.CustomerListOptions{PageOptions: wave.PageOptions{Page: 3}})
+			So(err, ShouldBeNil)
+			So(len(customers), ShouldEqual, 5)
+			So(resp.NextPage, ShouldEqual, 0)
+		})
+
+		Convey("ListIter should walk every page", func() {
+			it := client.Customers.ListIter(businessID, &wave.CustomerListOptions{PageOptions: wave.PageOptions{PageSize: 7}})
+			count := 0
+			for it.Next(context.Background()) {
+				count++
+			}
+			So(it.Err(), ShouldBeNil)
+			So(count, ShouldEqual, 25)
+		})
+
+		Convey("A page past the end should not be found", func() {
+			_, _, err := client.Customers.List(businessID, &wave.CustomerListOptions{PageOptions: wave.PageOptions{Page: 4}})
+			So(errors.Is(err, wave.ErrNotFound), ShouldBeTrue)
+		})
+	})
+}
+
+func TestUser(t *testing.T) {
+	Convey("The user should list the businesses and accept updates", t, func() {
+		setUp()
+		defer tearDown()
+
+		user, _, err := client.Users.Get()
+		So(err, ShouldBeNil)
+		So(user.FullName(), ShouldEqual, "Test User")
+		So(len(user.Businesses), ShouldEqual, 1)
+		So(*user.Businesses[0].ID, ShouldEqual, businessID)
+
+		user, _, err = client.Users.Update(&wave.User{FirstName: wave.String("Jane")})
+		So(err, ShouldBeNil)
+		So(user.FullName(), ShouldEqual, "Jane User")
+	})
+}
+
+func TestCurrenciesAndCountries(t *testing.T) {
+	Convey("Currencies and countries should be served read-only", t, func() {
+		setUp()
+		defer tearDown()
+
+		currency, _, err := client.Currencies.Get("CAD")
+		So(err, ShouldBeNil)
+		So(*currency.Name, ShouldEqual, "Canadian dollar")
+
+		srv.AddCurrency(wave.Currency{Code: wave.String("CHF"), Symbol: wave.String("CHF"), Name: wave.String("Swiss franc")})
+		currencies, _, err := client.Currencies.List()
+		So(err, ShouldBeNil)
+		So(len(currencies), ShouldEqual, 6)
+
+		country, _, err := client.Countries.Get("CA")
+		So(err, ShouldBeNil)
+		So(*country.CurrencyCode, ShouldEqual, "CAD")
+		So(len(country.Provinces), ShouldBeGreaterThan, 0)
+
+		_, _, err = client.Countries.Get("ZZ")
+		So(errors.Is(err, wave.ErrNotFound), ShouldBeTrue)
+	})
+}
+
+func TestFail(t *testing.T) {
+	Convey("Injected failures should be returned for matching requests", t, func() {
+		setUp()
+		defer tearDown()
+
+		srv.Fail(Failure{
+			Method: "GET",
+			Path:   "/businesses/*/customers/",
+			Status: http.StatusTooManyRequests,
+			Header: http.Header{"Retry-After": {"2"}},
+		})
+
+		_, _, err := client.Customers.List(businessID, nil)
+		var rate *wave.RateLimitError
+		So(errors.As(err, &rate), ShouldBeTrue)
+		So(rate.RetryAfter, ShouldEqual, 2*time.Second)
+		So(rate.Err.Message, ShouldEqual, "Too Many Requests")
+
+		_, _, err = client.Customers.List(businessID, nil)
+		So(err, ShouldBeNil)
+
+		Convey("Repeated failures should last until cleared", func() {
+			srv.Fail(Failure{Path: "/user/", Status: http.StatusServiceUnavailable, Times: -1})
+			for i := 0; i < 3; i++ {
+				_, _, err := client.Users.Get()
+				So(errors.Is(err, wave.ErrServer), ShouldBeTrue)
+			}
+			srv.ClearFailures()
+			_, _, err := client.Users.Get()
+			So(err, ShouldBeNil)
+		})
+
+		Convey("A custom body should be sent as is", func() {
+			srv.Fail(Failure{Path: "/user/", Status: http.StatusBadGateway, Body: "<html>Bad Gateway</html>"})
+			_, _, err := client.Users.Get()
+			var eresp *wave.ErrorResponse
+			So(errors.As(err, &eresp), ShouldBeTrue)
+			So(string(eresp.Body), ShouldEqual, "<html>Bad Gateway</html>")
+		})
+	})
+
+	Convey("Requests without the access token should be unauthorized", t, func() {
+		setUp()
+		defer tearDown()
+
+		srv.AccessToken = "secret"
+		_, _, err := client.Users.Get()
+		So(errors.Is(err, wave.ErrUnauthorized), ShouldBeTrue)
+	})
+}
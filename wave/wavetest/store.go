@@ -0,0 +1,574 @@
+// Copyright (c) 2013, Nick Presta
+// All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wavetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NickPresta/gowave/wave"
+)
+
+// serverKeys are the fields of every record which are set by the server and
+// ignored in request bodies.
+var serverKeys = []string{"id", "url", "date_created", "date_modified"}
+
+// resource describes a kind of record kept by the server.
+type resource struct {
+	name      string // the path segment, such as "customers"
+	singular  string
+	numeric   bool // whether IDs are numbers rather than strings
+	paged     bool // whether the LIST endpoint is paginated
+	deletable bool
+	required  []string
+	readOnly  []string // fields which can only be set when seeding
+
+	// prepare, if set, checks and fills in a record before it is saved,
+	// adding any problems to fields.
+	prepare func(s *Server, businessID string, data map[string]interface{}, fields map[string][]string)
+
+	// present, if set, adjusts a copy of a record before it is sent.
+	present func(s *Server, businessID string, data map[string]interface{}, query url.Values)
+}
+
+var (
+	businessResource = &resource{
+		name:     "businesses",
+		singular: "business",
+		paged:    true,
+		required: []string{"company_name"},
+		prepare:  prepareBusiness,
+	}
+	accountResource = &resource{
+		name:      "accounts",
+		singular:  "account",
+		numeric:   true,
+		deletable: true,
+		required:  []string{"name", "account_type"},
+		readOnly:  []string{"can_delete"},
+		prepare:   prepareAccount,
+	}
+	customerResource = &resource{
+		name:      "customers",
+		singular:  "customer",
+		numeric:   true,
+		paged:     true,
+		deletable: true,
+		required:  []string{"name"},
+		prepare:   prepareCustomer,
+	}
+	productResource = &resource{
+		name:      "products",
+		singular:  "product",
+		numeric:   true,
+		paged:     true,
+		deletable: true,
+		required:  []string{"name"},
+		prepare:   prepareProduct,
+		present:   presentProduct,
+	}
+
+	// childResources are the resources which belong to a business.
+	childResources = []*resource{accountResource, customerResource, productResource}
+)
+
+// path returns the path of the LIST endpoint of the resource.
+func (res *resource) path(businessID string) string {
+	if res == businessResource {
+		return "/businesses/"
+	}
+	return "/businesses/" + businessID + "/" + res.name + "/"
+}
+
+// record is a stored resource, kept as the JSON object the API sends.
+type record struct {
+	id   string
+	data map[string]interface{}
+}
+
+// collection holds records in the order they were created.
+type collection struct {
+	records []*record
+}
+
+func (c *collection) find(id string) *record {
+	for _, rec := range c.records {
+		if rec.id == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+func (c *collection) remove(rec *record) {
+	for i, r := range c.records {
+		if r == rec {
+			c.records = append(c.records[:i:i], c.records[i+1:]...)
+			return
+		}
+	}
+}
+
+// create validates data and adds it to coll as a new record, returning the
+// problems with each field if it is invalid.
+func (s *Server) create(res *resource, businessID string, coll *collection, data map[string]interface{}) (*record, map[string][]string) {
+	for _, key := range serverKeys {
+		delete(data, key)
+	}
+	if fields := s.check(res, businessID, data); fields != nil {
+		return nil, fields
+	}
+
+	s.nextID++
+	id := strconv.FormatUint(s.nextID, 10)
+	if res.numeric {
+		data["id"] = json.Number(id)
+	} else {
+		data["id"] = id
+	}
+	data["url"] = s.URL + res.path(businessID) + id + "/"
+	now := timestamp()
+	data["date_created"] = now
+	data["date_modified"] = now
+
+	rec := &record{id: id, data: data}
+	coll.records = append(coll.records, rec)
+	if res == businessResource {
+		s.children[id] = make(map[*resource]*collection)
+		for _, child := range childResources {
+			s.children[id][child] = new(collection)
+		}
+	}
+	return rec, nil
+}
+
+// save replaces the fields of rec with data, or only those given in data if
+// partial is true, returning the problems with each field if the result is
+// invalid.
+func (s *Server) save(res *resource, businessID string, rec *record, data map[string]interface{}, partial bool) map[string][]string {
+	kept := append(append([]string(nil), serverKeys...), res.readOnly...)
+	for _, key := range kept {
+		delete(data, key)
+	}
+
+	merged := data
+	if partial {
+		merged = copyObject(rec.data)
+		for key, v := range data {
+			merged[key] = v
+		}
+	} else {
+		for _, key := range kept {
+			if v, ok := rec.data[key]; ok {
+				merged[key] = v
+			}
+		}
+	}
+	for key, v := range merged {
+		if v == nil {
+			delete(merged, key)
+		}
+	}
+	if fields := s.check(res, businessID, merged); fields != nil {
+		return fields
+	}
+	merged["date_modified"] = timestamp()
+	rec.data = merged
+	return nil
+}
+
+// check reports the problems with each field of data, or nil if it is valid.
+func (s *Server) check(res *resource, businessID string, data map[string]interface{}) map[string][]string {
+	fields := make(map[string][]string)
+	for _, key := range res.required {
+		if isBlank(data[key]) {
+			fields[key] = append(fields[key], "This field is required.")
+		}
+	}
+	if res.prepare != nil {
+		res.prepare(s, businessID, data, fields)
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// present returns the record as it is sent for a request with the given
+// query parameters.
+func (s *Server) present(res *resource, businessID string, rec *record, query url.Values) map[string]interface{} {
+	data := copyObject(rec.data)
+	if res.present != nil {
+		res.present(s, businessID, data, query)
+	}
+	return data
+}
+
+// paginate returns the page of items asked for by r and sets the headers
+// describing it, in the form read by wave.Response. It responds with a 404
+// and returns false if there is no such page.
+func (s *Server) paginate(w http.ResponseWriter, r *http.Request, items []interface{}) ([]interface{}, bool) {
+	query := r.URL.Query()
+	page, size := 1, DefaultPageSize
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusNotFound, "Invalid page.", nil)
+			return nil, false
+		}
+		page = n
+	}
+	if n, err := strconv.Atoi(query.Get("page_size")); err == nil && n > 0 {
+		size = n
+	}
+	last := (len(items) + size - 1) / size
+	if last == 0 {
+		last = 1
+	}
+	if page > last {
+		writeError(w, http.StatusNotFound, "Invalid page.", nil)
+		return nil, false
+	}
+
+	var links []string
+	link := func(page int, rel string) {
+		query.Set("page", strconv.Itoa(page))
+		links = append(links, fmt.Sprintf(`<%v%v?%v>; rel="%v"`, s.URL, r.URL.Path, query.Encode(), rel))
+	}
+	if page < last {
+		link(page+1, "next")
+		link(last, "last")
+	}
+	if page > 1 {
+		link(1, "first")
+		link(page-1, "prev")
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+
+	start := (page - 1) * size
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], true
+}
+
+func prepareBusiness(s *Server, businessID string, data map[string]interface{}, fields map[string][]string) {
+	if code, ok := data["primary_currency_code"].(string); ok && s.currency(code) == nil {
+		fields["primary_currency_code"] = append(fields["primary_currency_code"], "Unknown currency code.")
+	}
+}
+
+func prepareAccount(s *Server, businessID string, data map[string]interface{}, fields map[string][]string) {
+	for key, value := range map[string]bool{"active": true, "can_delete": true, "is_payment": false} {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+	s.expandCurrency(businessID, data, fields)
+}
+
+func prepareCustomer(s *Server, businessID string, data map[string]interface{}, fields map[string][]string) {
+	s.expandCurrency(businessID, data, fields)
+}
+
+// productAccounts are the fields of a product which refer to an account.
+var productAccounts = []string{"income_account", "expense_account"}
+
+func prepareProduct(s *Server, businessID string, data map[string]interface{}, fields map[string][]string) {
+	accounts := s.children[businessID][accountResource]
+	for _, key := range productAccounts {
+		ref, ok := data[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(ref["id"])
+		if accounts.find(id) == nil {
+			fields[key] = append(fields[key], fmt.Sprintf(`Invalid pk "%v" - object does not exist.`, id))
+			continue
+		}
+		// Only the reference is kept, so that the account is always
+		// presented as it is now.
+		data[key] = map[string]interface{}{"id": json.Number(id)}
+	}
+}
+
+// presentProduct fills in the accounts of a product, which are only embedded
+// in full when the request asks for it with embed_accounts.
+func presentProduct(s *Server, businessID string, data map[string]interface{}, query url.Values) {
+	accounts := s.children[businessID][accountResource]
+	embed, _ := strconv.ParseBool(query.Get("embed_accounts"))
+	for _, key := range productAccounts {
+		ref, ok := data[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		account := accounts.find(fmt.Sprint(ref["id"]))
+		switch {
+		case account == nil:
+			// The account has since been deleted.
+			delete(data, key)
+		case embed:
+			data[key] = copyObject(account.data)
+		default:
+			data[key] = map[string]interface{}{"id": account.data["id"], "url": account.data["url"]}
+		}
+	}
+}
+
+// expandCurrency replaces the currency of data, given by its code, with the
+// full currency. It defaults to the primary currency of the business.
+func (s *Server) expandCurrency(businessID string, data map[string]interface{}, fields map[string][]string) {
+	var code string
+	switch v := data["currency"].(type) {
+	case map[string]interface{}:
+		code, _ = v["code"].(string)
+	case string:
+		code = v
+	case nil:
+		if business := s.businesses.find(businessID); business != nil {
+			code, _ = business.data["primary_currency_code"].(string)
+		}
+	}
+	if code == "" {
+		delete(data, "currency")
+		return
+	}
+	c := s.currency(code)
+	if c == nil {
+		fields["currency"] = append(fields["currency"], "Unknown currency code.")
+		return
+	}
+	data["currency"] = toObject(c)
+}
+
+func (s *Server) currency(code string) *wave.Currency {
+	for i, c := range s.currencies {
+		if c.Code != nil && strings.EqualFold(*c.Code, code) {
+			return &s.currencies[i]
+		}
+	}
+	return nil
+}
+
+// saveUser replaces the fields of the user with data, or only those given in
+// data if partial is true.
+func (s *Server) saveUser(data map[string]interface{}, partial bool) {
+	kept := append(append([]string(nil), serverKeys...), "businesses", "last_login")
+	for _, key := range kept {
+		delete(data, key)
+	}
+	if partial {
+		merged := copyObject(s.user)
+		for key, v := range data {
+			merged[key] = v
+		}
+		data = merged
+	} else {
+		for _, key := range kept {
+			if v, ok := s.user[key]; ok {
+				data[key] = v
+			}
+		}
+	}
+	data["date_modified"] = timestamp()
+	s.user = data
+}
+
+// seed fills in the user and the currencies and countries every server
+// starts with.
+func (s *Server) seed() {
+	now := timestamp()
+	s.user = map[string]interface{}{
+		"id":         "1",
+		"url":        s.URL + "/user/",
+		"first_name": "Test",
+		"last_name":  "User",
+		"emails": []interface{}{
+			map[string]interface{}{"email": "test@example.com", "is_verified": true, "is_default": true},
+		},
+		"date_created":  now,
+		"date_modified": now,
+		"last_login":    now,
+	}
+
+	for _, c := range [][3]string{
+		{"CAD", "$", "Canadian dollar"},
+		{"EUR", "€", "Euro"},
+		{"GBP", "£", "Pound sterling"},
+		{"JPY", "¥", "Japanese yen"},
+		{"USD", "$", "United States dollar"},
+	} {
+		s.addCurrency(wave.Currency{Code: wave.String(c[0]), Symbol: wave.String(c[1]), Name: wave.String(c[2])})
+	}
+
+	for _, c := range []struct {
+		code, name, currency string
+		provinces            [][2]string
+	}{
+		{"CA", "Canada", "CAD", [][2]string{{"Alberta", "CA-AB"}, {"British Columbia", "CA-BC"}, {"Ontario", "CA-ON"}, {"Quebec", "CA-QC"}}},
+		{"GB", "United Kingdom", "GBP", nil},
+		{"US", "United States", "USD", [][2]string{{"California", "US-CA"}, {"New York", "US-NY"}, {"Texas", "US-TX"}}},
+	} {
+		country := wave.Country{Name: wave.String(c.name), CountryCode: wave.String(c.code), CurrencyCode: wave.String(c.currency)}
+		for _, p := range c.provinces {
+			country.Provinces = append(country.Provinces, wave.Province{Name: wave.String(p[0]), Slug: wave.String(p[1])})
+		}
+		s.addCountry(country)
+	}
+}
+
+// AddBusiness adds a business, as if it had been created through the API,
+// and returns it with its ID, URL and timestamps filled in. It panics if the
+// business is invalid.
+func (s *Server) AddBusiness(business *wave.Business) *wave.Business {
+	out := new(wave.Business)
+	s.add(businessResource, "", business, out)
+	return out
+}
+
+// AddAccount adds an account to a business, as if it had been created through
+// the API, and returns it with its ID, URL and timestamps filled in. Unlike
+// through the API, CanDelete may be set to false, as it is for the accounts
+// Wave creates for every business. It panics if there is no such business or
+// the account is invalid.
+func (s *Server) AddAccount(businessID string, account *wave.Account) *wave.Account {
+	out := new(wave.Account)
+	s.add(accountResource, businessID, account, out)
+	return out
+}
+
+// AddCustomer adds a customer to a business, as if it had been created
+// through the API, and returns it with its ID, URL and timestamps filled in.
+// It panics if there is no such business or the customer is invalid.
+func (s *Server) AddCustomer(businessID string, customer *wave.Customer) *wave.Customer {
+	out := new(wave.Customer)
+	s.add(customerResource, businessID, customer, out)
+	return out
+}
+
+// AddProduct adds a product to a business, as if it had been created through
+// the API, and returns it with its ID, URL and timestamps filled in. It panics
+// if there is no such business or the product is invalid.
+func (s *Server) AddProduct(businessID string, product *wave.Product) *wave.Product {
+	out := new(wave.Product)
+	s.add(productResource, businessID, product, out)
+	return out
+}
+
+func (s *Server) add(res *resource, businessID string, in, out interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coll := s.businesses
+	if res != businessResource {
+		if s.businesses.find(businessID) == nil {
+			panic(fmt.Sprintf("wavetest: no business with ID %q", businessID))
+		}
+		coll = s.children[businessID][res]
+	}
+	rec, fields := s.create(res, businessID, coll, toObject(in))
+	if fields != nil {
+		panic(fmt.Sprintf("wavetest: invalid %v: %v", res.singular, fields))
+	}
+	fromObject(s.present(res, businessID, rec, url.Values{"embed_accounts": {"true"}}), out)
+}
+
+// SetUser replaces the authenticated user. Its ID, URL and businesses are
+// kept.
+func (s *Server) SetUser(user *wave.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveUser(toObject(user), false)
+}
+
+// AddCurrency adds a currency, replacing any with the same code.
+func (s *Server) AddCurrency(currency wave.Currency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addCurrency(currency)
+}
+
+func (s *Server) addCurrency(currency wave.Currency) {
+	currency.URL = wave.String(s.URL + "/currencies/" + *currency.Code)
+	if c := s.currency(*currency.Code); c != nil {
+		*c = currency
+		return
+	}
+	s.currencies = append(s.currencies, currency)
+}
+
+// AddCountry adds a country, replacing any with the same country code.
+func (s *Server) AddCountry(country wave.Country) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addCountry(country)
+}
+
+func (s *Server) addCountry(country wave.Country) {
+	country.URL = wave.String(s.URL + "/countries/" + *country.CountryCode)
+	for i, c := range s.countries {
+		if strings.EqualFold(*c.CountryCode, *country.CountryCode) {
+			s.countries[i] = country
+			return
+		}
+	}
+	s.countries = append(s.countries, country)
+}
+
+// timestamp returns the current time as the API formats it.
+func timestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05+00:00")
+}
+
+func isBlank(v interface{}) bool {
+	s, ok := v.(string)
+	return v == nil || ok && strings.TrimSpace(s) == ""
+}
+
+func copyObject(data map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(data))
+	for key, v := range data {
+		c[key] = v
+	}
+	return c
+}
+
+// toObject converts v to the JSON object it encodes to, keeping numbers
+// exactly as they were encoded.
+func toObject(v interface{}) map[string]interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic("wavetest: " + err.Error())
+	}
+	var data map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil || data == nil {
+		return make(map[string]interface{})
+	}
+	return data
+}
+
+func fromObject(data map[string]interface{}, v interface{}) {
+	b, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		panic("wavetest: " + err.Error())
+	}
+}